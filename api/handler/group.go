@@ -8,6 +8,7 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/status"
 	"time"
 )
 
@@ -42,9 +43,11 @@ func SearchGroup(ctx *gin.Context) {
 }
 
 type getGroupMsgByPageReq struct {
-	GroupId  int64 `json:"groupId" binding:"required"`
-	Current  int64 `json:"current" binding:"required"`
-	PageSize int64 `json:"pageSize" binding:"required"`
+	GroupId  int64  `json:"groupId" binding:"required"`
+	Current  int64  `json:"current"` // before和after都为空时按页码分页
+	PageSize int64  `json:"pageSize" binding:"required"`
+	Before   string `json:"before"` // 获取该snowId之前的消息
+	After    string `json:"after"`  // 获取该snowId之后的消息
 }
 
 func GetGroupMsgByPage(ctx *gin.Context) {
@@ -66,18 +69,22 @@ func GetGroupMsgByPage(ctx *gin.Context) {
 		GroupId:  form.GroupId,
 		Current:  form.Current,
 		PageSize: form.PageSize,
+		Before:   form.Before,
+		After:    form.After,
 	})
 	if err != nil {
 		zlog.Error(err.Error())
-		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
 		return
 	}
 	utils.SuccessWithMsg(ctx, nil, reply)
 }
 
 type createGroupReq struct {
-	GroupName string `json:"groupName" binding:"required"`
-	Notice    string `json:"notice" binding:"required"`
+	GroupName  string `json:"groupName" binding:"required"`
+	Notice     string `json:"notice" binding:"required"`
+	Avatar     string `json:"avatar"`     // 群头像地址，可选
+	JoinPolicy int32  `json:"joinPolicy"` // 1自由加入 2需要管理员审批 3仅限邀请，可选，默认自由加入
 }
 
 func CreateGroup(ctx *gin.Context) {
@@ -101,9 +108,11 @@ func CreateGroup(ctx *gin.Context) {
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	reply, err := client.CreateGroup(_ctx, &proto.Group{
-		Userid:    userid.(int64),
-		GroupName: form.GroupName,
-		Notice:    form.Notice,
+		Userid:     userid.(int64),
+		GroupName:  form.GroupName,
+		Notice:     form.Notice,
+		Avatar:     form.Avatar,
+		JoinPolicy: form.JoinPolicy,
 	})
 	if err != nil {
 		zlog.Error(err.Error())
@@ -124,7 +133,8 @@ func CreateGroup(ctx *gin.Context) {
 }
 
 type addGroup struct {
-	GroupId int64 `json:"groupId" binding:"required"`
+	GroupId int64  `json:"groupId" binding:"required"`
+	Reason  string `json:"reason"` // 申请理由，加入需要审批的群聊时可选
 }
 
 func AddGroup(ctx *gin.Context) {
@@ -147,14 +157,474 @@ func AddGroup(ctx *gin.Context) {
 	client := proto.NewLogicClient(ins.Conn)
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	_, err = client.AddGroup(_ctx, &proto.AddGroupRequest{
+	reply, err := client.AddGroup(_ctx, &proto.AddGroupRequest{
+		GroupId: form.GroupId,
+		Userid:  userid.(int64),
+		Reason:  form.Reason,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		// 群聊仅限邀请加入或者已经提交过入群申请等原因需要告知用户
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type setMemberRoleReq struct {
+	GroupId  int64 `json:"groupId" binding:"required"`
+	MemberId int64 `json:"memberId" binding:"required"`
+	Role     int32 `json:"role" binding:"required"` // 1成员 2管理员
+}
+
+func SetMemberRole(ctx *gin.Context) {
+	var form setMemberRoleReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.SetMemberRole(_ctx, &proto.SetMemberRoleRequest{
+		Userid:   userid.(int64),
+		GroupId:  form.GroupId,
+		MemberId: form.MemberId,
+		Role:     form.Role,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		// 没有权限等原因需要告知用户
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type kickMemberReq struct {
+	GroupId  int64 `json:"groupId" binding:"required"`
+	MemberId int64 `json:"memberId" binding:"required"`
+}
+
+func KickMember(ctx *gin.Context) {
+	var form kickMemberReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.KickMember(_ctx, &proto.KickMemberRequest{
+		Userid:   userid.(int64),
+		GroupId:  form.GroupId,
+		MemberId: form.MemberId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type muteMemberReq struct {
+	GroupId  int64 `json:"groupId" binding:"required"`
+	MemberId int64 `json:"memberId" binding:"required"`
+	Duration int64 `json:"duration"` // 禁言时长，单位秒，为0时解除禁言
+}
+
+func MuteMember(ctx *gin.Context) {
+	var form muteMemberReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.MuteMember(_ctx, &proto.MuteMemberRequest{
+		Userid:   userid.(int64),
+		GroupId:  form.GroupId,
+		MemberId: form.MemberId,
+		Duration: form.Duration,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type transferOwnershipReq struct {
+	GroupId  int64 `json:"groupId" binding:"required"`
+	MemberId int64 `json:"memberId" binding:"required"`
+}
+
+func TransferOwnership(ctx *gin.Context) {
+	var form transferOwnershipReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.TransferOwnership(_ctx, &proto.TransferOwnershipRequest{
+		Userid:   userid.(int64),
+		GroupId:  form.GroupId,
+		MemberId: form.MemberId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type leaveGroupReq struct {
+	GroupId int64 `json:"groupId" binding:"required"`
+}
+
+func LeaveGroup(ctx *gin.Context) {
+	var form leaveGroupReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.LeaveGroup(_ctx, &proto.LeaveGroupRequest{
+		Userid:  userid.(int64),
+		GroupId: form.GroupId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type dissolveGroupReq struct {
+	GroupId int64 `json:"groupId" binding:"required"`
+}
+
+func DissolveGroup(ctx *gin.Context) {
+	var form dissolveGroupReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.DissolveGroup(_ctx, &proto.DissolveGroupRequest{
+		Userid:  userid.(int64),
 		GroupId: form.GroupId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type setJoinPolicyReq struct {
+	GroupId    int64 `json:"groupId" binding:"required"`
+	JoinPolicy int32 `json:"joinPolicy" binding:"required"` // 1自由加入 2需要管理员审批 3仅限邀请
+}
+
+func SetJoinPolicy(ctx *gin.Context) {
+	var form setJoinPolicyReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.SetJoinPolicy(_ctx, &proto.SetJoinPolicyRequest{
+		Userid:     userid.(int64),
+		GroupId:    form.GroupId,
+		JoinPolicy: form.JoinPolicy,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type createGroupInviteReq struct {
+	GroupId  int64 `json:"groupId" binding:"required"`
+	ExpireIn int64 `json:"expireIn"` // 有效时长，单位秒，可选，默认24小时
+}
+
+func CreateGroupInvite(ctx *gin.Context) {
+	var form createGroupInviteReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.CreateGroupInvite(_ctx, &proto.CreateGroupInviteRequest{
+		Userid:   userid.(int64),
+		GroupId:  form.GroupId,
+		ExpireIn: form.ExpireIn,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type joinGroupByInviteReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func JoinGroupByInvite(ctx *gin.Context) {
+	var form joinGroupByInviteReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.JoinGroupByInvite(_ctx, &proto.JoinGroupByInviteRequest{
+		Userid: userid.(int64),
+		Token:  form.Token,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type listJoinRequestsReq struct {
+	GroupId int64 `json:"groupId" binding:"required"`
+}
+
+func ListJoinRequests(ctx *gin.Context) {
+	var form listJoinRequestsReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.ListJoinRequests(_ctx, &proto.ListJoinRequestsRequest{
 		Userid:  userid.(int64),
+		GroupId: form.GroupId,
 	})
 	if err != nil {
 		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type respondJoinRequestReq struct {
+	RequestId int64 `json:"requestId" binding:"required"`
+	Approve   bool  `json:"approve"`
+}
+
+func RespondJoinRequest(ctx *gin.Context) {
+	var form respondJoinRequestReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
 		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
 		return
 	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.RespondJoinRequest(_ctx, &proto.RespondJoinRequestRequest{
+		Userid:    userid.(int64),
+		RequestId: form.RequestId,
+		Approve:   form.Approve,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
 	utils.SuccessWithMsg(ctx, nil, nil)
 }
+
+// updateGroupReq 不传的字段不修改，群公告和群头像传空字符串时清空
+type updateGroupReq struct {
+	GroupId   int64   `json:"groupId" binding:"required"`
+	GroupName *string `json:"groupName"`
+	Notice    *string `json:"notice"`
+	Avatar    *string `json:"avatar"`
+}
+
+func UpdateGroup(ctx *gin.Context) {
+	var form updateGroupReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.UpdateGroup(_ctx, &proto.UpdateGroupRequest{
+		Userid:    userid.(int64),
+		GroupId:   form.GroupId,
+		GroupName: form.GroupName,
+		Notice:    form.Notice,
+		Avatar:    form.Avatar,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
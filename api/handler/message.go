@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"axisChat/api/rpc"
+	"axisChat/api/utils"
+	"axisChat/proto"
+	"axisChat/utils/zlog"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+type recallMessageReq struct {
+	SnowId string `json:"snowId" binding:"required"`
+}
+
+func RecallMessage(ctx *gin.Context) {
+	var form recallMessageReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.RecallMessage(_ctx, &proto.RecallMessageRequest{
+		Userid: userid.(int64),
+		SnowId: form.SnowId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		// 超过撤回时间或者撤回他人消息等原因需要告知用户
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type editMessageReq struct {
+	SnowId  string `json:"snowId" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+func EditMessage(ctx *gin.Context) {
+	var form editMessageReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.EditMessage(_ctx, &proto.EditMessageRequest{
+		Userid:  userid.(int64),
+		SnowId:  form.SnowId,
+		Content: form.Content,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type reactionReq struct {
+	SnowId string `json:"snowId" binding:"required"`
+	Emoji  string `json:"emoji" binding:"required"`
+}
+
+func AddReaction(ctx *gin.Context) {
+	dealReaction(ctx, true)
+}
+
+func RemoveReaction(ctx *gin.Context) {
+	dealReaction(ctx, false)
+}
+
+// dealReaction 添加和取消表情回应的请求参数相同
+func dealReaction(ctx *gin.Context, add bool) {
+	var form reactionReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	request := &proto.ReactionRequest{
+		Userid: userid.(int64),
+		SnowId: form.SnowId,
+		Emoji:  form.Emoji,
+	}
+	if add {
+		_, err = client.AddReaction(_ctx, request)
+	} else {
+		_, err = client.RemoveReaction(_ctx, request)
+	}
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+func ListMentions(ctx *gin.Context) {
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.ListMentions(_ctx, &proto.ListMentionsRequest{
+		Userid: userid.(int64),
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type resolveMentionsReq struct {
+	GroupId int64  `json:"groupId" binding:"required"`
+	SnowId  string `json:"snowId"` // 不为空时只处理该条消息中的@提及
+}
+
+func ResolveMentions(ctx *gin.Context) {
+	var form resolveMentionsReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.ResolveMentions(_ctx, &proto.ResolveMentionsRequest{
+		Userid:  userid.(int64),
+		GroupId: form.GroupId,
+		SnowId:  form.SnowId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type markReadReq struct {
+	Type     string `json:"type" binding:"required,oneof=friend group"`
+	ObjectId int64  `json:"objectId" binding:"required"` // 私聊为好友id，群聊为群聊id
+}
+
+func MarkRead(ctx *gin.Context) {
+	var form markReadReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.MarkRead(_ctx, &proto.MarkReadRequest{
+		Userid:   userid.(int64),
+		Type:     form.Type,
+		ObjectId: form.ObjectId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type listConversationsReq struct {
+	Cursor string `json:"cursor"` // 上一页返回的nextCursor，为空时获取第一页
+	Limit  int32  `json:"limit" binding:"min=0,max=100"`
+}
+
+func ListConversations(ctx *gin.Context) {
+	var form listConversationsReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.ListConversations(_ctx, &proto.ListConversationsRequest{
+		Userid: userid.(int64),
+		Cursor: form.Cursor,
+		Limit:  form.Limit,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type conversationMuteReq struct {
+	Type     string `json:"type" binding:"required,oneof=friend group"`
+	ObjectId int64  `json:"objectId" binding:"required"` // 私聊为好友id，群聊为群聊id
+	Muted    bool   `json:"muted"`
+}
+
+func SetConversationMute(ctx *gin.Context) {
+	var form conversationMuteReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.SetConversationMute(_ctx, &proto.SetConversationMuteRequest{
+		Userid:   userid.(int64),
+		Type:     form.Type,
+		ObjectId: form.ObjectId,
+		Muted:    form.Muted,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type searchMessagesReq struct {
+	Keyword     string `json:"keyword" binding:"required"`
+	Type        string `json:"type" binding:"omitempty,oneof=friend group"`
+	ObjectId    int64  `json:"objectId" binding:"required_with=Type"` // 私聊为好友id，群聊为群聊id
+	FromId      int64  `json:"fromId"`
+	MessageType string `json:"messageType"`
+	Start       string `json:"start"` // RFC3339格式
+	End         string `json:"end"`
+	Cursor      string `json:"cursor"` // 上一页返回的nextCursor，为空时获取第一页
+	Limit       int32  `json:"limit" binding:"min=0,max=50"`
+}
+
+func SearchMessages(ctx *gin.Context) {
+	var form searchMessagesReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.SearchMessages(_ctx, &proto.SearchMessagesRequest{
+		Userid:      userid.(int64),
+		Keyword:     form.Keyword,
+		Type:        form.Type,
+		ObjectId:    form.ObjectId,
+		FromId:      form.FromId,
+		MessageType: form.MessageType,
+		Start:       form.Start,
+		End:         form.End,
+		Cursor:      form.Cursor,
+		Limit:       form.Limit,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type messageTTLReq struct {
+	Type     string `json:"type" binding:"required,oneof=friend group"`
+	ObjectId int64  `json:"objectId" binding:"required"` // 私聊为好友id，群聊为群聊id
+	Ttl      int64  `json:"ttl" binding:"min=0"`         // 消息存活时间，单位秒，0表示关闭
+}
+
+func SetMessageTTL(ctx *gin.Context) {
+	var form messageTTLReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.SetMessageTTL(_ctx, &proto.SetMessageTTLRequest{
+		Userid:   userid.(int64),
+		Type:     form.Type,
+		ObjectId: form.ObjectId,
+		Ttl:      form.Ttl,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithRpcError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
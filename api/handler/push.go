@@ -18,14 +18,13 @@ import (
 	"time"
 )
 
+// pushReq 发送方和接收方的用户名、头像由logic层根据用户信息填充
 type pushReq struct {
-	FriendId     int64  `json:"friendId" binding:"required"`
-	Content      string `json:"content" binding:"required"`
-	MessageType  string `json:"messageType" binding:"required"`
-	FromUsername string `json:"fromUsername" binding:"required"`
-	FriendName   string `json:"friendName" binding:"required"`
-	Avatar       string `json:"avatar" binding:"required"`
-	Watermark    int64  `json:"watermark" binding:"required"`
+	FriendId    int64  `json:"friendId" binding:"required"`
+	Content     string `json:"content" binding:"required"`
+	MessageType string `json:"messageType" binding:"required"`
+	Watermark   int64  `json:"watermark" binding:"required"`
+	ReplyTo     string `json:"replyTo"` // 引用回复的消息snowId，可选
 }
 
 func Push(ctx *gin.Context) {
@@ -48,35 +47,35 @@ func Push(ctx *gin.Context) {
 	client := proto.NewLogicClient(ins.Conn)
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	_, err = client.Push(_ctx, &proto.PushRequest{
+	reply, err := client.Push(_ctx, &proto.PushRequest{
 		Msg: &proto.ChatMessage{
-			Userid:       userid.(int64),
-			FriendId:     form.FriendId,
-			Content:      form.Content,
-			MessageType:  form.MessageType,
-			FromUsername: form.FromUsername,
-			FriendName:   form.FriendName,
-			Avatar:       form.Avatar,
-			CreateAt:     time.Now().Format(time.RFC3339),
-			Watermark:    form.Watermark,
+			Userid:      userid.(int64),
+			FriendId:    form.FriendId,
+			Content:     form.Content,
+			MessageType: form.MessageType,
+			CreateAt:    time.Now().Format(time.RFC3339),
+			Watermark:   form.Watermark,
+			ReplyTo:     form.ReplyTo,
 		},
 	})
 	if err != nil {
 		zlog.Error(err.Error())
-		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		// 对方不是好友或者存在拉黑关系等原因需要告知用户
+		utils.FailWithRpcError(ctx, err)
 		return
 	}
-	utils.SuccessWithMsg(ctx, nil, "success")
+	utils.SuccessWithMsg(ctx, nil, reply)
 }
 
+// pushRoomReq 发送方的用户名、头像以及群名称由logic层填充
 type pushRoomReq struct {
-	GroupId      int64  `json:"groupId" binding:"required"`
-	Content      string `json:"content" binding:"required"`
-	MessageType  string `json:"messageType" binding:"required"`
-	FromUsername string `json:"fromUsername" binding:"required"`
-	GroupName    string `json:"groupName" binding:"required"`
-	Avatar       string `json:"avatar" binding:"required"`
-	Watermark    int64  `json:"watermark" binding:"required"`
+	GroupId     int64   `json:"groupId" binding:"required"`
+	Content     string  `json:"content" binding:"required"`
+	MessageType string  `json:"messageType" binding:"required"`
+	Watermark   int64   `json:"watermark" binding:"required"`
+	ReplyTo     string  `json:"replyTo"`    // 引用回复的消息snowId，可选
+	Mentions    []int64 `json:"mentions"`   // @提及的用户id，可选
+	MentionAll  bool    `json:"mentionAll"` // 是否@所有人，只有群主和管理员可以使用
 }
 
 func PushRoom(ctx *gin.Context) {
@@ -99,25 +98,26 @@ func PushRoom(ctx *gin.Context) {
 	client := proto.NewLogicClient(ins.Conn)
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	_, err = client.PushRoom(_ctx, &proto.PushRoomRequest{
+	reply, err := client.PushRoom(_ctx, &proto.PushRoomRequest{
 		Msg: &proto.ChatMessage{
-			Userid:       userid.(int64),
-			GroupId:      form.GroupId,
-			Content:      form.Content,
-			MessageType:  form.MessageType,
-			FromUsername: form.FromUsername,
-			GroupName:    form.GroupName,
-			CreateAt:     time.Now().Format(time.RFC3339),
-			Avatar:       form.Avatar,
-			Watermark:    form.Watermark,
+			Userid:      userid.(int64),
+			GroupId:     form.GroupId,
+			Content:     form.Content,
+			MessageType: form.MessageType,
+			CreateAt:    time.Now().Format(time.RFC3339),
+			Watermark:   form.Watermark,
+			ReplyTo:     form.ReplyTo,
+			Mentions:    form.Mentions,
+			MentionAll:  form.MentionAll,
 		},
 	})
 	if err != nil {
 		zlog.Error(err.Error())
-		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		// 不在群聊中、被禁言或者@提及的成员不在群聊中等原因需要告知用户
+		utils.FailWithRpcError(ctx, err)
 		return
 	}
-	utils.SuccessWithMsg(ctx, nil, "success")
+	utils.SuccessWithMsg(ctx, nil, reply)
 }
 
 type pushRoomCountReq struct {
@@ -182,21 +182,16 @@ func PushRoomInfo(ctx *gin.Context) {
 
 type reqPushImgMsg struct {
 	// 公共部分
-	Content      *multipart.FileHeader `form:"content" binding:"required"`
-	Ty           string                `form:"type" binding:"required"`   //图片对象是group还是friend
-	FromId       int64                 `form:"fromId" binding:"required"` //如果是群聊则是groupId，否则是图片发起方的id
-	FromUsername string                `form:"fromUsername" binding:"required"`
-	Avatar       string                `form:"avatar" binding:"required"`
-	AccessToken  string                `form:"accessToken" binding:"required"`
-	Watermark    int64                 `form:"watermark" binding:"required"`
+	Content     *multipart.FileHeader `form:"content" binding:"required"`
+	Ty          string                `form:"type" binding:"required,oneof=group friend"` //图片对象是group还是friend
+	AccessToken string                `form:"accessToken" binding:"required"`
+	Watermark   int64                 `form:"watermark" binding:"required"`
 
 	// 群聊消息独有
-	GroupId   int64  `form:"groupId"`
-	GroupName string `form:"groupName"`
+	GroupId int64 `form:"groupId"`
 
 	// 私聊消息独有
-	FriendId   int64  `form:"friendId"`
-	FriendName string `form:"friendName"`
+	FriendId int64 `form:"friendId"`
 }
 
 func PushImgMsg(ctx *gin.Context) {
@@ -224,6 +219,12 @@ func PushImgMsg(ctx *gin.Context) {
 		return
 	}
 
+	// 群聊图片按groupId存放，私聊图片按发送方id存放，都不使用客户端提交的id
+	fromId := reply.User.Id
+	if form.Ty == "group" {
+		fromId = form.GroupId
+	}
+
 	// 存放图片
 	f, _ := form.Content.Open()
 	extendName := strings.Split(form.Content.Filename, ".")
@@ -239,7 +240,7 @@ func PushImgMsg(ctx *gin.Context) {
 		return
 	}
 	conf := config.GetConfig()
-	filePath := conf.Api.Api.ChatImgDir + form.Ty + "/" + fmt.Sprintf("%d/", form.FromId)
+	filePath := conf.Api.Api.ChatImgDir + form.Ty + "/" + fmt.Sprintf("%d/", fromId)
 	err = os.MkdirAll(filePath, os.ModePerm)
 	if err != nil {
 		zlog.Error(fmt.Sprintf("创建聊天图片存放目录失败:%v", err))
@@ -258,7 +259,7 @@ func PushImgMsg(ctx *gin.Context) {
 		return
 	}
 	//example: https://localhost:8090/api/images/group/1/8dwekdkjfl.png
-	imgUrl := fmt.Sprintf("%s/api/images/%s/%d/%s", conf.Api.Api.Host, form.Ty, form.FromId, fileName)
+	imgUrl := fmt.Sprintf("%s/api/images/%s/%d/%s", conf.Api.Api.Host, form.Ty, fromId, fileName)
 
 	// 将图片消息写入Kafka对应的topic
 	switch form.Ty {
@@ -266,39 +267,33 @@ func PushImgMsg(ctx *gin.Context) {
 		defer cancel()
 		_, err = client.PushRoom(_ctx, &proto.PushRoomRequest{
 			Msg: &proto.ChatMessage{
-				Userid:       reply.User.Id,
-				GroupId:      form.GroupId,
-				Content:      imgUrl,
-				MessageType:  "image",
-				FromUsername: form.FromUsername,
-				GroupName:    form.GroupName,
-				CreateAt:     time.Now().Format(time.RFC3339),
-				Avatar:       form.Avatar,
-				Watermark:    form.Watermark,
+				Userid:      reply.User.Id,
+				GroupId:     form.GroupId,
+				Content:     imgUrl,
+				MessageType: "image",
+				CreateAt:    time.Now().Format(time.RFC3339),
+				Watermark:   form.Watermark,
 			},
 		})
 		if err != nil {
 			zlog.Error(err.Error())
-			utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+			utils.FailWithRpcError(ctx, err)
 			return
 		}
 	case "friend":
 		_, err = client.Push(_ctx, &proto.PushRequest{
 			Msg: &proto.ChatMessage{
-				Userid:       reply.User.Id,
-				FriendId:     form.FriendId,
-				Content:      imgUrl,
-				MessageType:  "image",
-				FromUsername: form.FromUsername,
-				FriendName:   form.FriendName,
-				Avatar:       form.Avatar,
-				CreateAt:     time.Now().Format(time.RFC3339),
-				Watermark:    form.Watermark,
+				Userid:      reply.User.Id,
+				FriendId:    form.FriendId,
+				Content:     imgUrl,
+				MessageType: "image",
+				CreateAt:    time.Now().Format(time.RFC3339),
+				Watermark:   form.Watermark,
 			},
 		})
 		if err != nil {
 			zlog.Error(err.Error())
-			utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+			utils.FailWithRpcError(ctx, err)
 			return
 		}
 	}
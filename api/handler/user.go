@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/status"
 	"io/ioutil"
 	"mime/multipart"
 	"os"
@@ -311,6 +312,11 @@ func SearchUser(ctx *gin.Context) {
 		utils.FailWithMsg(ctx, "参数校验失败")
 		return
 	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
 	ins, err := rpc.GetLogicRpcInstance()
 	if err != nil {
 		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
@@ -321,6 +327,7 @@ func SearchUser(ctx *gin.Context) {
 	defer cancel()
 	reply, err := client.SearchUser(_ctx, &proto.SearchUserRequest{
 		Username: form.Username,
+		Userid:   userid.(int64),
 	})
 	if err != nil {
 		zlog.Error(err.Error())
@@ -331,9 +338,11 @@ func SearchUser(ctx *gin.Context) {
 }
 
 type getFriendMsgByPageReq struct {
-	FriendId int64 `json:"friendId" binding:"required"`
-	Current  int64 `json:"current" binding:"required"`
-	PageSize int64 `json:"pageSize" binding:"required"`
+	FriendId int64  `json:"friendId" binding:"required"`
+	Current  int64  `json:"current"` // before和after都为空时按页码分页
+	PageSize int64  `json:"pageSize" binding:"required"`
+	Before   string `json:"before"` // 获取该snowId之前的消息
+	After    string `json:"after"`  // 获取该snowId之后的消息
 }
 
 func GetFriendMsgByPage(ctx *gin.Context) {
@@ -361,10 +370,12 @@ func GetFriendMsgByPage(ctx *gin.Context) {
 		Userid:   userid.(int64),
 		Current:  form.Current,
 		PageSize: form.PageSize,
+		Before:   form.Before,
+		After:    form.After,
 	})
 	if err != nil {
 		zlog.Error(err.Error())
-		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
 		return
 	}
 	utils.SuccessWithMsg(ctx, nil, reply)
@@ -400,7 +411,8 @@ func AddFriend(ctx *gin.Context) {
 	})
 	if err != nil {
 		zlog.Error(err.Error())
-		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		// 已经是好友或者已经发送过好友申请等原因需要告知用户
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
 		return
 	}
 	utils.SuccessWithMsg(ctx, nil, nil)
@@ -493,10 +505,204 @@ func UploadAvtar(ctx *gin.Context) {
 	}
 	//example: https://localhost:8090/api/avatars/1/8dwekdkjfl.png
 	imgUrl := fmt.Sprintf("%s/api/avatars/%d/%s", conf.Api.Api.Host, reply.User.Id, fileName)
-	user := db.TUser{
-		ID:     reply.User.Id,
-		Avatar: imgUrl,
+	// 通过logic层更新头像，同时删除用户展示信息的缓存
+	_, err = client.UpdateUserInfo(_ctx, &proto.UpdateUserInfoRequest{
+		User: &proto.User{
+			Id:     reply.User.Id,
+			Avatar: imgUrl,
+		},
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "系统异常")
+		return
 	}
-	db.UpdateUser(&user)
 	utils.SuccessWithMsg(ctx, nil, imgUrl)
 }
+
+type sendFriendRequestReq struct {
+	FriendId int64  `json:"friendId" binding:"required"`
+	Greeting string `json:"greeting"` // 招呼语，可选
+}
+
+func SendFriendRequest(ctx *gin.Context) {
+	var form sendFriendRequestReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.SendFriendRequest(_ctx, &proto.SendFriendRequestReq{
+		Userid:   userid.(int64),
+		FriendId: form.FriendId,
+		Greeting: form.Greeting,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type respondFriendRequestReq struct {
+	RequestId int64 `json:"requestId" binding:"required"`
+	Accept    bool  `json:"accept"`
+}
+
+func RespondFriendRequest(ctx *gin.Context) {
+	var form respondFriendRequestReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.RespondFriendRequest(_ctx, &proto.RespondFriendRequestReq{
+		Userid:    userid.(int64),
+		RequestId: form.RequestId,
+		Accept:    form.Accept,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+func ListFriendRequests(ctx *gin.Context) {
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	reply, err := client.ListFriendRequests(_ctx, &proto.ListFriendRequestsReq{
+		Userid: userid.(int64),
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, reply)
+}
+
+type removeFriendReq struct {
+	FriendId int64 `json:"friendId" binding:"required"`
+}
+
+func RemoveFriend(ctx *gin.Context) {
+	var form removeFriendReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.RemoveFriend(_ctx, &proto.RemoveFriendRequest{
+		Userid:   userid.(int64),
+		FriendId: form.FriendId,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
+
+type blockUserReq struct {
+	BlockId int64 `json:"blockId" binding:"required"`
+}
+
+func BlockUser(ctx *gin.Context) {
+	dealBlockUser(ctx, true)
+}
+
+func UnblockUser(ctx *gin.Context) {
+	dealBlockUser(ctx, false)
+}
+
+// dealBlockUser 拉黑和移出黑名单的请求参数相同
+func dealBlockUser(ctx *gin.Context, block bool) {
+	var form blockUserReq
+	if err := ctx.ShouldBindBodyWith(&form, binding.JSON); err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, "参数校验失败")
+		return
+	}
+	userid, ok := ctx.Get("userid")
+	if !ok {
+		utils.ResponseWithCode(ctx, utils.CodeSessionError, nil, nil)
+		return
+	}
+	ins, err := rpc.GetLogicRpcInstance()
+	if err != nil {
+		utils.ResponseWithCode(ctx, utils.CodeUnknownError, nil, nil)
+		return
+	}
+	client := proto.NewLogicClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	request := &proto.BlockUserRequest{
+		Userid:  userid.(int64),
+		BlockId: form.BlockId,
+	}
+	if block {
+		_, err = client.BlockUser(_ctx, request)
+	} else {
+		_, err = client.UnblockUser(_ctx, request)
+	}
+	if err != nil {
+		zlog.Error(err.Error())
+		utils.FailWithMsg(ctx, status.Convert(err).Message())
+		return
+	}
+	utils.SuccessWithMsg(ctx, nil, nil)
+}
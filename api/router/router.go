@@ -15,6 +15,7 @@ func Register() *gin.Engine {
 	initUserRouter(r)
 	initGroupRouter(r)
 	initPushRouter(r)
+	initMessageRouter(r)
 	r.StaticFS("/images/", http.Dir("./static/img/"))
 	r.StaticFS("/avatars/", http.Dir("./static/avatar/"))
 	r.NoRoute(func(ctx *gin.Context) {
@@ -32,13 +33,19 @@ func initUserRouter(r *gin.Engine) {
 	userRouter.Use(utils.CheckSession())
 	{
 		userRouter.GET("/login-out", handler.LoginOut)
-		userRouter.GET("/chat-data", handler.GetChatHistoryAfterLogin) //在用户登陆后获取用户所有好友和群聊的聊天消息
+		userRouter.GET("/chat-data", handler.GetChatHistoryAfterLogin) //在用户登陆后获取用户所有好友、群聊以及在线用户的信息
 		userRouter.GET("/info", handler.GetUserInfoByUserid)
 		userRouter.POST("/update-info", handler.UpdateUserInfo)
 		userRouter.POST("/update-password", handler.UpdatePassword)
 		userRouter.POST("/search", handler.SearchUser)
 		userRouter.POST("/chat-history", handler.GetFriendMsgByPage)
 		userRouter.POST("/add-friend", handler.AddFriend)
+		userRouter.POST("/friend-request", handler.SendFriendRequest)            //发送好友申请
+		userRouter.POST("/friend-request/respond", handler.RespondFriendRequest) //同意或者拒绝好友申请
+		userRouter.GET("/friend-requests", handler.ListFriendRequests)           //获取收到和发出的好友申请
+		userRouter.POST("/remove-friend", handler.RemoveFriend)                  //删除好友
+		userRouter.POST("/block", handler.BlockUser)                             //拉黑用户
+		userRouter.POST("/unblock", handler.UnblockUser)                         //将用户移出黑名单
 	}
 
 }
@@ -51,6 +58,18 @@ func initGroupRouter(r *gin.Engine) {
 		groupRouter.POST("/chat-history", handler.GetGroupMsgByPage)
 		groupRouter.POST("/create", handler.CreateGroup)
 		groupRouter.POST("/add-group", handler.AddGroup)
+		groupRouter.POST("/set-role", handler.SetMemberRole)                  //群主设置或者取消管理员
+		groupRouter.POST("/kick", handler.KickMember)                         //将成员移出群聊
+		groupRouter.POST("/mute", handler.MuteMember)                         //禁言或者解除禁言成员
+		groupRouter.POST("/transfer", handler.TransferOwnership)              //转让群主
+		groupRouter.POST("/leave", handler.LeaveGroup)                        //退出群聊
+		groupRouter.POST("/dissolve", handler.DissolveGroup)                  //群主解散群聊
+		groupRouter.POST("/update", handler.UpdateGroup)                      //修改群名称、群公告和群头像
+		groupRouter.POST("/join-policy", handler.SetJoinPolicy)               //设置群聊的加入方式
+		groupRouter.POST("/invite", handler.CreateGroupInvite)                //创建群聊邀请链接
+		groupRouter.POST("/join-invite", handler.JoinGroupByInvite)           //通过邀请链接加入群聊
+		groupRouter.POST("/join-requests", handler.ListJoinRequests)          //获取群聊待处理的入群申请
+		groupRouter.POST("/join-request/respond", handler.RespondJoinRequest) //同意或者拒绝入群申请
 	}
 }
 
@@ -65,3 +84,21 @@ func initPushRouter(r *gin.Engine) {
 		pushRouter.POST("/push-group-info", handler.PushRoomInfo)
 	}
 }
+
+func initMessageRouter(r *gin.Engine) {
+	messageRouter := r.Group("/message")
+	messageRouter.Use(utils.CheckSession())
+	{
+		messageRouter.POST("/recall", handler.RecallMessage)             //撤回消息
+		messageRouter.POST("/edit", handler.EditMessage)                 //编辑消息
+		messageRouter.POST("/reaction/add", handler.AddReaction)         //添加表情回应
+		messageRouter.POST("/reaction/remove", handler.RemoveReaction)   //取消表情回应
+		messageRouter.GET("/mentions", handler.ListMentions)             //获取尚未处理的@提及
+		messageRouter.POST("/mentions/resolve", handler.ResolveMentions) //将@提及标记为已处理
+		messageRouter.POST("/read", handler.MarkRead)                    //将会话的未读数清零
+		messageRouter.POST("/conversations", handler.ListConversations)  //按最后一条消息的时间倒序分页获取会话列表
+		messageRouter.POST("/mute", handler.SetConversationMute)         //设置会话的消息免打扰
+		messageRouter.POST("/search", handler.SearchMessages)            //在用户可见的聊天记录中检索消息
+		messageRouter.POST("/ttl", handler.SetMessageTTL)                //设置会话的消息存活时间，过期的消息会被自动删除
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"net/http"
 )
 
@@ -10,6 +12,7 @@ const (
 	CodeFail         = 1
 	CodeUnknownError = -1
 	CodeSessionError = 40100
+	CodeForbidden    = 40300 // 越权操作，如向非好友发送消息、非群成员在群聊中发言
 )
 
 var MsgCodeMap = map[int]string{
@@ -17,6 +20,7 @@ var MsgCodeMap = map[int]string{
 	CodeFail:         "fail",
 	CodeUnknownError: "unknown error",
 	CodeSessionError: "invalid session",
+	CodeForbidden:    "permission denied",
 }
 
 func SuccessWithMsg(ctx *gin.Context, msg interface{}, data interface{}) {
@@ -27,6 +31,16 @@ func FailWithMsg(ctx *gin.Context, msg interface{}) {
 	ResponseWithCode(ctx, CodeFail, msg, nil)
 }
 
+// FailWithRpcError 将logic层返回的错误告知用户，越权操作返回CodeForbidden，其他错误返回CodeFail
+func FailWithRpcError(ctx *gin.Context, err error) {
+	st := status.Convert(err)
+	if st.Code() == codes.PermissionDenied {
+		ResponseWithCode(ctx, CodeForbidden, st.Message(), nil)
+		return
+	}
+	FailWithMsg(ctx, st.Message())
+}
+
 func ResponseWithCode(ctx *gin.Context, code int, msg interface{}, data interface{}) {
 	if msg == nil {
 		if val, ok := MsgCodeMap[code]; ok {
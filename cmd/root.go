@@ -7,6 +7,7 @@ import (
 	"axisChat/connect"
 	"axisChat/db"
 	"axisChat/logic"
+	"axisChat/persist"
 	"axisChat/task"
 	"axisChat/utils/zlog"
 	"fmt"
@@ -31,7 +32,7 @@ var rootCmd = &cobra.Command{
 	},
 }
 
-// 运行模式，决定当前启动的是api、logic、task、connect层服务中的哪一个
+// 运行模式，决定当前启动的是api、logic、task、connect、persist层服务中的哪一个
 var module string
 
 func init() {
@@ -60,6 +61,9 @@ func Execute() {
 	case "task":
 		// task层服务
 		task.New().Run()
+	case "persist":
+		// 消息持久化服务
+		persist.New().Run()
 	case "api":
 		// RESTFUL API 层服务
 		api.New().Run()
@@ -80,7 +84,7 @@ func Execute() {
 	/// / /_       __\ \_/ / /    /_/ /\__\/_/___\ \/___/ /           \ \___\/ / / /____\ \ /_______/\__\/
 	//\_\___\     /____/_\/_/     \_\/\/_________/\_____\/             \/___/_/\/________\_\\_______\/
 	//                                                                                                    `)
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	// 收到系统的中断信号会往quit channel种写入信息然后退出（优雅退出）
 	signal.Notify(quit, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-quit
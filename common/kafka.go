@@ -7,7 +7,6 @@ package common
  */
 
 import (
-	"axisChat/config"
 	"axisChat/utils/zlog"
 	"context"
 	"fmt"
@@ -23,26 +22,6 @@ type KafkaProducerConn struct {
 	CreateTime map[string]time.Time // 记录连接建立的时间
 }
 
-//type KafkaConsumerReader struct {
-//	mutex      sync.RWMutex
-//	ReaderMap  map[string]*kafka.Reader
-//	CreateTime map[string]time.Time
-//}
-
-var (
-	producerConnMap = &KafkaProducerConn{
-		SocketMap:  make(map[string]*kafka.Conn),
-		CreateTime: make(map[string]time.Time),
-	}
-
-	//consumerReader = &KafkaConsumerReader{
-	//	ReaderMap:  make(map[string]*kafka.Reader),
-	//	CreateTime: make(map[string]time.Time),
-	//}
-
-	_once sync.Once
-)
-
 type KafkaMsgInfo struct {
 	Topic     string `json:"topic"`
 	Partition int    `json:"partition"`
@@ -54,7 +33,25 @@ const (
 	GroupQueuePrefix  = "group_chat_%d"
 )
 
-func watchLongTimeNotUseConn() {
+// KafkaMQ MQ接口的kafka实现
+type KafkaMQ struct {
+	address  string
+	producer *KafkaProducerConn
+	once     sync.Once
+}
+
+func NewKafkaMQ(address string) *KafkaMQ {
+	return &KafkaMQ{
+		address: address,
+		producer: &KafkaProducerConn{
+			SocketMap:  make(map[string]*kafka.Conn),
+			CreateTime: make(map[string]time.Time),
+		},
+	}
+}
+
+func (k *KafkaMQ) watchLongTimeNotUseConn() {
+	producerConnMap := k.producer
 	ticker := time.NewTicker(60 * time.Second)
 	defer func() {
 		ticker.Stop()
@@ -82,19 +79,12 @@ func watchLongTimeNotUseConn() {
 	}
 }
 
-func getProducerConn(objectId int64, _type string) (*kafka.Conn, error) {
-	_once.Do(func() {
+func (k *KafkaMQ) getProducerConn(topic string) (*kafka.Conn, error) {
+	k.once.Do(func() {
 		// 监听并删除长时间不使用的连接
-		go watchLongTimeNotUseConn()
+		go k.watchLongTimeNotUseConn()
 	})
-	var topic = ""
-	if _type == "friend" {
-		topic = fmt.Sprintf(FriendQueuePrefix, objectId)
-	} else if _type == "group" {
-		topic = fmt.Sprintf(GroupQueuePrefix, objectId)
-	} else {
-		return nil, errors.New(fmt.Sprintf("_type = %s is not alllow", _type))
-	}
+	producerConnMap := k.producer
 	producerConnMap.mutex.RLock()
 	if conn, ok := producerConnMap.SocketMap[topic]; ok {
 		producerConnMap.mutex.RUnlock()
@@ -109,10 +99,9 @@ func getProducerConn(objectId int64, _type string) (*kafka.Conn, error) {
 	producerConnMap.mutex.Lock()
 	defer producerConnMap.mutex.Unlock()
 	// 为保证整体消息的有序性，每个topic的partition数为0
-	address := config.GetConfig().Common.Kafka.Address
-	conn, err := kafka.DialLeader(context.Background(), "tcp", address, topic, 0)
+	conn, err := kafka.DialLeader(context.Background(), "tcp", k.address, topic, 0)
 	if err != nil {
-		err = errors.Wrap(err, fmt.Sprintf("get topic:%s from Kafka failure host=%v", topic, address))
+		err = errors.Wrap(err, fmt.Sprintf("get topic:%s from Kafka failure host=%v", topic, k.address))
 		return nil, err
 	}
 
@@ -122,91 +111,84 @@ func getProducerConn(objectId int64, _type string) (*kafka.Conn, error) {
 	return conn, nil
 }
 
-func TopicProduce(objectId int64, _type string, msg []byte) error {
-	var topic string
-	if _type == "friend" {
-		topic = fmt.Sprintf(FriendQueuePrefix, objectId)
-	} else if _type == "group" {
-		topic = fmt.Sprintf(GroupQueuePrefix, objectId)
-	} else {
-		return errors.New(fmt.Sprintf("_type = %v is not alllow", _type))
-	}
-	conn, err := getProducerConn(objectId, _type)
+// dropProducerConn 删除不可用的kafka实例
+func (k *KafkaMQ) dropProducerConn(topic string) {
+	k.producer.mutex.Lock()
+	delete(k.producer.SocketMap, topic)
+	delete(k.producer.CreateTime, topic)
+	k.producer.mutex.Unlock()
+}
+
+func (k *KafkaMQ) Produce(topic string, value []byte) error {
+	conn, err := k.getProducerConn(topic)
 	if err != nil {
-		zlog.Error(err.Error())
 		return err
 	}
 	// 设置写入消息的超时时间
 	err = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
 	if err != nil {
-		err = errors.Wrap(err, "conn.SetWriteDeadline get err")
-
-		// 删除不可用的kafka实例
-		producerConnMap.mutex.Lock()
-		delete(producerConnMap.SocketMap, topic)
-		delete(producerConnMap.CreateTime, topic)
-		producerConnMap.mutex.Unlock()
-		return err
+		k.dropProducerConn(topic)
+		return errors.Wrap(err, "conn.SetWriteDeadline get err")
 	}
 	_, err = conn.WriteMessages(kafka.Message{
-		Value: msg,
+		Value: value,
 	})
 	if err != nil {
-		err = errors.Wrap(err, "conn.WriteMessages get err")
-		zlog.Error(err.Error())
-
-		//删除不可用的Kafka实例
-		producerConnMap.mutex.Lock()
-		delete(producerConnMap.SocketMap, topic)
-		delete(producerConnMap.CreateTime, topic)
-		producerConnMap.mutex.Unlock()
+		k.dropProducerConn(topic)
 		_ = conn.Close()
-		return err
+		return errors.Wrap(err, "conn.WriteMessages get err")
 	}
-	zlog.Debug(fmt.Sprintf("success write msg=%s", string(msg)))
 	return nil
 }
 
-// ===================消费者===============
-
-func getConsumerReader(groupId string, topic string) (*kafka.Reader, error) {
-	var (
-		reader *kafka.Reader
-	)
+func (k *KafkaMQ) NewConsumer(groupId string, topic string) (MQConsumer, error) {
 	// 为保证整体消息的有序性，每个topic的partition数为1
-	reader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{config.GetConfig().Common.Kafka.Address},
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  []string{k.address},
 		Topic:    topic,
 		GroupID:  groupId,
 		MinBytes: 10e3,
 		MaxBytes: 10e6,
 	})
-
 	zlog.Info("success init kafka topic consumer reader!!!")
-	return reader, nil
+	return &kafkaConsumer{
+		topic:  topic,
+		reader: reader,
+	}, nil
 }
 
-func GetConsumeReader(consumerSuffix string, topic string) (*kafka.Reader, error) {
-	groupId := fmt.Sprintf("%s-%s", topic, consumerSuffix)
-	reader, err := getConsumerReader(groupId, topic)
+type kafkaConsumer struct {
+	topic  string
+	reader *kafka.Reader
+}
 
-	// todo bug: 这里有可能出现reader close的异常
+func (c *kafkaConsumer) Fetch(ctx context.Context) (MQMessage, error) {
+	msg, err := c.reader.FetchMessage(ctx)
 	if err != nil {
-		zlog.Error(fmt.Sprintf("get consumer reader failure「err:%v」", err))
-		return nil, err
+		return MQMessage{}, err
 	}
-	return reader, nil
+	return fromKafkaMessage(msg), nil
+}
+
+func (c *kafkaConsumer) Commit(ctx context.Context, msg MQMessage) error {
+	return c.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset, // CommitMessages提交的偏移量是在msg的基础上加1
+	})
 }
 
-func TopicConsume(ctx context.Context, reader *kafka.Reader) (msg kafka.Message, err error) {
-	msg, err = reader.FetchMessage(ctx)
-	return
+func (c *kafkaConsumer) Close() error {
+	return c.reader.Close()
 }
 
-func TopicConsumerConfirm(reader *kafka.Reader, msg kafka.Message) error {
-	if err := reader.CommitMessages(context.Background(), msg); err != nil {
-		zlog.Error(fmt.Sprintf("failed to commit messages:%v", err))
-		return err
+func fromKafkaMessage(msg kafka.Message) MQMessage {
+	return MQMessage{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Time:      msg.Time,
 	}
-	return nil
 }
@@ -0,0 +1,193 @@
+package common
+
+/**
+*Author: AxisZql
+*Date: 2022-7-20
+*DESC: MQ abstraction, logic、task、connect层只依赖该接口，kafka、redis stream、内存队列为其具体实现
+ */
+
+import (
+	"axisChat/config"
+	"axisChat/utils/zlog"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+const (
+	MQDriverKafka  = "kafka"
+	MQDriverRedis  = "redis"
+	MQDriverMemory = "memory"
+)
+
+// MQMessage 与具体消息队列实现无关的消息结构，Offset在同一个topic内单调递增
+type MQMessage struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Time      time.Time
+}
+
+// MQ 消息队列抽象，负责消息的生产以及创建消费者
+type MQ interface {
+	// Produce 往对应topic写入一条消息
+	Produce(topic string, value []byte) error
+	// NewConsumer 创建属于消费组groupId的topic消费者
+	NewConsumer(groupId string, topic string) (MQConsumer, error)
+}
+
+// MQExpirer 支持删除单条消息的消息队列实现该接口，用于清理会话中已经过期的消息，
+// 不支持的实现（如kafka）由消费方在推送时跳过过期的消息
+type MQExpirer interface {
+	// ProduceExpiring 往对应topic写入一条在expireAt之后需要删除的消息
+	ProduceExpiring(topic string, value []byte, expireAt time.Time) error
+	// RemoveExpired 删除在now之前过期的消息，最多删除limit条，返回删除的数目
+	RemoveExpired(now time.Time, limit int) (int, error)
+}
+
+// MQConsumer topic消费者，为保证消息的有序性每个topic只有一个partition
+type MQConsumer interface {
+	// Fetch 从消费组当前的偏移量开始读取下一条消息，没有消息时阻塞直到ctx结束
+	Fetch(ctx context.Context) (MQMessage, error)
+	// Commit 提交消费确认，之后新建的消费者会从msg.Offset+1开始消费
+	Commit(ctx context.Context, msg MQMessage) error
+	Close() error
+}
+
+var (
+	mqOnce    sync.Once
+	mqMutex   sync.RWMutex
+	defaultMQ MQ
+)
+
+// GetMQ 根据配置的driver获取消息队列实例，默认使用kafka
+func GetMQ() MQ {
+	mqOnce.Do(func() {
+		mqMutex.Lock()
+		defer mqMutex.Unlock()
+		switch config.GetConfig().Common.Mq.Driver {
+		case MQDriverRedis:
+			defaultMQ = NewRedisStreamMQ()
+		case MQDriverMemory:
+			defaultMQ = NewMemoryMQ()
+		default:
+			defaultMQ = NewKafkaMQ(config.GetConfig().Common.Kafka.Address)
+		}
+		zlog.Info(fmt.Sprintf("init message queue driver %T", defaultMQ))
+	})
+	mqMutex.RLock()
+	defer mqMutex.RUnlock()
+	return defaultMQ
+}
+
+// SetMQ 替换默认的消息队列实现，用于单进程部署以及集成测试
+func SetMQ(mq MQ) {
+	mqOnce.Do(func() {})
+	mqMutex.Lock()
+	defaultMQ = mq
+	mqMutex.Unlock()
+}
+
+// GetTopic 获取对象（用户或者群聊）对应的topic名称
+func GetTopic(objectId int64, _type string) (string, error) {
+	switch _type {
+	case "friend":
+		return fmt.Sprintf(FriendQueuePrefix, objectId), nil
+	case "group":
+		return fmt.Sprintf(GroupQueuePrefix, objectId), nil
+	}
+	return "", errors.New(fmt.Sprintf("_type = %v is not alllow", _type))
+}
+
+func TopicProduce(objectId int64, _type string, msg []byte) error {
+	topic, err := GetTopic(objectId, _type)
+	if err != nil {
+		return err
+	}
+	if err = GetMQ().Produce(topic, msg); err != nil {
+		zlog.Error(err.Error())
+		return err
+	}
+	markPersistPending(topic)
+	zlog.Debug(fmt.Sprintf("success write msg=%s", string(msg)))
+	return nil
+}
+
+// markPersistPending 登记有新消息的topic，persist层只持久化登记过的topic，
+// 必须在消息写入之后登记，persist层认领topic时先删除登记再读取，读取期间写入的消息会重新登记
+func markPersistPending(topic string) {
+	if err := RedisHSet(PersistPendingTopic, topic, time.Now().Unix()); err != nil {
+		zlog.Error(fmt.Sprintf("mark topic=%s pending persist err:%v", topic, err))
+	}
+}
+
+// TopicProduceExpiring 写入在expireAt之后过期的消息，消息队列不支持删除单条消息时与TopicProduce相同
+func TopicProduceExpiring(objectId int64, _type string, msg []byte, expireAt time.Time) error {
+	expirer, ok := GetMQ().(MQExpirer)
+	if !ok {
+		return TopicProduce(objectId, _type, msg)
+	}
+	topic, err := GetTopic(objectId, _type)
+	if err != nil {
+		return err
+	}
+	if err = expirer.ProduceExpiring(topic, msg, expireAt); err != nil {
+		zlog.Error(err.Error())
+		return err
+	}
+	markPersistPending(topic)
+	zlog.Debug(fmt.Sprintf("success write msg=%s expireAt=%s", string(msg), expireAt.Format(time.RFC3339)))
+	return nil
+}
+
+// RemoveExpiredMessages 删除消息队列中已经过期的消息，消息队列不支持删除单条消息时直接返回
+func RemoveExpiredMessages(now time.Time, limit int) (int, error) {
+	expirer, ok := GetMQ().(MQExpirer)
+	if !ok {
+		return 0, nil
+	}
+	return expirer.RemoveExpired(now, limit)
+}
+
+// ===================消费者===============
+
+// 每个topic都有的消费组的后缀，消费组id为「topic-后缀」
+const (
+	PushConsumerSuffix    = "push"    // task层推送给在线的用户
+	PersistConsumerSuffix = "persist" // persist层写入db
+)
+
+// requiredConsumerSuffixes 删除已经确认的消息时必须等待这些消费组都确认，
+// 消费组对应的消费者可能还没有创建（用户不在线、persist层还没有发现topic），此时它的偏移量视为0
+var requiredConsumerSuffixes = []string{PushConsumerSuffix, PersistConsumerSuffix}
+
+func consumerGroupId(consumerSuffix string, topic string) string {
+	return fmt.Sprintf("%s-%s", topic, consumerSuffix)
+}
+
+func GetConsumeReader(consumerSuffix string, topic string) (MQConsumer, error) {
+	groupId := consumerGroupId(consumerSuffix, topic)
+	reader, err := GetMQ().NewConsumer(groupId, topic)
+	if err != nil {
+		zlog.Error(fmt.Sprintf("get consumer reader failure「err:%v」", err))
+		return nil, err
+	}
+	return reader, nil
+}
+
+func TopicConsume(ctx context.Context, reader MQConsumer) (msg MQMessage, err error) {
+	msg, err = reader.Fetch(ctx)
+	return
+}
+
+func TopicConsumerConfirm(reader MQConsumer, msg MQMessage) error {
+	if err := reader.Commit(context.Background(), msg); err != nil {
+		zlog.Error(fmt.Sprintf("failed to commit messages:%v", err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+/*
+*Author:AxisZql
+*Date:2022-7-20
+*Desc:进程内的内存消息队列，适用于单进程部署以及集成测试，进程退出后消息会丢失
+ */
+
+type MemoryMQ struct {
+	mutex  sync.Mutex
+	topics map[string]*memoryTopic
+}
+
+type memoryTopic struct {
+	msgs      []MQMessage
+	committed map[string]int64 // 消费组id和下一条待消费消息偏移量的映射
+	notify    chan struct{}    // 有新消息写入时关闭并重建，以此唤醒所有等待的消费者
+}
+
+func NewMemoryMQ() *MemoryMQ {
+	return &MemoryMQ{
+		topics: make(map[string]*memoryTopic),
+	}
+}
+
+// getTopic 调用方必须持有锁
+func (m *MemoryMQ) getTopic(topic string) *memoryTopic {
+	t, ok := m.topics[topic]
+	if !ok {
+		t = &memoryTopic{
+			committed: make(map[string]int64),
+			notify:    make(chan struct{}),
+		}
+		m.topics[topic] = t
+	}
+	return t
+}
+
+func (m *MemoryMQ) Produce(topic string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	t := m.getTopic(topic)
+	t.msgs = append(t.msgs, MQMessage{
+		Topic:  topic,
+		Offset: int64(len(t.msgs)),
+		Value:  append([]byte(nil), value...),
+		Time:   time.Now(),
+	})
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return nil
+}
+
+func (m *MemoryMQ) NewConsumer(groupId string, topic string) (MQConsumer, error) {
+	return &memoryConsumer{
+		mq:      m,
+		groupId: groupId,
+		topic:   topic,
+		pos:     -1,
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+type memoryConsumer struct {
+	mq      *MemoryMQ
+	groupId string
+	topic   string
+	pos     int64 // 下一次读取的偏移量，-1表示还未开始读取，第一次读取时以消费组提交的偏移量为准
+	once    sync.Once
+	closed  chan struct{}
+}
+
+func (c *memoryConsumer) Fetch(ctx context.Context) (MQMessage, error) {
+	for {
+		c.mq.mutex.Lock()
+		t := c.mq.getTopic(c.topic)
+		if c.pos < 0 {
+			c.pos = t.committed[c.groupId]
+		}
+		if c.pos < int64(len(t.msgs)) {
+			msg := t.msgs[c.pos]
+			c.pos++
+			c.mq.mutex.Unlock()
+			return msg, nil
+		}
+		notify := t.notify
+		c.mq.mutex.Unlock()
+
+		select {
+		case <-notify:
+		case <-c.closed:
+			return MQMessage{}, errors.New("memory consumer is closed")
+		case <-ctx.Done():
+			return MQMessage{}, ctx.Err()
+		}
+	}
+}
+
+func (c *memoryConsumer) Commit(ctx context.Context, msg MQMessage) error {
+	c.mq.mutex.Lock()
+	defer c.mq.mutex.Unlock()
+	t := c.mq.getTopic(c.topic)
+	t.committed[c.groupId] = msg.Offset + 1
+	return nil
+}
+
+func (c *memoryConsumer) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryMQ(t *testing.T) {
+	mq := NewMemoryMQ()
+	topic := "friend_chat_1"
+	for _, v := range []string{"a", "b", "c"} {
+		if err := mq.Produce(topic, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reader, _ := mq.NewConsumer("friend_chat_1-task", topic)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := reader.Fetch(ctx)
+	if err != nil || string(msg.Value) != "a" || msg.Offset != 0 {
+		t.Fatalf("fetch got %v %v", msg, err)
+	}
+	if err = reader.Commit(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	_ = reader.Close()
+
+	// 同一个消费组新建的消费者从已提交的偏移量开始消费
+	reader, _ = mq.NewConsumer("friend_chat_1-task", topic)
+	msg, err = reader.Fetch(ctx)
+	if err != nil || string(msg.Value) != "b" {
+		t.Fatalf("fetch after commit got %v %v", msg, err)
+	}
+	msg, err = reader.Fetch(ctx)
+	if err != nil || string(msg.Value) != "c" {
+		t.Fatalf("fetch got %v %v", msg, err)
+	}
+
+	// 没有新消息时阻塞，直到有新消息写入
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = mq.Produce(topic, []byte("d"))
+	}()
+	msg, err = reader.Fetch(ctx)
+	if err != nil || string(msg.Value) != "d" || msg.Offset != 3 {
+		t.Fatalf("blocking fetch got %v %v", msg, err)
+	}
+}
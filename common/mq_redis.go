@@ -0,0 +1,282 @@
+package common
+
+import (
+	"axisChat/utils/zlog"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+*Author:AxisZql
+*Date:2022-7-20
+*Desc:基于redis stream的消息队列实现，适用于没有kafka集群的小规模部署
+ */
+
+// MQStream 每个topic对应一个stream，消息id固定为「offset-1」，从而得到和kafka一致的整数偏移量
+const MQStream string = "axis:mq_stream:%s"
+
+// MQStreamSeq 记录topic下一条消息的偏移量
+const MQStreamSeq string = "axis:mq_stream_seq:%s"
+
+// MQStreamOffset 消费组id和下一条待消费消息偏移量的映射
+const MQStreamOffset string = "axis:mq_stream_offset:%s"
+
+// MQStreamExpire 所有topic中会过期的消息，member为「topic|offset」，score为过期时间的unix时间戳
+const MQStreamExpire string = "axis:mq_stream_expire"
+
+const (
+	// 为保证偏移量和消息写入顺序一致，分配偏移量和写入stream必须是原子操作，
+	// MAXLEN只是兜底的上限，正常情况下已经被所有消费组确认的消息在提交时就会被删除
+	streamProduceCommand = `
+    local offset = redis.call("INCR", KEYS[2]) - 1
+    redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[2], offset .. "-1", "value", ARGV[1])
+    return offset`
+
+	// 提交偏移量并返回所有必需的消费组的偏移量，ARGV[3:]为必需的消费组id
+	streamCommitCommand = `
+    redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+    return redis.call("HMGET", KEYS[1], unpack(ARGV, 3))`
+
+	// 删除偏移量小于ARGV[1]的消息，每次最多删除ARGV[2]条，
+	// 使用XRANGE+XDEL而不是XTRIM MINID，兼容redis 6.2之前的版本
+	streamTrimCommand = `
+    local entries = redis.call("XRANGE", KEYS[1], "-", (ARGV[1] - 1) .. "-1", "COUNT", ARGV[2])
+    for _, entry in ipairs(entries) do
+        redis.call("XDEL", KEYS[1], entry[1])
+    end
+    return #entries`
+
+	// 每个topic最多保留的消息数，消费组落后超过该数目时最早的消息会被丢弃
+	streamMaxLen = 100000
+	// 每次提交时最多删除的已确认消息数
+	streamTrimBatch = 100
+
+	streamBlockTime = time.Second
+)
+
+type RedisStreamMQ struct{}
+
+func NewRedisStreamMQ() *RedisStreamMQ {
+	return &RedisStreamMQ{}
+}
+
+// 同一个topic的所有key都通过stream key选择redis实例，确保lua脚本操作的key在同一个实例上
+func streamClient(topic string) (*redis.Client, error) {
+	return GetRedisClientByKey(fmt.Sprintf(MQStream, topic))
+}
+
+func (r *RedisStreamMQ) Produce(topic string, value []byte) error {
+	_, err := r.produce(topic, value)
+	return err
+}
+
+// produce 写入消息并返回分配的偏移量
+func (r *RedisStreamMQ) produce(topic string, value []byte) (int64, error) {
+	client, err := streamClient(topic)
+	if err != nil {
+		return 0, err
+	}
+	keys := []string{fmt.Sprintf(MQStream, topic), fmt.Sprintf(MQStreamSeq, topic)}
+	offset, err := client.Eval(streamProduceCommand, keys, value, streamMaxLen).Int64()
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("produce msg to redis stream %s failure", topic))
+	}
+	return offset, nil
+}
+
+// ProduceExpiring 写入消息后把它登记到MQStreamExpire中，登记失败时只记录日志，
+// 消息已经写入，返回错误会导致调用方重复生产，没有登记的消息在推送时仍然会因为过期被跳过
+func (r *RedisStreamMQ) ProduceExpiring(topic string, value []byte, expireAt time.Time) error {
+	offset, err := r.produce(topic, value)
+	if err != nil {
+		return err
+	}
+	client, err := GetRedisClientByKey(MQStreamExpire)
+	if err == nil {
+		err = client.ZAdd(MQStreamExpire, redis.Z{
+			Score:  float64(expireAt.Unix()),
+			Member: fmt.Sprintf("%s|%d", topic, offset),
+		}).Err()
+	}
+	if err != nil {
+		zlog.Error(fmt.Sprintf("register expiring msg topic=%s offset=%d err:%v", topic, offset, err))
+	}
+	return nil
+}
+
+// RemoveExpired 从stream中删除已经过期的消息，XREAD会跳过被删除的id，不影响消费者的偏移量
+func (r *RedisStreamMQ) RemoveExpired(now time.Time, limit int) (int, error) {
+	client, err := GetRedisClientByKey(MQStreamExpire)
+	if err != nil {
+		return 0, err
+	}
+	members, err := client.ZRangeByScore(MQStreamExpire, redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, member := range members {
+		idx := strings.LastIndex(member, "|")
+		offset, e := strconv.ParseInt(member[idx+1:], 10, 64)
+		if idx < 0 || e != nil {
+			// 无法识别的member直接丢弃
+			client.ZRem(MQStreamExpire, member)
+			continue
+		}
+		topic := member[:idx]
+		streamCli, err := streamClient(topic)
+		if err != nil {
+			return removed, err
+		}
+		if err = streamCli.XDel(fmt.Sprintf(MQStream, topic), fmt.Sprintf("%d-1", offset)).Err(); err != nil {
+			return removed, err
+		}
+		if err = client.ZRem(MQStreamExpire, member).Err(); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (r *RedisStreamMQ) NewConsumer(groupId string, topic string) (MQConsumer, error) {
+	client, err := streamClient(topic)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStreamConsumer{
+		client:  client,
+		groupId: groupId,
+		topic:   topic,
+		pos:     -1,
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+type redisStreamConsumer struct {
+	client  *redis.Client
+	groupId string
+	topic   string
+	pos     int64 // 下一次读取的偏移量，-1表示还未开始读取
+	once    sync.Once
+	closed  chan struct{}
+}
+
+func (c *redisStreamConsumer) Fetch(ctx context.Context) (MQMessage, error) {
+	if c.pos < 0 {
+		res, err := c.client.HGet(fmt.Sprintf(MQStreamOffset, c.topic), c.groupId).Result()
+		if err != nil && err != redis.Nil {
+			return MQMessage{}, err
+		}
+		c.pos, _ = strconv.ParseInt(res, 10, 64)
+	}
+	for {
+		select {
+		case <-c.closed:
+			return MQMessage{}, errors.New("redis stream consumer is closed")
+		case <-ctx.Done():
+			return MQMessage{}, ctx.Err()
+		default:
+		}
+		// XREAD 返回id大于start的消息
+		start := "0-0"
+		if c.pos > 0 {
+			start = fmt.Sprintf("%d-1", c.pos-1)
+		}
+		res, err := c.client.XRead(&redis.XReadArgs{
+			Streams: []string{fmt.Sprintf(MQStream, c.topic), start},
+			Count:   1,
+			Block:   streamBlockTime,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return MQMessage{}, err
+		}
+		if len(res) == 0 || len(res[0].Messages) == 0 {
+			continue
+		}
+		msg, err := c.toMQMessage(res[0].Messages[0])
+		if err != nil {
+			return MQMessage{}, err
+		}
+		c.pos = msg.Offset + 1
+		return msg, nil
+	}
+}
+
+// Commit 提交偏移量后删除所有必需的消费组都已经确认的消息，偏移量只会增大，所以删除不需要和提交在同一个脚本中
+func (c *redisStreamConsumer) Commit(ctx context.Context, msg MQMessage) error {
+	args := []interface{}{c.groupId, msg.Offset + 1}
+	for _, suffix := range requiredConsumerSuffixes {
+		args = append(args, consumerGroupId(suffix, c.topic))
+	}
+	offsets, err := c.client.Eval(streamCommitCommand, []string{fmt.Sprintf(MQStreamOffset, c.topic)}, args...).Result()
+	if err != nil {
+		return err
+	}
+	values, _ := offsets.([]interface{})
+	if trimOffset := streamTrimOffset(values); trimOffset > 0 {
+		err = c.client.Eval(streamTrimCommand, []string{fmt.Sprintf(MQStream, c.topic)}, trimOffset, streamTrimBatch).Err()
+		if err != nil {
+			zlog.Error(fmt.Sprintf("trim redis stream topic=%s err:%v", c.topic, err))
+		}
+	}
+	return nil
+}
+
+// streamTrimOffset 所有必需的消费组中最小的偏移量，小于该偏移量的消息可以删除，
+// 有消费组还没有提交过偏移量时返回0，不删除任何消息
+func streamTrimOffset(offsets []interface{}) int64 {
+	if len(offsets) == 0 {
+		return 0
+	}
+	var min int64 = -1
+	for _, v := range offsets {
+		str, ok := v.(string)
+		if !ok {
+			return 0
+		}
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return 0
+		}
+		if min < 0 || n < min {
+			min = n
+		}
+	}
+	return min
+}
+
+func (c *redisStreamConsumer) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (c *redisStreamConsumer) toMQMessage(xMsg redis.XMessage) (MQMessage, error) {
+	strList := strings.Split(xMsg.ID, "-")
+	offset, err := strconv.ParseInt(strList[0], 10, 64)
+	if err != nil {
+		return MQMessage{}, errors.Wrap(err, fmt.Sprintf("illegal stream msg id %s", xMsg.ID))
+	}
+	value, _ := xMsg.Values["value"].(string)
+	return MQMessage{
+		Topic:  c.topic,
+		Offset: offset,
+		Value:  []byte(value),
+		Time:   time.Now(),
+	}, nil
+}
@@ -0,0 +1,26 @@
+package common
+
+import "testing"
+
+func TestStreamTrimOffset(t *testing.T) {
+	// 推送的消费组已经确认到偏移量5，persist层的消费组还没有提交过偏移量
+	if offset := streamTrimOffset([]interface{}{"5", nil}); offset != 0 {
+		t.Fatalf("trim offset with an uncommitted group = %d, want 0", offset)
+	}
+	if offset := streamTrimOffset([]interface{}{"5", "3"}); offset != 3 {
+		t.Fatalf("trim offset = %d, want 3", offset)
+	}
+	if offset := streamTrimOffset([]interface{}{"5", "illegal"}); offset != 0 {
+		t.Fatalf("trim offset with an illegal offset = %d, want 0", offset)
+	}
+	if offset := streamTrimOffset(nil); offset != 0 {
+		t.Fatalf("trim offset without groups = %d, want 0", offset)
+	}
+	required := make(map[string]bool)
+	for _, suffix := range requiredConsumerSuffixes {
+		required[suffix] = true
+	}
+	if !required[PushConsumerSuffix] || !required[PersistConsumerSuffix] {
+		t.Fatal("push and persist groups must both be required before trimming")
+	}
+}
@@ -1,6 +1,9 @@
 package common
 
-import "axisChat/db"
+import (
+	"axisChat/db"
+	"fmt"
+)
 
 const (
 	OpFriendMsgSend           = 0
@@ -9,19 +12,39 @@ const (
 	OpGroupInfoSend           = 4
 	OpFriendOnlineSend        = 5
 	OPFriendOffOnlineSend     = 6
+	OpMsgRecallSend           = 7  // 消息撤回事件，与聊天消息写入同一个topic，使用GroupMsg或者FriendMsg承载，snowId为被撤回的消息
+	OpMsgEditSend             = 8  // 消息编辑事件，承载方式与撤回事件相同，content为编辑后的内容
+	OpMsgReactionSend         = 9  // 表情回应变化事件，通过状态消息队列推送，不占用聊天消息topic
+	OpGroupDetachSend         = 10 // 成员退出、被移出群聊或者群聊解散事件，connect层据此将连接从GroupNode中删除
+	OpFriendRequestSend       = 11 // 收到好友申请或者发出的好友申请被处理的通知
+	OpGroupJoinRequestSend    = 12 // 管理员收到入群申请或者申请方的入群申请被处理的通知
+	OpUnreadSend              = 13 // 会话未读数变化事件，用户在一个设备上已读后同步其他设备的未读数
 )
 
+// SystemUserid 系统消息的发送方id
+const SystemUserid = 0
+
 type MsgSend struct {
 	Op  int         `json:"op"`
 	Msg interface{} `json:"msg"`
 }
 
 type GroupInfoMsg struct {
-	GroupId       int64      `json:"groupId"`
-	Count         int        `json:"count"`
-	UserArr       []db.TUser `json:"userArr"`
-	OnlineUserIds []int64    `json:"onlineUserIds"`
-	Op            int        `json:"op"`
+	GroupId       int64         `json:"groupId"`
+	GroupName     string        `json:"groupName"`
+	Notice        string        `json:"notice"`
+	Avatar        string        `json:"avatar"`
+	Count         int           `json:"count"`
+	UserArr       []db.TUser    `json:"userArr"`
+	OnlineUserIds []int64       `json:"onlineUserIds"`
+	Members       []GroupMember `json:"members"` // 群成员的角色和禁言状态
+	Op            int           `json:"op"`
+}
+
+type GroupMember struct {
+	Userid    int64  `json:"userid"`
+	Role      int    `json:"role"`
+	MuteUntil string `json:"muteUntil,omitempty"` // 禁言截止时间，没有被禁言时为空
 }
 
 type GroupCountMsg struct {
@@ -43,17 +66,77 @@ type FriendOfflineMsg struct {
 	Belong   int64 `json:"belong"`
 }
 
-type GroupMsg struct {
-	Userid       int64  `json:"userid"`
-	GroupId      int64  `json:"groupId"`
-	Content      string `json:"content"`
-	MessageType  string `json:"messageType"`
-	CreateAt     string `json:"createAt"`
-	GroupName    string `json:"groupName"`
+type ReactionMsg struct {
+	SnowId  string `json:"snowId"` // 被回应的消息
+	Userid  int64  `json:"userid"` // 回应方id
+	Emoji   string `json:"emoji"`
+	Action  string `json:"action"`  // add、remove
+	GroupId int64  `json:"groupId"` // 群聊消息对应的群聊id，私聊消息为0
+	Belong  int64  `json:"belong"`  // 私聊消息的事件接收方id
+	Op      int    `json:"op"`
+}
+
+type FriendRequestMsg struct {
+	RequestId    int64  `json:"requestId"`
+	FromUserid   int64  `json:"fromUserid"`
 	FromUsername string `json:"fromUsername"`
-	Avatar       string `json:"avatar"`
+	FromAvatar   string `json:"fromAvatar"`
+	ToUserid     int64  `json:"toUserid"`
+	Greeting     string `json:"greeting"`
+	State        int    `json:"state"`  // 1收到新的申请 2申请已被同意 3申请已被拒绝
+	Belong       int64  `json:"belong"` // 通知的接收方id
 	Op           int    `json:"op"`
-	Watermark    int64  `json:"watermark"`
+}
+
+type GroupJoinRequestMsg struct {
+	RequestId int64  `json:"requestId"`
+	GroupId   int64  `json:"groupId"`
+	GroupName string `json:"groupName"`
+	Userid    int64  `json:"userid"` // 申请方id
+	Username  string `json:"username"`
+	Avatar    string `json:"avatar"`
+	Reason    string `json:"reason"`
+	State     int    `json:"state"`  // 1收到新的申请 2申请已被同意 3申请已被拒绝
+	Belong    int64  `json:"belong"` // 通知的接收方id
+	Op        int    `json:"op"`
+}
+
+type UnreadMsg struct {
+	Type     string `json:"type"`     // friend、group
+	ObjectId int64  `json:"objectId"` // 私聊为好友id，群聊为群聊id
+	Unread   int64  `json:"unread"`   // 变化后的未读数
+	Delta    int64  `json:"delta"`    // 未读数的变化量
+	Belong   int64  `json:"belong"`   // 未读数所属的用户id
+	Op       int    `json:"op"`
+}
+
+type GroupDetachMsg struct {
+	GroupId   int64    `json:"groupId"`
+	Userids   []int64  `json:"userids"`             // 离开群聊的成员
+	Dissolved bool     `json:"dissolved"`           // 群聊是否已经解散，解散时所有成员都离开群聊
+	ServerIds []string `json:"serverIds,omitempty"` // 需要处理该事件的connect实例，在清理redis中的在线状态之前获取
+	Op        int      `json:"op"`
+}
+
+type GroupMsg struct {
+	Userid       int64   `json:"userid"`
+	GroupId      int64   `json:"groupId"`
+	Content      string  `json:"content"`
+	MessageType  string  `json:"messageType"`
+	CreateAt     string  `json:"createAt"`
+	GroupName    string  `json:"groupName"`
+	FromUsername string  `json:"fromUsername"`
+	Avatar       string  `json:"avatar"`
+	Op           int     `json:"op"`
+	Watermark    int64   `json:"watermark"`
+	Seq          int64   `json:"seq"`                // 会话内单调递增的消息序号，客户端据此检测和补齐缺失的消息
+	SnowId       string  `json:"snowId"`             // 生产消息时分配，推送和持久化都以此去重
+	Revision     int64   `json:"revision,omitempty"` // 编辑事件中消息编辑后的版本号
+	EditedAt     string  `json:"editedAt,omitempty"`
+	ReplyTo      string  `json:"replyTo,omitempty"`    // 引用回复的消息snowId
+	Mentions     []int64 `json:"mentions,omitempty"`   // @提及的用户id，被提及的成员即使屏蔽了群聊也应该高亮展示
+	MentionAll   bool    `json:"mentionAll,omitempty"` // 是否@所有人
+	ExpireAt     string  `json:"expireAt,omitempty"`   // 会话设置了消息存活时间时消息的过期时间，过期后不再推送并且会被清理
 }
 
 type FriendMsg struct {
@@ -68,4 +151,21 @@ type FriendMsg struct {
 	Op           int    `json:"op"`
 	Belong       int64  `json:"belong"` // 消息所属于的信箱id（用户id），在connect层需要根据这个id把消息推送到对应用户
 	Watermark    int64  `json:"watermark"`
+	Seq          int64  `json:"seq"`                // 会话内单调递增的消息序号，私聊双方的信箱使用同一个序号
+	SnowId       string `json:"snowId"`             // 生产消息时分配，推送和持久化都以此去重
+	Revision     int64  `json:"revision,omitempty"` // 编辑事件中消息编辑后的版本号
+	EditedAt     string `json:"editedAt,omitempty"`
+	ReplyTo      string `json:"replyTo,omitempty"`  // 引用回复的消息snowId
+	ExpireAt     string `json:"expireAt,omitempty"` // 会话设置了消息存活时间时消息的过期时间，过期后不再推送并且会被清理
+}
+
+// ConversationId 获取会话id，私聊会话与双方的顺序无关
+func ConversationId(_type string, objectA, objectB int64) string {
+	if _type == "group" {
+		return fmt.Sprintf("group_%d", objectB)
+	}
+	if objectA > objectB {
+		objectA, objectB = objectB, objectA
+	}
+	return fmt.Sprintf("friend_%d_%d", objectA, objectB)
 }
@@ -41,25 +41,49 @@ const UserGroupList string = "axis:user_group_list:%d"
 // UserFriendList  用户所有好友id
 const UserFriendList string = "axis:user_friend_list:%d"
 
+// UserUnread 用户在各个会话中的未读消息数，field为会话id
+const UserUnread string = "axis:user_unread:%d"
+
+// UserProfile 用户的展示信息缓存（用户名、头像），发送消息时据此填充发送方信息
+const UserProfile string = "axis:user_profile:%d"
+
+// GroupProfile 群聊的展示信息缓存（群名称）
+const GroupProfile string = "axis:group_profile:%d"
+
 // AllOnlineUser 记录所有在线用户
 const AllOnlineUser string = "axis:online_user"
 
 // KafkaTopicOffset 记录kafka最后一次提交的偏移量,topic名和最新提交偏移量的映射
 const KafkaTopicOffset string = "axis:kafka_topic_offset:%s"
 
-// StatusMsgQueue  利用List实现简单的消息队列，存放对象上下线状态消息
-const StatusMsgQueue string = "axis:status_msg_queue"
+// ConversationSeq 会话内单调递增的消息序号，后缀为会话id
+const ConversationSeq string = "axis:conversation_seq:%s"
+
+// ConversationTTL 会话的消息存活时间（秒）缓存，后缀为会话id
+const ConversationTTL string = "axis:conversation_ttl:%s"
 
-// RedisLock redis distributed lock , 后缀是topic名称，每个topic持有一个分布式锁
-const RedisLock string = "axis:redis_lock:%s"
+// ExpireSweeperLock 清理过期消息的分布式锁，多个logic实例中同一时间只有一个在清理
+const ExpireSweeperLock string = "axis:expire_sweeper_lock"
 
-// UserLetterBox GroupLetterBox 是暂时存放已经消费的数据但是为被持久化的数据，然后定时批量持久化
+// RecentMsg 刚发送的消息，persist层写入db之前撤回和表情回应据此校验，后缀为消息的snowId
+const RecentMsg string = "axis:recent_msg:%s"
+
+// SendIdempotency 发送聊天消息的幂等键，后缀为发送方的userid和客户端生成的watermark
+const SendIdempotency string = "axis:send_idempotency:%d:%d"
+
+// PersistPendingTopic 有新消息等待persist层持久化的topic，生产消息之后登记，persist层认领之后删除
+const PersistPendingTopic string = "axis:persist_pending_topic"
+
+// UserLetterBox GroupLetterBox 升级之前connect层暂存的已经推送但还没有持久化的聊天消息，由persist层一次性写入db
 const UserLetterBox string = "axis_user_letter_box:%d"
 
 const GroupLetterBox string = "axis_group_letter_box:%d"
 
-// PersistentLock 数据批量持久化时要加上分布式锁
-const PersistentLock string = "axis_persistent_lock:%s"
+// LetterBoxMigrated 旧信箱中的消息已经全部写入db的标记
+const LetterBoxMigrated string = "axis:letter_box_migrated"
+
+// StatusMsgQueue  利用List实现简单的消息队列，存放对象上下线状态消息
+const StatusMsgQueue string = "axis:status_msg_queue"
 
 type RedisClient struct {
 	Client map[string]*redis.Client
@@ -211,6 +235,44 @@ func RedisSetString(key string, value []byte, expire time.Duration) error {
 	return nil
 }
 
+// RedisSetNX 只有当key不存在时才会进行设置，ok为true表示设置成功
+func RedisSetNX(key string, value interface{}, expire time.Duration) (ok bool, err error) {
+	client, err := GetRedisClientByKey(key)
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	ok, err = client.SetNX(key, value, expire).Result()
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	return ok, nil
+}
+
+// 只有key当前的值与ARGV[1]相同时才设置为ARGV[2]
+const compareAndSetCommand = `
+    if redis.call("GET", KEYS[1]) == ARGV[1] then
+        redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+        return 1
+    end
+    return 0`
+
+// RedisCompareAndSet key的值仍然是old时设置为value，ok为false表示值已经被其他请求修改
+func RedisCompareAndSet(key string, old, value []byte, expire time.Duration) (ok bool, err error) {
+	client, err := GetRedisClientByKey(key)
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	res, err := client.Eval(compareAndSetCommand, []string{key}, old, value, expire.Milliseconds()).Int64()
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	return res == 1, nil
+}
+
 func RedisGetString(key string) ([]byte, error) {
 	client, err := GetRedisClientByKey(key)
 	if err != nil {
@@ -305,6 +367,7 @@ func RedisHGetAll(key string) (map[string]string, error) {
 	return res, nil
 }
 
+// RedisHGet 获取hash table中field的值，field不存在时返回空字符串
 func RedisHGet(key string, filed string) (string, error) {
 	client, err := GetRedisClientByKey(key)
 	if err != nil {
@@ -312,7 +375,7 @@ func RedisHGet(key string, filed string) (string, error) {
 		return "", err
 	}
 	res, err := client.HGet(key, filed).Result()
-	if err != nil {
+	if err != nil && err != redis.Nil {
 		zlog.Error(err.Error())
 		return "", err
 	}
@@ -350,6 +413,21 @@ func RedisInc(key string) error {
 	return nil
 }
 
+// RedisIncr 自增并返回自增后的值
+func RedisIncr(key string) (int64, error) {
+	client, err := GetRedisClientByKey(key)
+	if err != nil {
+		zlog.Error(err.Error())
+		return 0, err
+	}
+	val, err := client.Incr(key).Result()
+	if err != nil {
+		zlog.Error(err.Error())
+		return 0, err
+	}
+	return val, nil
+}
+
 func RedisDecr(key string) error {
 	client, err := GetRedisClientByKey(key)
 	if err != nil {
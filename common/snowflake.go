@@ -0,0 +1,60 @@
+package common
+
+/**
+*Author: AxisZql
+*Date: 2022-7-25
+*DESC: 从etcd租用snowflake workerId并初始化当前进程的id generator
+ */
+
+import (
+	"axisChat/config"
+	"axisChat/etcd"
+	"axisChat/utils"
+	"axisChat/utils/zlog"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const snowflakeWorkerLease = 10 // workerId租约的ttl，单位秒
+
+// InitSnowflakeNode 为当前进程分配集群内唯一的workerId，租约失效后会重新分配，name用于在etcd中标识占用workerId的进程
+func InitSnowflakeNode(name string) error {
+	conf := config.GetConfig().Common.Etcd
+	endpoints := strings.Split(conf.Address, ";")
+	prefix := fmt.Sprintf("%s/%s", conf.BasePath, conf.SnowflakePath)
+	allocator, err := etcd.NewWorkerIdAllocator(endpoints, prefix, utils.SnowflakeWorkerMax, snowflakeWorkerLease, conf.ConnectionTimeout)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	val := fmt.Sprintf("%s@%s:%d", name, hostname, os.Getpid())
+	workerId, err := allocator.Acquire(val)
+	if err != nil {
+		return err
+	}
+	if err = utils.InitSnowflake(workerId); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			allocator.ListenLeaseRespChan()
+			// 租约失效后workerId可能已经被其他进程占用，重新分配之前停止生成id
+			utils.DisableSnowflake()
+			for {
+				workerId, err = allocator.Acquire(val)
+				if err == nil {
+					break
+				}
+				zlog.Error(fmt.Sprintf("reacquire snowflake worker id err:%v", err))
+				time.Sleep(time.Second)
+			}
+			if err = utils.InitSnowflake(workerId); err != nil {
+				zlog.Error(err.Error())
+			}
+		}
+	}()
+	return nil
+}
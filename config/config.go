@@ -44,11 +44,16 @@ type Config struct {
 			Password string `mapstructure:"password"`
 		}
 
+		Mq struct {
+			Driver string `mapstructure:"driver"` // kafka、redis、memory，默认kafka
+		} `mapstructure:"mq"`
+
 		Etcd struct {
 			Address           string `mapstructure:"address"`
 			BasePath          string `mapstructure:"basePath"`
 			ServerPathLogic   string `mapstructure:"serverPathLogic"`
 			ServerPathConnect string `mapstructure:"serverPathConnect"`
+			SnowflakePath     string `mapstructure:"snowflakePath"` // snowflake workerId的分配路径
 			Username          string `mapstructure:"username"`
 			Password          string `mapstructure:"password"`
 			ConnectionTimeout int    `mapstructure:"connectionTimeout"`
@@ -71,6 +76,8 @@ type Config struct {
 			RpcAddress string `mapstructure:"rpcAddress"`
 			CerPath    string `mapstructure:"cerPath"`
 			KeyPath    string `mapstructure:"keyPath"`
+			// 消息发送之后可以撤回的时间范围，单位分钟
+			RecallWindow int `mapstructure:"recallWindow"`
 		} `mapstructure:"logic"`
 	}
 	ConnectRpc struct {
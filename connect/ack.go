@@ -0,0 +1,119 @@
+package connect
+
+import (
+	"axisChat/proto"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+*Author: AxisZql
+*Date: 2022-7-22
+*DESC: 记录通过PushStream推送过来的消息的投递情况，writePump写入客户端连接后回传投递结果
+ */
+
+// 超过该时间仍然没有被writePump处理的消息，视为投递失败（例如连接断开时缓冲区中还没有写出的消息）
+const ackTimeout = 30 * time.Second
+
+type deliveryAck struct {
+	mutex     sync.Mutex
+	key       string
+	expected  int  // 消息写入了多少个连接的发送缓冲区
+	finished  int  // 已经被writePump处理的数目
+	expectSet bool // expected是否已经确定
+	reported  bool
+	report    func(result proto.PushResult)
+	timer     *time.Timer
+}
+
+type ackRegistry struct {
+	mutex sync.Mutex
+	acks  map[string]*deliveryAck // topic:offset和投递记录之间的映射
+}
+
+var pushAcks = &ackRegistry{
+	acks: make(map[string]*deliveryAck),
+}
+
+func ackKey(topic string, offset int64) string {
+	return fmt.Sprintf("%s:%d", topic, offset)
+}
+
+// Register 在消息写入连接的发送缓冲区之前登记，report只会被调用一次
+func (r *ackRegistry) Register(topic string, offset int64, report func(result proto.PushResult)) *deliveryAck {
+	ack := &deliveryAck{
+		key:    ackKey(topic, offset),
+		report: report,
+	}
+	ack.timer = time.AfterFunc(ackTimeout, func() {
+		ack.finish(proto.PushResult_DROPPED)
+	})
+	r.mutex.Lock()
+	// 同一条消息被task层重传时，之前的记录直接视为投递失败
+	old := r.acks[ack.key]
+	r.acks[ack.key] = ack
+	r.mutex.Unlock()
+	if old != nil {
+		old.finish(proto.PushResult_DROPPED)
+	}
+	return ack
+}
+
+// Done writePump处理完一条消息后调用，committed表示消息成功写入连接并提交了偏移量
+func (r *ackRegistry) Done(topic string, offset int64, committed bool) {
+	r.mutex.Lock()
+	ack := r.acks[ackKey(topic, offset)]
+	r.mutex.Unlock()
+	if ack == nil {
+		// 不是通过PushStream推送的消息
+		return
+	}
+	ack.mutex.Lock()
+	ack.finished++
+	allFinished := ack.expectSet && ack.finished >= ack.expected
+	ack.mutex.Unlock()
+	// 群聊消息只要有一个连接成功提交偏移量就算成功消费
+	if committed {
+		ack.finish(proto.PushResult_COMMITTED)
+	} else if allFinished {
+		ack.finish(proto.PushResult_DROPPED)
+	}
+}
+
+func (r *ackRegistry) remove(ack *deliveryAck) {
+	r.mutex.Lock()
+	if r.acks[ack.key] == ack {
+		delete(r.acks, ack.key)
+	}
+	r.mutex.Unlock()
+}
+
+// Expect 消息写入所有连接的发送缓冲区之后调用，n为写入成功的数目，n为0时以none作为投递结果
+func (a *deliveryAck) Expect(n int, none proto.PushResult) {
+	if n == 0 {
+		a.finish(none)
+		return
+	}
+	a.mutex.Lock()
+	a.expected = n
+	a.expectSet = true
+	allFinished := a.finished >= a.expected
+	a.mutex.Unlock()
+	if allFinished {
+		a.finish(proto.PushResult_DROPPED)
+	}
+}
+
+func (a *deliveryAck) finish(result proto.PushResult) {
+	a.mutex.Lock()
+	if a.reported {
+		a.mutex.Unlock()
+		return
+	}
+	a.reported = true
+	a.mutex.Unlock()
+	a.timer.Stop()
+	pushAcks.remove(a)
+	a.report(result)
+}
@@ -0,0 +1,42 @@
+package connect
+
+import (
+	"axisChat/proto"
+	"testing"
+)
+
+func TestAckRegistry(t *testing.T) {
+	var got []proto.PushResult
+	report := func(result proto.PushResult) {
+		got = append(got, result)
+	}
+
+	// 群聊消息只要有一个连接成功提交就算成功，且只回传一次结果
+	ack := pushAcks.Register("group_chat_1", 1, report)
+	ack.Expect(2, proto.PushResult_DROPPED)
+	pushAcks.Done("group_chat_1", 1, false)
+	pushAcks.Done("group_chat_1", 1, true)
+	if len(got) != 1 || got[0] != proto.PushResult_COMMITTED {
+		t.Fatalf("group ack got %v", got)
+	}
+
+	// 所有连接都写入失败
+	got = nil
+	ack = pushAcks.Register("friend_chat_1", 2, report)
+	pushAcks.Done("friend_chat_1", 2, false) // writePump可能在Expect之前处理完消息
+	ack.Expect(1, proto.PushResult_DROPPED)
+	if len(got) != 1 || got[0] != proto.PushResult_DROPPED {
+		t.Fatalf("friend ack got %v", got)
+	}
+
+	// 没有写入任何连接
+	got = nil
+	ack = pushAcks.Register("friend_chat_1", 3, report)
+	ack.Expect(0, proto.PushResult_USER_NOT_HERE)
+	if len(got) != 1 || got[0] != proto.PushResult_USER_NOT_HERE {
+		t.Fatalf("empty ack got %v", got)
+	}
+	if len(pushAcks.acks) != 0 {
+		t.Fatalf("registry not cleaned %v", pushAcks.acks)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"axisChat/utils/zlog"
 	"encoding/json"
 	"fmt"
-	"github.com/segmentio/kafka-go"
 	"sync"
 	"sync/atomic"
 )
@@ -22,7 +21,7 @@ type Bucket struct {
 	GroupNode  map[int64]*GroupNode
 	routineIdx uint64
 	routineNum uint64
-	routines   []chan kafka.Message // 所有要进行消息推送的消息在channel队列中排队
+	routines   []chan common.MQMessage // 所有要进行消息推送的消息在channel队列中排队
 
 	// statusMsg
 	_routineIdx uint64
@@ -83,11 +82,11 @@ func NewBucket(option ...BucketOption) (b *Bucket) {
 	b = new(Bucket)
 	b.GroupNode = make(map[int64]*GroupNode)
 	b.socketMap = make(map[int64]*Channel, options.SocketSize)
-	b.routines = make([]chan kafka.Message, options.RoutineAmount)
+	b.routines = make([]chan common.MQMessage, options.RoutineAmount)
 	b.routineNum = uint64(options.RoutineAmount)
 	b.routineIdx = 0
 	for i := 0; i < options.RoutineAmount; i++ {
-		c := make(chan kafka.Message, options.RoutineSize)
+		c := make(chan common.MQMessage, options.RoutineSize)
 		b.routines[i] = c
 		// TODO: 开始监听每个请求队列发送过来的消息
 		go b.PushGroupMsg(c)
@@ -105,7 +104,7 @@ func NewBucket(option ...BucketOption) (b *Bucket) {
 	return
 }
 
-func (b *Bucket) BroadcastRoom(msg kafka.Message) {
+func (b *Bucket) BroadcastRoom(msg common.MQMessage) {
 	//TODO:原子加法，将推送消息给对应群聊的请求，按照顺序分发到不同的routines缓冲区
 	idx := atomic.AddUint64(&b.routineIdx, 1) % b.routineNum
 	b.routines[idx] <- msg
@@ -118,9 +117,9 @@ func (b *Bucket) BroadcastStatusRoom(msg []byte) {
 }
 
 //PushGroupMsg 专门处理群聊消息推送
-func (b *Bucket) PushGroupMsg(ch chan kafka.Message) {
+func (b *Bucket) PushGroupMsg(ch chan common.MQMessage) {
 	for {
-		var msg kafka.Message
+		var msg common.MQMessage
 		select {
 		// TODO: MQ中读取的数据会写入ch中
 		case msg = <-ch:
@@ -230,6 +229,40 @@ func (b *Bucket) DeleteChanel(ch *Channel) {
 	b.mutex.RUnlock()
 }
 
+// RemoveChannelFromGroup 用户退出或者被移出群聊时，将其连接从对应的GroupNode中删除
+func (b *Bucket) RemoveChannelFromGroup(ch *Channel, groupId int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, node := range ch.GroupNodes {
+		if node.groupId != groupId {
+			continue
+		}
+		ch.GroupNodes = append(ch.GroupNodes[:i], ch.GroupNodes[i+1:]...)
+		if node.DeleteChannel(ch) {
+			delete(b.GroupNode, groupId)
+		}
+		return
+	}
+}
+
+// DeleteGroupNode 群聊解散时删除对应的GroupNode，并从所有连接记录的GroupNode中移除
+func (b *Bucket) DeleteGroupNode(groupId int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.GroupNode[groupId]; !ok {
+		return
+	}
+	delete(b.GroupNode, groupId)
+	for _, ch := range b.socketMap {
+		for i, node := range ch.GroupNodes {
+			if node.groupId == groupId {
+				ch.GroupNodes = append(ch.GroupNodes[:i], ch.GroupNodes[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
 func (b *Bucket) GetGroupNode(groupId int64) (groupNode *GroupNode) {
 	b.mutex.RLock()
 	groupNode, _ = b.GroupNode[groupId]
@@ -0,0 +1,25 @@
+package connect
+
+import "testing"
+
+func TestBucketDetachGroup(t *testing.T) {
+	b := NewBucket(BucketWithRoutineAmount(1))
+	ch := NewChannel(1)
+	b.PutChannel(1, 10, ch)
+	b.PutChannel(1, 20, ch)
+
+	// 退出群聊后不再属于该群聊的GroupNode，群聊没有在线成员时删除GroupNode
+	b.RemoveChannelFromGroup(ch, 10)
+	if b.GetGroupNode(10) != nil || len(ch.GroupNodes) != 1 || ch.GroupNodes[0].groupId != 20 {
+		t.Fatalf("unexpected group nodes after leave %v", ch.GroupNodes)
+	}
+	if b.GetChannel(1) != ch {
+		t.Fatal("channel should stay connected after leaving group")
+	}
+
+	// 群聊解散
+	b.DeleteGroupNode(20)
+	if b.GetGroupNode(20) != nil || len(ch.GroupNodes) != 0 {
+		t.Fatalf("unexpected group nodes after dissolve %v", ch.GroupNodes)
+	}
+}
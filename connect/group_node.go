@@ -1,8 +1,8 @@
 package connect
 
 import (
+	"axisChat/common"
 	"axisChat/utils/zlog"
-	"github.com/segmentio/kafka-go"
 	"sync"
 )
 
@@ -63,11 +63,14 @@ func (g *GroupNode) DeleteChannel(ch *Channel) bool {
 	return g.drop
 }
 
-func (g *GroupNode) PushGroupMsg(msg kafka.Message) {
+// PushGroupMsg 返回成功写入发送缓冲区的连接数目
+func (g *GroupNode) PushGroupMsg(msg common.MQMessage) (count int) {
 	g.mutex.RLock()
 	for ch := g.socketHead; ch != nil; ch = ch.Next {
 		//to send msg
-		ch.Push(msg)
+		if ch.Push(msg) {
+			count++
+		}
 	}
 	g.mutex.RUnlock()
 	return
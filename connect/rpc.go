@@ -1,6 +1,7 @@
 package connect
 
 import (
+	"axisChat/common"
 	"axisChat/config"
 	"axisChat/etcd"
 	"axisChat/proto"
@@ -10,7 +11,7 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
-	"github.com/segmentio/kafka-go"
+	"io"
 	"strings"
 	"time"
 )
@@ -119,74 +120,210 @@ func (sc *ServerConnect) PushFriendOfflineMsg(ctx context.Context, req *proto.Pu
 	return
 }
 
-func (sc *ServerConnect) PushGroupMsg(ctx context.Context, req *proto.PushGroupMsgReq) (reply *empty.Empty, err error) {
+// PushEventMsg 推送表情回应等轻量事件，群聊事件推送给本实例上该群聊所有在线的成员
+func (sc *ServerConnect) PushEventMsg(ctx context.Context, req *proto.PushEventMsgReq) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
 	if req == nil {
-		err = errors.New("req *proto.PushGroupMsgReq == nil")
+		err = errors.New("req *proto.PushEventMsgReq == nil")
 		zlog.Error(err.Error())
 		return
 	}
-	header := make([]kafka.Header, 0)
-	for _, val := range req.KafkaInfo.Headers {
-		header = append(header, kafka.Header{
-			Key:   val.Key,
-			Value: val.Value,
-		})
-	}
-
-	msgBody, _ := json.Marshal(req.Msg)
-	_time, _ := time.ParseInLocation(time.RFC3339, req.KafkaInfo.Time, time.Local)
-	kafkaMsg := kafka.Message{
-		Topic:         req.KafkaInfo.Topic,
-		Partition:     int(req.KafkaInfo.Partition),
-		Offset:        req.KafkaInfo.Offset,
-		HighWaterMark: req.KafkaInfo.High_WaterMark,
-		Key:           req.KafkaInfo.Key,
-		Value:         msgBody,
-		Headers:       header,
-		Time:          _time,
+	if req.GroupId != 0 {
+		for _, bucket := range DefaultServer.Buckets {
+			if groupNode := bucket.GetGroupNode(req.GroupId); groupNode != nil {
+				groupNode.PushGroupStatusMsg(req.Body)
+			}
+		}
+		return
 	}
-	// todo 遍历bucket推送群聊消息，因为同一个群的成员可以分散在不同的bucket中，故不同bucket中可能有同一个groupNode
-	for _, bucket := range DefaultServer.Buckets {
-		bucket.BroadcastRoom(kafkaMsg)
+	if ch := DefaultServer.Bucket(req.Belong).GetChannel(req.Belong); ch != nil {
+		ch.PushStatus(req.Body)
 	}
 	return
 }
 
-func (sc *ServerConnect) PushFriendMsg(ctx context.Context, req *proto.PushFriendMsgReq) (reply *empty.Empty, err error) {
+// DetachGroupChannel 先把事件推送给离开群聊的成员，再将其连接从GroupNode中删除，之后不再收到该群聊的消息
+func (sc *ServerConnect) DetachGroupChannel(ctx context.Context, req *proto.DetachGroupChannelReq) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
 	if req == nil {
-		zlog.Error("req *proto.PushFriendMsgReq == nil")
+		err = errors.New("req *proto.DetachGroupChannelReq == nil")
+		zlog.Error(err.Error())
+		return
+	}
+	if req.Dissolved {
+		for _, bucket := range DefaultServer.Buckets {
+			if groupNode := bucket.GetGroupNode(req.GroupId); groupNode != nil {
+				groupNode.PushGroupStatusMsg(req.Body)
+			}
+			bucket.DeleteGroupNode(req.GroupId)
+		}
 		return
 	}
-	header := make([]kafka.Header, 0)
-	for _, val := range req.KafkaInfo.Headers {
-		header = append(header, kafka.Header{
-			Key:   val.Key,
-			Value: val.Value,
-		})
+	for _, userid := range req.Userids {
+		bucket := DefaultServer.Bucket(userid)
+		if ch := bucket.GetChannel(userid); ch != nil {
+			ch.PushStatus(req.Body)
+			bucket.RemoveChannelFromGroup(ch, req.GroupId)
+		}
 	}
+	return
+}
 
-	msgBody, _ := json.Marshal(req.Msg)
-	_time, _ := time.ParseInLocation(time.RFC3339, req.KafkaInfo.Time, time.Local)
-	kafkaMsg := kafka.Message{
-		Topic:         req.KafkaInfo.Topic,
-		Partition:     int(req.KafkaInfo.Partition),
-		Offset:        req.KafkaInfo.Offset,
-		HighWaterMark: req.KafkaInfo.High_WaterMark,
-		Key:           req.KafkaInfo.Key,
-		Value:         msgBody,
-		Headers:       header,
-		Time:          _time,
+func (sc *ServerConnect) PushGroupMsg(ctx context.Context, req *proto.PushGroupMsgReq) (reply *proto.PushMsgReply, err error) {
+	if req == nil {
+		err = errors.New("req *proto.PushGroupMsgReq == nil")
+		zlog.Error(err.Error())
+		return
 	}
-	// todo 获取目标推送用户所在的令牌桶
-	bucket := DefaultServer.Bucket(req.Msg.Belong)
-	ch := bucket.GetChannel(req.Msg.Belong)
-	if ch != nil {
-		ch.Push(kafkaMsg) // 向目标用户的消息发送缓冲区中写入消息
-	} else {
-		err = errors.New(fmt.Sprintf("bucket.GetChannel(req.Msg.FriendId=%d) get nil", req.Msg.FriendId))
+	reply = waitPushResult(ctx, &proto.PushStreamReq_Item{
+		GroupMsg:  req.Msg,
+		KafkaInfo: req.KafkaInfo,
+	})
+	return
+}
+
+func (sc *ServerConnect) PushFriendMsg(ctx context.Context, req *proto.PushFriendMsgReq) (reply *proto.PushMsgReply, err error) {
+	if req == nil {
+		err = errors.New("req *proto.PushFriendMsgReq == nil")
 		zlog.Error(err.Error())
+		return
 	}
+	reply = waitPushResult(ctx, &proto.PushStreamReq_Item{
+		FriendMsg: req.Msg,
+		KafkaInfo: req.KafkaInfo,
+	})
 	return
 }
+
+// 单次rpc推送聊天消息时等待投递结果的最长时间，超过该时间则返回HANDED_OFF
+const unaryAckWait = 3 * time.Second
+
+func waitPushResult(ctx context.Context, item *proto.PushStreamReq_Item) *proto.PushMsgReply {
+	res := make(chan proto.PushResult, 1)
+	pushChatMsg(item, func(result proto.PushResult) {
+		res <- result
+	})
+	timer := time.NewTimer(unaryAckWait)
+	defer timer.Stop()
+	select {
+	case result := <-res:
+		return &proto.PushMsgReply{Result: result}
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return &proto.PushMsgReply{Result: proto.PushResult_HANDED_OFF}
+}
+
+// toMQMessage 根据task层推送过来的消息位置信息还原MQ中的消息
+func toMQMessage(info *proto.KafkaMsgInfo, value []byte) common.MQMessage {
+	_time, _ := time.ParseInLocation(time.RFC3339, info.Time, time.Local)
+	return common.MQMessage{
+		Topic:     info.Topic,
+		Partition: int(info.Partition),
+		Offset:    info.Offset,
+		Key:       info.Key,
+		Value:     value,
+		Time:      _time,
+	}
+}
+
+// 回传投递结果时每次最多合并的结果数目
+const streamReplyBatch = 64
+
+// PushStream task层通过该双向流批量推送聊天消息，消息被writePump处理后回传投递结果
+func (sc *ServerConnect) PushStream(stream proto.ConnectLayer_PushStreamServer) error {
+	ctx := stream.Context()
+	results := make(chan *proto.PushStreamReply_Result, 256)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case res := <-results:
+				reply := &proto.PushStreamReply{Results: []*proto.PushStreamReply_Result{res}}
+				// 合并已经产生的投递结果，减少发送次数
+			collect:
+				for len(reply.Results) < streamReplyBatch {
+					select {
+					case res = <-results:
+						reply.Results = append(reply.Results, res)
+					default:
+						break collect
+					}
+				}
+				if err := stream.Send(reply); err != nil {
+					zlog.Error(fmt.Sprintf("push stream send reply err:%v", err))
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			zlog.Error(fmt.Sprintf("push stream recv err:%v", err))
+			return err
+		}
+		for _, item := range req.Items {
+			seq := item.Seq
+			pushChatMsg(item, func(result proto.PushResult) {
+				select {
+				case results <- &proto.PushStreamReply_Result{Seq: seq, Result: result}:
+				case <-ctx.Done():
+				}
+			})
+		}
+	}
+}
+
+// pushChatMsg 将聊天消息写入目标连接的发送缓冲区，投递结果确定后调用report
+func pushChatMsg(item *proto.PushStreamReq_Item, report func(result proto.PushResult)) {
+	if item.KafkaInfo == nil {
+		zlog.Error(fmt.Sprintf("push chat msg seq=%d without kafkaInfo", item.Seq))
+		report(proto.PushResult_DROPPED)
+		return
+	}
+	switch {
+	case item.GroupMsg != nil:
+		// todo 同一个群的成员可以分散在不同的bucket中，故不同bucket中可能有同一个groupNode
+		var groupNodes []*GroupNode
+		for _, bucket := range DefaultServer.Buckets {
+			if groupNode := bucket.GetGroupNode(item.GroupMsg.GroupId); groupNode != nil {
+				groupNodes = append(groupNodes, groupNode)
+			}
+		}
+		if len(groupNodes) == 0 {
+			report(proto.PushResult_USER_NOT_HERE)
+			return
+		}
+		msgBody, _ := json.Marshal(item.GroupMsg)
+		msg := toMQMessage(item.KafkaInfo, msgBody)
+		ack := pushAcks.Register(msg.Topic, msg.Offset, report)
+		count := 0
+		for _, groupNode := range groupNodes {
+			count += groupNode.PushGroupMsg(msg)
+		}
+		ack.Expect(count, proto.PushResult_DROPPED)
+	case item.FriendMsg != nil:
+		ch := DefaultServer.Bucket(item.FriendMsg.Belong).GetChannel(item.FriendMsg.Belong)
+		if ch == nil {
+			report(proto.PushResult_USER_NOT_HERE)
+			return
+		}
+		msgBody, _ := json.Marshal(item.FriendMsg)
+		msg := toMQMessage(item.KafkaInfo, msgBody)
+		ack := pushAcks.Register(msg.Topic, msg.Offset, report)
+		count := 0
+		if ch.Push(msg) {
+			count = 1
+		}
+		ack.Expect(count, proto.PushResult_DROPPED)
+	default:
+		zlog.Error(fmt.Sprintf("push chat msg seq=%d without msg", item.Seq))
+		report(proto.PushResult_DROPPED)
+	}
+}
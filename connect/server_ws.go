@@ -2,8 +2,6 @@ package connect
 
 import (
 	"axisChat/common"
-	"axisChat/db"
-	"axisChat/proto"
 	"axisChat/utils/zlog"
 	"encoding/json"
 	"fmt"
@@ -21,18 +19,15 @@ func (ws *WsServer) writePump(ch *Channel) {
 		err error
 	)
 	ticker := time.NewTicker(ws.Options.PingPeriod)
-	commitTicker := time.NewTicker(10 * time.Second) // 每10持久化一次聊天记录
 
 	defer func() {
-		commitTicker.Stop()
 		err := ch.Conn.Close()
 		if err != nil {
 			zlog.Error(err.Error())
 		}
-		saveChatDataToDb(ch)
 	}()
 	if err != nil {
-		zlog.Error(fmt.Sprintf("get kafka consum reader err:%v", err))
+		zlog.Error(fmt.Sprintf("get mq consume reader err:%v", err))
 		return
 	}
 
@@ -90,6 +85,7 @@ func (ws *WsServer) writePump(ch *Channel) {
 			w, err := ch.Conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				zlog.Warn(fmt.Sprintf("ch.Conn.NextWriter err %v", err))
+				pushAcks.Done(msg.Topic, msg.Offset, false)
 				return
 			}
 			zlog.Debug(fmt.Sprintf("message write body:%s", string(msg.Value)))
@@ -97,103 +93,16 @@ func (ws *WsServer) writePump(ch *Channel) {
 			_, err = w.Write(DealWebSocketResp(msg.Value))
 			if err = w.Close(); err != nil {
 				zlog.Error(fmt.Sprintf("w.Close err :%v", err))
+				pushAcks.Done(msg.Topic, msg.Offset, false)
 				return
 			}
 			if err != nil {
 				zlog.Error(fmt.Sprintf("push msg get err: %v", err))
+				pushAcks.Done(msg.Topic, msg.Offset, false)
 			} else {
-				// todo 消息消费成功后释放redis分布式锁
-				redisLocker, _ := common.NewRedisLocker(fmt.Sprintf(common.RedisLock, msg.Topic), msg.Topic)
-				// todo 如果当前消息被成功消费了offset>=msg.Offset 则不用提交确认消费的偏移量，因为消费后的消息已经持久化到db中了
-				res, err := common.RedisGetString(fmt.Sprintf(common.KafkaTopicOffset, msg.Topic))
-				if err != nil {
-					zlog.Error(fmt.Sprintf("common.RedisGetString(fmt.Sprintf(common.KafkaTopicOffset, %s))", msg.Topic))
-				}
-				var hasCommit common.KafkaMsgInfo
-				_ = json.Unmarshal(res, &hasCommit)
-				offset := hasCommit.Offset
-				if offset >= msg.Offset && offset != 0 {
-					// 证明该消息已经被其他服务消费过（群聊消息会出现这种情况）
-					zlog.Info(fmt.Sprintf("msg:%s have been consumed by other server 「curOffset=%d,preOffset=%d」", string(msg.Value), msg.Offset, offset))
-					// todo 释放redis分布式锁
-					_, _ = redisLocker.Release()
-				} else {
-					offsetInfo := common.KafkaMsgInfo{
-						Topic:     msg.Topic,
-						Partition: msg.Partition,
-						Offset:    msg.Offset,
-					}
-					offsetInfoPayload, _ := json.Marshal(offsetInfo)
-					// 以redis提交的偏移量为准
-					err = common.RedisSetString(fmt.Sprintf(common.KafkaTopicOffset, msg.Topic), offsetInfoPayload, 0)
-					if err != nil {
-						zlog.Error(fmt.Sprintf("common.RedisSetString(fmt.Sprintf(common.KafkaTopicOffset, msg.Topic), msg.Offset, 0) err: %v", err))
-						return
-					}
-					// todo 释放redis分布式锁
-					_, _ = redisLocker.Release()
-
-					//todo task 此处需要持久化消息到db中
-					var msgOp MsgOp
-					_ = json.Unmarshal(msg.Value, &msgOp)
-					// 只有聊天消息才会被持久化
-					switch int(msgOp.Op) {
-					case common.OpGroupMsgSend:
-						var _msg proto.PushGroupMsgReq_Msg
-						_ = json.Unmarshal(msg.Value, &_msg)
-						dbMsg := &db.TMessage{
-							Belong:      _msg.GroupId,
-							SnowID:      _msg.SnowId,
-							Type:        "group",
-							Content:     _msg.Content,
-							FromA:       _msg.Userid,
-							ToB:         _msg.GroupId,
-							MessageType: _msg.MessageType,
-						}
-						body, _ := json.Marshal(&dbMsg)
-						rLock, _ := common.NewRedisLocker(fmt.Sprintf(common.PersistentLock, fmt.Sprintf(common.GroupLetterBox, _msg.GroupId)), "lock")
-						rLock.SetExpire(60)
-						lock, _ := rLock.Acquire()
-						for !lock {
-							lock, _ = rLock.Acquire()
-							time.Sleep(time.Millisecond * 280)
-						}
-						err = common.RedisHSet(fmt.Sprintf(common.GroupLetterBox, _msg.GroupId), _msg.SnowId, body)
-						_, _ = rLock.Release()
-						if err != nil {
-							zlog.Error(fmt.Sprintf("Failed to temporarily store data 「err=%v」", err))
-						}
-					case common.OpFriendMsgSend:
-						var _msg proto.PushFriendMsgReq_Msg
-						_ = json.Unmarshal(msg.Value, &_msg)
-						dbMsg := &db.TMessage{
-							Belong:      ch.Userid,
-							SnowID:      _msg.SnowId,
-							Type:        "friend",
-							Content:     _msg.Content,
-							FromA:       _msg.Userid,
-							ToB:         _msg.FriendId,
-							MessageType: _msg.MessageType,
-						}
-						body, _ := json.Marshal(&dbMsg)
-						rLock, _ := common.NewRedisLocker(fmt.Sprintf(common.PersistentLock, fmt.Sprintf(common.UserLetterBox, ch.Userid)), "lock")
-						rLock.SetExpire(60)
-						lock, _ := rLock.Acquire()
-						for !lock {
-							lock, _ = rLock.Acquire()
-							time.Sleep(time.Millisecond * 290)
-						}
-						err = common.RedisHSet(fmt.Sprintf(common.UserLetterBox, ch.Userid), _msg.SnowId, body)
-						_, _ = rLock.Release()
-						if err != nil {
-							zlog.Error(fmt.Sprintf("Failed to temporarily store data 「err=%v」", err))
-						}
-					}
-				}
+				// 消息的持久化由persist层负责，写入连接后即可回传投递结果，偏移量由task层按顺序提交
+				pushAcks.Done(msg.Topic, msg.Offset, true)
 			}
-		case <-commitTicker.C:
-			// 定时持久化数据到db
-			saveChatDataToDb(ch)
 		case <-ticker.C:
 			// 利用心跳包定期检测客户端是否存活
 			err := ch.Conn.SetWriteDeadline(time.Now().Add(ws.Options.WriteWait))
@@ -311,66 +220,3 @@ func (ws *WsServer) readPump(ch *Channel, c *Connect) {
 		}
 	}
 }
-
-// 持久化数据到db
-func saveChatDataToDb(ch *Channel) {
-	// todo：需要使用分布式锁防止其他新的消息在此刻写入redis
-	rLock, err := common.NewRedisLocker(fmt.Sprintf(common.PersistentLock, fmt.Sprintf(common.UserLetterBox, ch.Userid)), "lock")
-	if err != nil {
-		zlog.Error(err.Error())
-		return
-	}
-	rLock.SetExpire(60)
-	lock, _ := rLock.Acquire()
-	//分布式锁获取成功的情况才继续
-	for !lock {
-		lock, _ = rLock.Acquire()
-		time.Sleep(time.Millisecond * 300)
-	}
-	allFriendMsg, err := common.RedisHGetAll(fmt.Sprintf(common.UserLetterBox, ch.Userid))
-	if err != nil {
-		zlog.Error(err.Error())
-		return
-	}
-	var friendMsg []db.TMessage
-	for snowId, payload := range allFriendMsg {
-		zlog.Debug(fmt.Sprintf("snowId==%s", snowId))
-		var tmp db.TMessage
-		_ = json.Unmarshal([]byte(payload), &tmp)
-		friendMsg = append(friendMsg, tmp)
-	}
-	db.SaveMsgInBatches(friendMsg)
-	// 删除已经持久化的数据
-	err = common.RedisDelString(fmt.Sprintf(common.UserLetterBox, ch.Userid))
-	if err != nil {
-		zlog.Error(err.Error())
-	}
-	_, _ = rLock.Release()
-
-	for _, val := range ch.GroupNodes {
-		var groupMsg []db.TMessage
-		rLock, _ = common.NewRedisLocker(fmt.Sprintf(common.PersistentLock, fmt.Sprintf(common.GroupLetterBox, val.groupId)), "lock")
-		rLock.SetExpire(60)
-		for !lock {
-			lock, _ = rLock.Acquire()
-			time.Sleep(time.Millisecond * 300)
-		}
-		allGroupMsg, err := common.RedisHGetAll(fmt.Sprintf(common.GroupLetterBox, val.groupId))
-		if err != nil {
-			zlog.Error(err.Error())
-			break
-		}
-		for snowId, payload := range allGroupMsg {
-			zlog.Debug(fmt.Sprintf("snowId==%s", snowId))
-			var tmp db.TMessage
-			_ = json.Unmarshal([]byte(payload), &tmp)
-			groupMsg = append(groupMsg, tmp)
-		}
-		db.SaveMsgInBatches(groupMsg)
-		err = common.RedisDelString(fmt.Sprintf(common.GroupLetterBox, val.groupId))
-		if err != nil {
-			zlog.Error(err.Error())
-		}
-		_, _ = rLock.Release()
-	}
-}
@@ -1,8 +1,8 @@
 package connect
 
 import (
+	"axisChat/common"
 	"github.com/gorilla/websocket"
-	"github.com/segmentio/kafka-go"
 	"net"
 )
 
@@ -13,8 +13,8 @@ import (
  */
 
 type Channel struct {
-	BroadcastMsg    chan kafka.Message //要推送给你当前连接对应用户的消息数据
-	BroadcastStatus chan []byte        // 状态消息广播通道
+	BroadcastMsg    chan common.MQMessage //要推送给你当前连接对应用户的消息数据
+	BroadcastStatus chan []byte           // 状态消息广播通道
 	Userid          int64
 	Conn            *websocket.Conn
 	CnnTcp          *net.TCPConn
@@ -25,17 +25,19 @@ type Channel struct {
 
 func NewChannel(size int) (s *Channel) {
 	return &Channel{
-		BroadcastMsg:    make(chan kafka.Message, size), //最多可以往信道中写入的消息条数为size
+		BroadcastMsg:    make(chan common.MQMessage, size), //最多可以往信道中写入的消息条数为size
 		BroadcastStatus: make(chan []byte, size),
 	}
 }
 
 // Push Channel
-//进行消息推送准备
-func (ch *Channel) Push(msg kafka.Message) {
+//进行消息推送准备，发送缓冲区已满时丢弃消息并返回false
+func (ch *Channel) Push(msg common.MQMessage) bool {
 	select {
 	case ch.BroadcastMsg <- msg:
+		return true
 	default:
+		return false
 	}
 }
 
@@ -36,6 +36,34 @@ func DealWebSocketResp(msg []byte) []byte {
 		resp = append(resp, "friendMsg", string(msg))
 		data, _ := json.Marshal(&resp)
 		return data
+	case common.OpMsgRecallSend:
+		resp = append(resp, "msgRecall", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpMsgEditSend:
+		resp = append(resp, "msgEdit", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpMsgReactionSend:
+		resp = append(resp, "msgReaction", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpGroupDetachSend:
+		resp = append(resp, "groupDetach", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpFriendRequestSend:
+		resp = append(resp, "friendRequest", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpGroupJoinRequestSend:
+		resp = append(resp, "groupJoinRequest", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
+	case common.OpUnreadSend:
+		resp = append(resp, "unread", string(msg))
+		data, _ := json.Marshal(&resp)
+		return data
 	}
 	return []byte{}
 }
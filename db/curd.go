@@ -2,9 +2,13 @@ package db
 
 import (
 	"axisChat/utils/zlog"
+	"errors"
 	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"sort"
+	"time"
 )
 
 func QueryUserById(id int64, user *TUser) {
@@ -181,65 +185,284 @@ func QueryGroupAllUser(groupId int64, userList *[]TUser) {
 	}
 }
 
-func CreateGroup(group *TGroup) {
+// CreateGroup 创建群聊，群聊名称已经被使用时返回ErrGroupNameUsed
+func CreateGroup(group *TGroup) error {
 	db := GetDb()
 	r := db.Model(&TGroup{}).Create(group)
 	if r.Error != nil {
 		zlog.Error(r.Error.Error())
-		return
+		return groupNameError(r.Error)
 	}
+	return nil
 }
 
-func UpdateGroup(group *TGroup) {
+// UpdateGroup 修改群资料，updates为需要修改的字段和修改后的值，可以修改为空，群聊名称已经被使用时返回ErrGroupNameUsed
+func UpdateGroup(groupId int64, updates map[string]interface{}) error {
 	db := GetDb()
-	// 使用struct更新时Updates方法只会更新非零值字段
-	r := db.Updates(group)
-	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+	r := db.Model(&TGroup{}).Where("id = ?", groupId).Updates(updates)
+	if r.Error != nil {
 		zlog.Error(r.Error.Error())
-		return
+		return groupNameError(r.Error)
+	}
+	return nil
+}
+
+// ErrGroupNameUsed 群聊名称与其他群聊（包括已经解散的群聊）重复
+var ErrGroupNameUsed = errors.New("group name is used")
+
+// groupNameError 并发创建或者修改群聊时由group_name的唯一索引保证名称不重复
+func groupNameError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrGroupNameUsed
+	}
+	return err
+}
+
+// mysqlDuplicateEntry 违反唯一索引时mysql返回的错误码
+const mysqlDuplicateEntry = 1062
+
+// messageOrder 会话内的消息按seq排序，seq相同（引入seq之前的历史消息）时按snowId的数值排序，
+// snowId是varchar类型，先比较长度避免位数不同的id按字符串比较时顺序错乱
+const messageOrder = "m.seq DESC, LENGTH(m.snow_id) DESC, m.snow_id DESC"
+
+// messageLess 与messageOrder的排序规则一致，用于恢复分页查询结果的正序
+func messageLess(seqA, seqB int64, snowIdA, snowIdB string) bool {
+	if seqA != seqB {
+		return seqA < seqB
 	}
+	if len(snowIdA) != len(snowIdB) {
+		return len(snowIdA) < len(snowIdB)
+	}
+	return snowIdA < snowIdB
+}
+
+// QueryMaxMessageSeq 查询会话中已经持久化的最大seq，用于redis中的会话序号丢失后重新确定起点
+func QueryMaxMessageSeq(_type string, objectA, objectB int64) (int64, error) {
+	var seq int64
+	db := GetDb().Model(&TMessage{}).Select("IFNULL(MAX(seq), 0)")
+	if _type == "group" {
+		db = db.Where("type = 'group' AND belong = ?", objectB)
+	} else {
+		db = db.Where("type = 'friend' AND belong = ? AND ((from_a = ? AND to_b = ?) OR (from_a = ? AND to_b = ?))", objectA, objectA, objectB, objectB, objectA)
+	}
+	r := db.Scan(&seq)
 	if r.Error != nil {
-		zlog.Info(r.Error.Error())
+		zlog.Error(r.Error.Error())
+		return 0, r.Error
 	}
+	return seq, nil
 }
 
-func QueryFriendMessageByPage(userid, friendId int64, msgList *[]VFriendMessage, current int, pageSize int) {
-	if current <= 0 || pageSize <= 0 {
-		current = 1
-		pageSize = 16
+// SaveConversationSeq 记录会话已经分配的序号，只会增大
+func SaveConversationSeq(conversationId string, seq int64) error {
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"seq": gorm.Expr("GREATEST(seq, VALUES(seq))")}),
+	}).Create(&TConversationSeq{ConversationId: conversationId, Seq: seq})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
 	}
+	return nil
+}
+
+// QueryConversationSeq 查询会话已经分配的最大序号，没有记录时返回0
+func QueryConversationSeq(conversationId string) (int64, error) {
+	var seq int64
 	db := GetDb()
-	r := db.Table("v_friend_message").Where("((userid = ? AND friend_id = ?) OR (friend_id = ? AND userid = ?)) AND belong = ?", userid, friendId, userid, friendId, userid).Order("snow_id DESC").Limit(pageSize).Offset((current - 1) * pageSize).Find(msgList)
-	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+	r := db.Model(&TConversationSeq{}).Select("IFNULL(MAX(seq), 0)").Where("conversation_id = ?", conversationId).Scan(&seq)
+	if r.Error != nil {
 		zlog.Error(r.Error.Error())
-		return
+		return 0, r.Error
+	}
+	return seq, nil
+}
+
+// MessageCursor 历史消息的分页参数，Before、After为snowId游标，分别获取该消息之前和之后的消息，
+// 都为空时按Current、PageSize分页，Current为1时获取最近的消息
+type MessageCursor struct {
+	Before   string
+	After    string
+	Current  int
+	PageSize int
+}
+
+// 历史消息每页的默认数目
+const defaultMessagePageSize = 16
+
+// snowIdBefore、snowIdAfter 按snowId的数值与游标比较，与messageOrder一样先比较长度
+const (
+	snowIdBefore = "(LENGTH(m.snow_id) < LENGTH(?) OR (LENGTH(m.snow_id) = LENGTH(?) AND m.snow_id < ?))"
+	snowIdAfter  = "(LENGTH(m.snow_id) > LENGTH(?) OR (LENGTH(m.snow_id) = LENGTH(?) AND m.snow_id > ?))"
+)
+
+// 引入seq之后的消息（包括系统消息）seq都大于0且在会话内唯一，直接使用(belong, type, seq)索引排序；
+// seq为0的历史消息都早于它们，只能按snowId的数值排序，分页时两段分别查询，只有历史消息需要额外排序
+const (
+	seqMessage      = "m.seq > 0"
+	legacyMessage   = "m.seq = 0"
+	legacyOrder     = "LENGTH(m.snow_id) DESC, m.snow_id DESC"
+	legacyOrderAsc  = "LENGTH(m.snow_id) ASC, m.snow_id ASC"
+	messageOrderAsc = "m.seq ASC, LENGTH(m.snow_id) ASC, m.snow_id ASC"
+)
+
+// messageFinder 执行一段分页查询并把结果追加到消息列表中，返回本段查询到的消息数目
+type messageFinder func(query *gorm.DB) (int, error)
+
+// pageMessage 按游标或者页码查询历史消息，与messageOrder的排序规则一致，多查询一条用于判断是否还有更多的消息
+func (c *MessageCursor) pageMessage(query *gorm.DB, find messageFinder) error {
+	if c.PageSize <= 0 {
+		c.PageSize = defaultMessagePageSize
 	}
+	// 每一段查询都从相同的条件开始
+	query = query.Session(&gorm.Session{})
+	limit := c.PageSize + 1
+	switch {
+	case c.Before != "":
+		seq, ok := cursorSeq(c.Before)
+		if !ok {
+			// 游标消息已经被清理时只能按snowId比较
+			_, err := find(query.Where(snowIdBefore, c.Before, c.Before, c.Before).Order(messageOrder).Limit(limit))
+			return err
+		}
+		if seq == 0 {
+			_, err := find(query.Where(legacyMessage).Where(snowIdBefore, c.Before, c.Before, c.Before).Order(legacyOrder).Limit(limit))
+			return err
+		}
+		n, err := find(query.Where("m.seq > 0 AND m.seq < ?", seq).Order("m.seq DESC").Limit(limit))
+		if err != nil || n >= limit {
+			return err
+		}
+		_, err = find(query.Where(legacyMessage).Order(legacyOrder).Limit(limit - n))
+		return err
+	case c.After != "":
+		seq, ok := cursorSeq(c.After)
+		if !ok {
+			_, err := find(query.Where(snowIdAfter, c.After, c.After, c.After).Order(messageOrderAsc).Limit(limit))
+			return err
+		}
+		if seq > 0 {
+			_, err := find(query.Where("m.seq > ?", seq).Order("m.seq ASC").Limit(limit))
+			return err
+		}
+		n, err := find(query.Where(legacyMessage).Where(snowIdAfter, c.After, c.After, c.After).Order(legacyOrderAsc).Limit(limit))
+		if err != nil || n >= limit {
+			return err
+		}
+		_, err = find(query.Where(seqMessage).Order("m.seq ASC").Limit(limit - n))
+		return err
+	default:
+		if c.Current <= 0 {
+			c.Current = 1
+		}
+		offset := (c.Current - 1) * c.PageSize
+		n, err := find(query.Where(seqMessage).Order("m.seq DESC").Offset(offset).Limit(limit))
+		if err != nil || n >= limit {
+			return err
+		}
+		// seq大于0的消息不够一页时从历史消息中补齐，页码越过了seq大于0的消息时需要扣除它们的数目
+		legacyOffset := 0
+		if n == 0 && offset > 0 {
+			var count int64
+			if r := query.Where(seqMessage).Select("COUNT(*)").Scan(&count); r.Error != nil {
+				return r.Error
+			}
+			if legacyOffset = offset - int(count); legacyOffset < 0 {
+				legacyOffset = 0
+			}
+		}
+		_, err = find(query.Where(legacyMessage).Order(legacyOrder).Offset(legacyOffset).Limit(limit - n))
+		return err
+	}
+}
+
+// cursorSeq 查询游标消息的seq，私聊双方信箱中的同一条消息seq相同，游标消息已经被清理时ok为false
+func cursorSeq(snowId string) (seq int64, ok bool) {
+	var seqList []int64
+	r := GetDb().Unscoped().Model(&TMessage{}).Where("snow_id = ?", snowId).Limit(1).Pluck("seq", &seqList)
 	if r.Error != nil {
-		zlog.Info(r.Error.Error())
+		zlog.Error(r.Error.Error())
 	}
-	// 由于获取的是最近消息，所以还要根据snowId恢复消息顺序
+	if len(seqList) == 0 {
+		return 0, false
+	}
+	return seqList[0], true
+}
+
+// messageNotExpired 过期的消息在被清理之前也不再返回
+const messageNotExpired = "(m.expire_at IS NULL OR m.expire_at > ?)"
+
+// friendMessageSelect 与v_friend_message的字段一致，直接查询t_message避免视图中子查询的排序
+const friendMessageSelect = "m.id, m.belong, m.from_a AS userid, m.to_b AS friend_id, " +
+	"IF(m.status = 2, '该消息已被撤回', m.content) AS content, IF(m.status = 2, 'text', m.message_type) AS message_type, " +
+	"friend.username AS friend_name, u.username AS from_username, u.avatar, " +
+	"m.snow_id, m.seq, m.status, m.revision, m.edited_at, m.reply_to, m.expire_at, m.create_at"
+
+// QueryFriendMessageByPage 查询用户信箱中与好友的历史消息，返回的消息按seq正序排列
+func QueryFriendMessageByPage(userid, friendId int64, msgList *[]VFriendMessage, cursor MessageCursor) (hasMore bool, err error) {
+	db := GetDb()
+	query := db.Table("t_message AS m").Select(friendMessageSelect).
+		Joins("JOIN t_user AS friend ON friend.id = m.to_b").
+		Joins("JOIN t_user AS u ON u.id = m.from_a").
+		Where("m.belong = ? AND m.type = 'friend' AND m.delete_at IS NULL", userid).
+		Where("(m.from_a = ? AND m.to_b = ?) OR (m.from_a = ? AND m.to_b = ?)", userid, friendId, friendId, userid).
+		Where(messageNotExpired, time.Now())
+	*msgList = nil
+	err = cursor.pageMessage(query, func(tx *gorm.DB) (int, error) {
+		var part []VFriendMessage
+		r := tx.Find(&part)
+		*msgList = append(*msgList, part...)
+		return len(part), r.Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	// Before和页码分页时更多指更早的消息，After时指更新的消息
+	if hasMore = len(*msgList) > cursor.PageSize; hasMore {
+		*msgList = (*msgList)[:cursor.PageSize]
+	}
+	// 由于获取的可能是最近消息，所以还要根据seq恢复消息顺序
 	sort.Slice(*msgList, func(i, j int) bool {
-		return (*msgList)[i].SnowId < (*msgList)[j].SnowId
+		return messageLess((*msgList)[i].Seq, (*msgList)[j].Seq, (*msgList)[i].SnowId, (*msgList)[j].SnowId)
 	})
+	return
 }
 
-func QueryGroupMessageByPage(groupId int64, msgList *[]VGroupMessage, current int, pageSize int) {
-	if current <= 0 || pageSize <= 0 {
-		current = 1
-		pageSize = 16
-	}
+// groupMessageSelect 与v_group_message的字段一致，系统消息的发送方不存在，发送方信息为空
+const groupMessageSelect = "m.id, m.belong, m.from_a AS userid, m.to_b AS group_id, " +
+	"IF(m.status = 2, '该消息已被撤回', m.content) AS content, IF(m.status = 2, 'text', m.message_type) AS message_type, " +
+	"g.group_name, IFNULL(u.username, '') AS from_username, IFNULL(u.avatar, '') AS avatar, " +
+	"m.snow_id, m.seq, m.status, m.revision, m.edited_at, m.reply_to, m.mentions, m.mention_all, m.expire_at, m.create_at"
+
+// QueryGroupMessageByPage 查询群聊的历史消息，返回的消息按seq正序排列
+func QueryGroupMessageByPage(groupId int64, msgList *[]VGroupMessage, cursor MessageCursor) (hasMore bool, err error) {
 	db := GetDb()
-	r := db.Table("v_group_message").Where("group_id = ? AND belong = ?", groupId, groupId).Order("snow_id DESC").Limit(pageSize).Offset((current - 1) * pageSize).Find(msgList)
-	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
-		zlog.Error(r.Error.Error())
-		return
+	query := db.Table("t_message AS m").Select(groupMessageSelect).
+		Joins("JOIN t_group AS g ON g.id = m.to_b").
+		Joins("LEFT JOIN t_user AS u ON u.id = m.from_a").
+		Where("m.belong = ? AND m.type = 'group' AND m.delete_at IS NULL", groupId).
+		Where(messageNotExpired, time.Now())
+	*msgList = nil
+	err = cursor.pageMessage(query, func(tx *gorm.DB) (int, error) {
+		var part []VGroupMessage
+		r := tx.Find(&part)
+		*msgList = append(*msgList, part...)
+		return len(part), r.Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
 	}
-	if r.Error != nil {
-		zlog.Info(r.Error.Error())
+	if hasMore = len(*msgList) > cursor.PageSize; hasMore {
+		*msgList = (*msgList)[:cursor.PageSize]
 	}
 	sort.Slice(*msgList, func(i, j int) bool {
-		return (*msgList)[i].SnowId < (*msgList)[j].SnowId
+		return messageLess((*msgList)[i].Seq, (*msgList)[j].Seq, (*msgList)[i].SnowId, (*msgList)[j].SnowId)
 	})
+	return
 }
 
 func CreateRelation(relation *TRelation) {
@@ -259,14 +482,789 @@ func CreateRelation(relation *TRelation) {
 	}
 }
 
-func SaveMsgInBatches(msg []TMessage) {
+// SaveMsgInBatches 批量持久化消息，(belong, snow_id)已经存在的消息直接忽略，重复写入同一批消息是幂等的
+func SaveMsgInBatches(msg []TMessage) error {
 	if msg == nil || len(msg) == 0 {
+		return nil
+	}
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(msg, 100) // 每次更新写入100条数据
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryMessageBySnowId 查询snowId对应的消息，私聊消息在双方信箱中各有一条记录，返回其中任意一条
+func QueryMessageBySnowId(snowId string, msg *TMessage) {
+	db := GetDb()
+	r := db.Where("snow_id = ? AND (expire_at IS NULL OR expire_at > ?)", snowId, time.Now()).First(msg)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+		return
+	}
+	if r.Error != nil {
+		zlog.Info(r.Error.Error())
+	}
+}
+
+// RecallMessage 将snowId对应的所有消息记录标记为已撤回
+func RecallMessage(snowId string) error {
+	db := GetDb()
+	r := db.Model(&TMessage{}).Where("snow_id = ?", snowId).Update("status", MessageStatusRecalled)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// EditMessage 保存消息编辑之前的版本并更新snowId对应的所有消息记录，msg为编辑之前的消息，
+// 消息在此期间已经被其他请求编辑过（版本号变化）时ok为false
+func EditMessage(msg *TMessage, content string, editedAt time.Time) (ok bool, err error) {
+	db := GetDb()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		r := tx.Model(&TMessage{}).Where("snow_id = ? AND revision = ?", msg.SnowID, msg.Revision).Updates(map[string]interface{}{
+			"content":   content,
+			"revision":  msg.Revision + 1,
+			"edited_at": editedAt,
+		})
+		if r.Error != nil {
+			return r.Error
+		}
+		if r.RowsAffected == 0 {
+			return nil
+		}
+		ok = true
+		return tx.Create(&TMessageRevision{
+			SnowID:   msg.SnowID,
+			Revision: msg.Revision,
+			Content:  msg.Content,
+		}).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	return ok, nil
+}
+
+// ApplyMessageEdit 把编辑后的内容写入snowId对应的版本号更旧的消息记录，
+// 用于persist层补齐编辑时还没有写入db的信箱（如私聊接收方）中的记录，编辑之前的版本已经由EditMessage保存
+func ApplyMessageEdit(snowId, content string, revision int64, editedAt time.Time) error {
+	db := GetDb()
+	r := db.Model(&TMessage{}).Where("snow_id = ? AND revision < ?", snowId, revision).Updates(map[string]interface{}{
+		"content":   content,
+		"revision":  revision,
+		"edited_at": editedAt,
+	})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryReplyPreviews 批量查询被引用消息的预览，私聊消息的两条记录只返回一条
+func QueryReplyPreviews(snowIds []string, previews *[]VReplyPreview) {
+	if len(snowIds) == 0 {
+		return
+	}
+	db := GetDb()
+	r := db.Table("t_message").
+		Select("DISTINCT t_message.snow_id, t_message.from_a AS userid, t_user.username AS from_username, t_message.message_type, t_message.content, t_message.status").
+		Joins("JOIN t_user ON t_user.id = t_message.from_a").
+		Where("t_message.snow_id IN ? AND t_message.delete_at IS NULL", snowIds).
+		Where("t_message.expire_at IS NULL OR t_message.expire_at > ?", time.Now()).
+		Find(previews)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// AddReaction 添加表情回应，已经回应过时created为false
+func AddReaction(reaction *TMessageReaction) (created bool, err error) {
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false, r.Error
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// RemoveReaction 取消表情回应，没有回应过时deleted为false
+func RemoveReaction(snowId string, userid int64, emoji string) (deleted bool, err error) {
+	db := GetDb()
+	r := db.Where("snow_id = ? AND userid = ? AND emoji = ?", snowId, userid, emoji).Delete(&TMessageReaction{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false, r.Error
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// QueryReactions 批量查询消息的表情回应，按回应的先后顺序排列
+func QueryReactions(snowIds []string, reactions *[]TMessageReaction) {
+	if len(snowIds) == 0 {
+		return
+	}
+	db := GetDb()
+	r := db.Where("snow_id IN ?", snowIds).Order("id").Find(reactions)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// SaveMentions 保存群聊消息中的@提及，重复保存时忽略
+func SaveMentions(mentions []TMessageMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(mentions, 100)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryUnresolvedMentions 查询用户在所有群聊中尚未处理的@提及，按时间倒序排列
+func QueryUnresolvedMentions(userid int64, mentions *[]VMention) {
+	db := GetDb()
+	r := db.Table("t_message_mention AS m").
+		Select("m.snow_id, m.group_id, t_group.group_name, m.from_a, t_user.username AS from_username, m.content, m.mention_all, m.create_at").
+		Joins("JOIN t_group ON t_group.id = m.group_id").
+		Joins("JOIN t_user ON t_user.id = m.from_a").
+		Where("m.userid = ? AND m.resolved = ?", userid, false).
+		Where("NOT EXISTS (SELECT 1 FROM t_message WHERE t_message.belong = m.group_id AND t_message.snow_id = m.snow_id AND t_message.expire_at <= ?)", time.Now()).
+		Order("m.id DESC").
+		Find(mentions)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// ResolveMentions 将用户在群聊中的@提及标记为已处理，snowId不为空时只处理该条消息中的@提及
+func ResolveMentions(userid, groupId int64, snowId string) error {
+	db := GetDb()
+	tx := db.Model(&TMessageMention{}).Where("userid = ? AND group_id = ? AND resolved = ?", userid, groupId, false)
+	if snowId != "" {
+		tx = tx.Where("snow_id = ?", snowId)
+	}
+	r := tx.Update("resolved", true)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// ResolveMentionsBySnowId 消息被撤回后其中的@提及不再需要处理
+func ResolveMentionsBySnowId(snowId string) error {
+	db := GetDb()
+	r := db.Model(&TMessageMention{}).Where("snow_id = ? AND resolved = ?", snowId, false).Update("resolved", true)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryGroupRelation 查询用户在群聊中的成员关系，不是群成员时relation.ID为0
+func QueryGroupRelation(userid, groupId int64, relation *TRelation) {
+	db := GetDb()
+	r := db.Where("object_a = ? AND object_b = ? AND type = ?", userid, groupId, "group").First(relation)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// QueryGroupRelations 查询群聊中所有成员的关系
+func QueryGroupRelations(groupId int64, relationList *[]TRelation) {
+	db := GetDb()
+	r := db.Where("object_b = ? AND type = ?", groupId, "group").Order("id").Find(relationList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// UpdateMemberRole 修改成员在群聊中的角色
+func UpdateMemberRole(userid, groupId int64, role int) error {
+	db := GetDb()
+	r := db.Model(&TRelation{}).Where("object_a = ? AND object_b = ? AND type = ?", userid, groupId, "group").Update("role", role)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// UpdateMemberMute 修改成员的禁言截止时间，until为nil时解除禁言
+func UpdateMemberMute(userid, groupId int64, until *time.Time) error {
+	db := GetDb()
+	r := db.Model(&TRelation{}).Where("object_a = ? AND object_b = ? AND type = ?", userid, groupId, "group").Update("mute_until", until)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// DeleteGroupRelation 将成员移出群聊
+func DeleteGroupRelation(userid, groupId int64) error {
+	db := GetDb()
+	r := db.Where("object_a = ? AND object_b = ? AND type = ?", userid, groupId, "group").Delete(&TRelation{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// TransferOwnership 转让群主，原群主成为管理员，新群主必须是群成员
+func TransferOwnership(groupId, owner, newOwner int64) error {
+	db := GetDb()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		r := tx.Model(&TRelation{}).Where("object_a = ? AND object_b = ? AND type = ?", owner, groupId, "group").Update("role", GroupRoleAdmin)
+		if r.Error != nil {
+			return r.Error
+		}
+		r = tx.Model(&TRelation{}).Where("object_a = ? AND object_b = ? AND type = ?", newOwner, groupId, "group").
+			Updates(map[string]interface{}{"role": GroupRoleOwner, "mute_until": nil})
+		if r.Error != nil {
+			return r.Error
+		}
+		return tx.Model(&TGroup{}).Where("id = ?", groupId).Update("userid", newOwner).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+	return err
+}
+
+// DissolveGroup 解散群聊，删除群聊和所有成员关系
+func DissolveGroup(groupId int64) error {
+	db := GetDb()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		r := tx.Where("object_b = ? AND type = ?", groupId, "group").Delete(&TRelation{})
+		if r.Error != nil {
+			return r.Error
+		}
+		return tx.Where("id = ?", groupId).Delete(&TGroup{}).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+	return err
+}
+
+// CreateFriendRequest 创建好友申请
+func CreateFriendRequest(request *TFriendRequest) error {
+	db := GetDb()
+	r := db.Create(request)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryPendingFriendRequest 查询fromA发给toB的尚未处理且没有过期的好友申请，不存在时request.ID为0
+func QueryPendingFriendRequest(fromA, toB int64, request *TFriendRequest) {
+	db := GetDb()
+	r := db.Where("from_a = ? AND to_b = ? AND state = ? AND expire_at > ?", fromA, toB, FriendRequestPending, time.Now()).
+		Order("id DESC").First(request)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+func QueryFriendRequestById(id int64, request *TFriendRequest) {
+	db := GetDb()
+	r := db.Where("id = ?", id).First(request)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// ExpireFriendRequests 将用户收到和发出的已经过期但仍处于待处理状态的好友申请标记为已过期
+func ExpireFriendRequests(userid int64) {
+	db := GetDb()
+	r := db.Model(&TFriendRequest{}).
+		Where("(from_a = ? OR to_b = ?) AND state = ? AND expire_at <= ?", userid, userid, FriendRequestPending, time.Now()).
+		Update("state", FriendRequestExpired)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// RespondFriendRequest 处理待处理的好友申请，同意时在同一个事务中建立好友关系，
+// 申请在此期间已经被处理或者过期时ok为false
+func RespondFriendRequest(request *TFriendRequest, accept bool) (ok bool, err error) {
+	state := FriendRequestRejected
+	if accept {
+		state = FriendRequestAccepted
+	}
+	db := GetDb()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		r := tx.Model(&TFriendRequest{}).
+			Where("id = ? AND state = ? AND expire_at > ?", request.ID, FriendRequestPending, time.Now()).
+			Update("state", state)
+		if r.Error != nil {
+			return r.Error
+		}
+		if r.RowsAffected == 0 || !accept {
+			ok = r.RowsAffected > 0
+			return nil
+		}
+		ok = true
+		var relation TRelation
+		r = tx.Where("((object_a = ? AND object_b = ?) OR (object_a = ? AND object_b = ?)) AND type = ?",
+			request.FromA, request.ToB, request.ToB, request.FromA, "friend").Find(&relation)
+		if r.Error != nil || relation.ID != 0 {
+			return r.Error
+		}
+		return tx.Create(&TRelation{Type: "friend", ObjectA: request.FromA, ObjectB: request.ToB}).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, err
+	}
+	return ok, nil
+}
+
+// QueryFriendRequests 查询用户收到和发出的好友申请，按时间倒序排列
+func QueryFriendRequests(userid int64, requests *[]VFriendRequest) {
+	db := GetDb()
+	r := db.Table("t_friend_request AS fr").
+		Select("fr.id, fr.from_a, fu.username AS from_username, fu.avatar AS from_avatar, "+
+			"fr.to_b, tu.username AS to_username, tu.avatar AS to_avatar, fr.greeting, fr.state, fr.expire_at, fr.create_at").
+		Joins("JOIN t_user AS fu ON fu.id = fr.from_a").
+		Joins("JOIN t_user AS tu ON tu.id = fr.to_b").
+		Where("fr.from_a = ? OR fr.to_b = ?", userid, userid).
+		Order("fr.id DESC").
+		Limit(friendRequestLimit).
+		Find(requests)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// 查询好友申请时最多返回的数目
+const friendRequestLimit = 100
+
+// QueryAllUserId 查询所有用户的id
+func QueryAllUserId() (idList []int64, err error) {
+	db := GetDb()
+	r := db.Model(&TUser{}).Pluck("id", &idList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return nil, r.Error
+	}
+	return idList, nil
+}
+
+// QueryAllGroupId 查询所有群聊的id，包括已经解散的群聊
+func QueryAllGroupId() (idList []int64, err error) {
+	db := GetDb()
+	r := db.Unscoped().Model(&TGroup{}).Pluck("id", &idList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return nil, r.Error
+	}
+	return idList, nil
+}
+
+// DeleteFriendRelation 删除双方之间的好友关系，好友关系不存在时ok为false
+func DeleteFriendRelation(userid, friendId int64) (ok bool, err error) {
+	db := GetDb()
+	r := db.Where("((object_a = ? AND object_b = ?) OR (object_a = ? AND object_b = ?)) AND type = ?",
+		userid, friendId, friendId, userid, "friend").Delete(&TRelation{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false, r.Error
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// CreateBlockRelation 将blockId加入userid的黑名单，已经拉黑时不重复创建。
+// 软删除的关系记录无法使用唯一索引，因此锁定拉黑方的用户记录，使同一用户的并发拉黑请求串行地检查和创建
+func CreateBlockRelation(userid, blockId int64) error {
+	db := GetDb()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user TUser
+		r := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userid).First(&user)
+		if r.Error != nil {
+			return r.Error
+		}
+		var count int64
+		r = tx.Model(&TRelation{}).Where("object_a = ? AND object_b = ? AND type = ?", userid, blockId, "block").Count(&count)
+		if r.Error != nil || count > 0 {
+			return r.Error
+		}
+		return tx.Create(&TRelation{Type: "block", ObjectA: userid, ObjectB: blockId}).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+	return err
+}
+
+// DeleteBlockRelation 将blockId移出userid的黑名单
+func DeleteBlockRelation(userid, blockId int64) error {
+	db := GetDb()
+	r := db.Where("object_a = ? AND object_b = ? AND type = ?", userid, blockId, "block").Delete(&TRelation{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// IsBlocked 双方中任意一方拉黑了另一方时返回true
+func IsBlocked(objectA, objectB int64) bool {
+	db := GetDb()
+	var count int64
+	r := db.Model(&TRelation{}).Where("((object_a = ? AND object_b = ?) OR (object_a = ? AND object_b = ?)) AND type = ?",
+		objectA, objectB, objectB, objectA, "block").Count(&count)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false
+	}
+	return count > 0
+}
+
+// QueryUserAllBlockId 查询与用户存在拉黑关系的所有用户id，包括用户拉黑的和拉黑了用户的
+func QueryUserAllBlockId(userid int64) (blockIdList []int64) {
+	db := GetDb()
+	var relationList []TRelation
+	r := db.Where("(object_a = ? OR object_b = ?) AND type = ?", userid, userid, "block").Find(&relationList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
 		return
 	}
+	blockIdList = make([]int64, 0, len(relationList))
+	for _, val := range relationList {
+		if val.ObjectA == userid {
+			blockIdList = append(blockIdList, val.ObjectB)
+		} else {
+			blockIdList = append(blockIdList, val.ObjectA)
+		}
+	}
+	return blockIdList
+}
+
+// QueryUserBlockerId 查询拉黑了该用户的所有用户id
+func QueryUserBlockerId(userid int64) (blockerIdList []int64) {
 	db := GetDb()
-	r := db.CreateInBatches(msg, 100) // 每次更新写入100条数据
+	r := db.Model(&TRelation{}).Where("object_b = ? AND type = ?", userid, "block").Pluck("object_a", &blockerIdList)
 	if r.Error != nil {
 		zlog.Error(r.Error.Error())
+	}
+	return
+}
+
+func UpdateGroupJoinPolicy(groupId int64, policy int) error {
+	db := GetDb()
+	r := db.Model(&TGroup{}).Where("id = ?", groupId).Update("join_policy", policy)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+func CreateGroupInvite(invite *TGroupInvite) error {
+	db := GetDb()
+	r := db.Create(invite)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryGroupInviteByToken 查询没有过期的邀请链接，不存在或者已经过期时invite.ID为0
+func QueryGroupInviteByToken(token string, invite *TGroupInvite) {
+	db := GetDb()
+	r := db.Where("token = ? AND expire_at > ?", token, time.Now()).First(invite)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+func CreateGroupJoinRequest(request *TGroupJoinRequest) error {
+	db := GetDb()
+	r := db.Create(request)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryPendingGroupJoinRequest 查询用户对该群聊尚未处理的入群申请，不存在时request.ID为0
+func QueryPendingGroupJoinRequest(userid, groupId int64, request *TGroupJoinRequest) {
+	db := GetDb()
+	r := db.Where("userid = ? AND group_id = ? AND state = ?", userid, groupId, GroupJoinPending).First(request)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+func QueryGroupJoinRequestById(id int64, request *TGroupJoinRequest) {
+	db := GetDb()
+	r := db.Where("id = ?", id).First(request)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// QueryPendingGroupJoinRequests 查询群聊所有待处理的入群申请，按申请时间先后排列
+func QueryPendingGroupJoinRequests(groupId int64, requests *[]VGroupJoinRequest) {
+	db := GetDb()
+	r := db.Table("t_group_join_request AS jr").
+		Select("jr.id, jr.group_id, jr.userid, t_user.username, t_user.avatar, jr.reason, jr.state, jr.create_at").
+		Joins("JOIN t_user ON t_user.id = jr.userid").
+		Where("jr.group_id = ? AND jr.state = ?", groupId, GroupJoinPending).
+		Order("jr.id ASC").
+		Find(requests)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// RespondGroupJoinRequest 处理待处理的入群申请，申请在此期间已经被其他管理员处理时ok为false
+func RespondGroupJoinRequest(request *TGroupJoinRequest, handler int64, approve bool) (ok bool, err error) {
+	state := GroupJoinRejected
+	if approve {
+		state = GroupJoinApproved
+	}
+	db := GetDb()
+	r := db.Model(&TGroupJoinRequest{}).Where("id = ? AND state = ?", request.ID, GroupJoinPending).
+		Updates(map[string]interface{}{"state": state, "handler": handler})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false, r.Error
+	}
+	return r.RowsAffected > 0, nil
+}
+
+// QueryUsersByIds 批量查询用户信息，避免逐个查询
+func QueryUsersByIds(idList []int64, userList *[]TUser) {
+	if len(idList) == 0 {
 		return
 	}
+	db := GetDb()
+	r := db.Where("id IN ?", idList).Find(userList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// QueryGroupsByIds 批量查询群聊信息，避免逐个查询
+func QueryGroupsByIds(idList []int64, groupList *[]TGroup) {
+	if len(idList) == 0 {
+		return
+	}
+	db := GetDb()
+	r := db.Where("id IN ?", idList).Find(groupList)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+	}
+}
+
+// conversationNewer 本次写入的消息比会话中已有的最后一条消息更新，snowId是varchar类型，与messageOrder一样先比较长度
+const conversationNewer = "(LENGTH(VALUES(last_snow_id)) > LENGTH(last_snow_id) OR " +
+	"(LENGTH(VALUES(last_snow_id)) = LENGTH(last_snow_id) AND VALUES(last_snow_id) > last_snow_id))"
+
+// UpsertConversations 更新会话的最后一条消息，会话不存在时创建，并发发送时只保留snowId最大的消息，
+// last_snow_id必须最后更新，前面的字段依赖它的旧值判断是否更新
+func UpsertConversations(list []TConversation) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var assignments []clause.Assignment
+	for _, column := range []string{"last_from_id", "last_content", "last_message_type", "last_recalled", "last_at", "last_snow_id"} {
+		assignments = append(assignments, clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("IF(%s, VALUES(%s), %s)", conversationNewer, column, column)),
+		})
+	}
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoUpdates: assignments}).CreateInBatches(list, 100)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// CreateConversations 建立好友关系或者加入群聊时创建会话，已经存在的会话直接忽略
+func CreateConversations(list []TConversation) error {
+	if len(list) == 0 {
+		return nil
+	}
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoNothing: true}).Create(list)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// DeleteConversation 删除用户的会话，用于删除好友、退出群聊或者被移出群聊
+func DeleteConversation(userid int64, _type string, objectId int64) error {
+	db := GetDb()
+	r := db.Where("userid = ? AND type = ? AND object_id = ?", userid, _type, objectId).Delete(&TConversation{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// DeleteGroupConversations 群聊解散后删除所有成员的会话
+func DeleteGroupConversations(groupId int64) error {
+	db := GetDb()
+	r := db.Where("type = 'group' AND object_id = ?", groupId).Delete(&TConversation{})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// UpdateConversationPreview 最后一条消息被撤回或者编辑后更新会话中的预览
+func UpdateConversationPreview(snowId, content string, recalled bool) error {
+	db := GetDb()
+	r := db.Model(&TConversation{}).Where("last_snow_id = ?", snowId).
+		Updates(map[string]interface{}{"last_content": content, "last_recalled": recalled})
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// SetConversationMute 设置会话的消息免打扰，会话不存在时创建
+func SetConversationMute(conversation *TConversation) error {
+	db := GetDb()
+	r := db.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"muted"})}).Create(conversation)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryConversations 按最后一条消息的时间倒序分页查询用户的会话，(lastAt, id)为上一页最后一个会话的位置，id为0时查询第一页
+func QueryConversations(userid int64, lastAt time.Time, id int64, limit int, list *[]VConversation) error {
+	db := GetDb()
+	query := db.Table("t_conversation AS c").
+		Select("c.id, c.type, c.object_id, "+
+			"IFNULL(IF(c.type = 'group', g.group_name, u.username), '') AS name, "+
+			"IFNULL(IF(c.type = 'group', g.avatar, u.avatar), '') AS avatar, "+
+			"c.last_snow_id, c.last_from_id, IFNULL(f.username, '') AS last_from_name, "+
+			"c.last_content, c.last_message_type, c.last_recalled, c.last_at, c.muted, "+
+			"IFNULL(IF(c.type = 'group', g.message_ttl, (SELECT r.message_ttl FROM t_relation AS r WHERE r.type = 'friend' AND r.delete_at IS NULL AND "+
+			"((r.object_a = c.userid AND r.object_b = c.object_id) OR (r.object_a = c.object_id AND r.object_b = c.userid)) LIMIT 1)), 0) AS message_ttl").
+		Joins("LEFT JOIN t_user AS u ON c.type = 'friend' AND u.id = c.object_id").
+		Joins("LEFT JOIN t_group AS g ON c.type = 'group' AND g.id = c.object_id").
+		Joins("LEFT JOIN t_user AS f ON f.id = c.last_from_id").
+		Where("c.userid = ?", userid)
+	if id != 0 {
+		query = query.Where("c.last_at < ? OR (c.last_at = ? AND c.id < ?)", lastAt, lastAt, id)
+	}
+	r := query.Order("c.last_at DESC, c.id DESC").Limit(limit).Find(list)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// QueryFriendMessageTTL 查询私聊会话的消息存活时间（秒），双方的好友关系记录中保持一致，不是好友时返回0
+func QueryFriendMessageTTL(userid, friendId int64) int64 {
+	db := GetDb()
+	var relation TRelation
+	r := db.Where("((object_a = ? AND object_b = ?) OR (object_a = ? AND object_b = ?)) AND type = ?",
+		userid, friendId, friendId, userid, "friend").First(&relation)
+	if r.Error != nil && r.Error != gorm.ErrRecordNotFound {
+		zlog.Error(r.Error.Error())
+	}
+	return relation.MessageTTL
+}
+
+// UpdateFriendMessageTTL 设置私聊会话的消息存活时间，同时更新双方的好友关系记录
+func UpdateFriendMessageTTL(userid, friendId, ttl int64) error {
+	db := GetDb()
+	r := db.Model(&TRelation{}).Where("((object_a = ? AND object_b = ?) OR (object_a = ? AND object_b = ?)) AND type = ?",
+		userid, friendId, friendId, userid, "friend").Update("message_ttl", ttl)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// UpdateGroupMessageTTL 设置群聊的消息存活时间
+func UpdateGroupMessageTTL(groupId, ttl int64) error {
+	db := GetDb()
+	r := db.Model(&TGroup{}).Where("id = ?", groupId).Update("message_ttl", ttl)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return r.Error
+	}
+	return nil
+}
+
+// DeleteExpiredMessages 物理删除在now之前过期的消息，每次最多删除limit条记录，同时删除消息的编辑记录、
+// 表情回应和@提及，并清空以这些消息为最后一条消息的会话预览，返回被删除的消息的snowId
+func DeleteExpiredMessages(now time.Time, limit int) (snowIds []string, err error) {
+	db := GetDb()
+	var list []TMessage
+	r := db.Unscoped().Select("id, snow_id").Where("expire_at <= ?", now).Order("expire_at").Limit(limit).Find(&list)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return nil, r.Error
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, 0, len(list))
+	seen := make(map[string]struct{}, len(list))
+	for _, msg := range list {
+		ids = append(ids, msg.ID)
+		if _, ok := seen[msg.SnowID]; !ok {
+			seen[msg.SnowID] = struct{}{}
+			snowIds = append(snowIds, msg.SnowID)
+		}
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 私聊消息在双方信箱中的两条记录过期时间相同，按snowId删除关联数据不会影响另一条还没有过期的记录
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&TMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("snow_id IN ?", snowIds).Delete(&TMessageRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("snow_id IN ?", snowIds).Delete(&TMessageReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("snow_id IN ?", snowIds).Delete(&TMessageMention{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&TConversation{}).Where("last_snow_id IN ?", snowIds).
+			Updates(map[string]interface{}{"last_content": "", "last_message_type": "", "last_recalled": false}).Error
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, err
+	}
+	return snowIds, nil
 }
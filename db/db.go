@@ -28,21 +28,28 @@ var (
 	db            *gorm.DB
 	vGroupMessage = `
     create view v_group_message as
-    select mg.*, t_user.username as 'from_username', t_user.avatar
+    select mg.*, ifnull(t_user.username, '') as 'from_username', ifnull(t_user.avatar, '') as 'avatar'
     from (select m.*, t_group.group_name
           from (select id,
                        from_a as 'userid',
                        to_b   as 'group_id',
-                       content,
-                       message_type,
+                       if(status = 2, '该消息已被撤回', content) as 'content',
+                       if(status = 2, 'text', message_type)      as 'message_type',
+                       status,
+                       revision,
+                       edited_at,
+                       reply_to,
+                       mentions,
+                       mention_all,
                        create_at,
                        snow_id,
+                       seq,
                        belong
                 from t_message
                 where type = 'group'
                 order by snow_id DESC) m
                    join t_group on m.group_id = t_group.id) mg
-             join t_user on t_user.id = mg.userid;`
+             left join t_user on t_user.id = mg.userid;`
 	vFriendMessage = `
     create view v_friend_message as
     select mf.*, t_user.username as 'from_username', t_user.avatar
@@ -50,16 +57,49 @@ var (
           from (select id,
                        from_a as 'userid',
                        to_b   as 'friend_id',
-                       content,
-                       message_type,
+                       if(status = 2, '该消息已被撤回', content) as 'content',
+                       if(status = 2, 'text', message_type)      as 'message_type',
+                       status,
+                       revision,
+                       edited_at,
+                       reply_to,
                        create_at,
                        snow_id,
+                       seq,
                        belong
                 from t_message
                 where type = 'friend'
                 order by snow_id DESC) m
                    join t_user as friend on m.friend_id = friend.id) mf
              join t_user on t_user.id = mf.userid;`
+	// 会话列表上线之前已有的会话，根据已有的消息和好友、群聊关系生成，没有消息的会话以建立关系的时间排序
+	conversationInit = []string{`
+    insert ignore into t_conversation (userid, type, object_id, last_snow_id, last_at, update_at)
+    select belong, 'friend', if(from_a = belong, to_b, from_a), max(snow_id), max(create_at), now()
+    from t_message
+    where type = 'friend' and delete_at is null
+    group by belong, if(from_a = belong, to_b, from_a);`, `
+    insert ignore into t_conversation (userid, type, object_id, last_snow_id, last_at, update_at)
+    select r.object_a, 'group', r.object_b, max(m.snow_id), max(m.create_at), now()
+    from t_relation r
+             join t_message m on m.belong = r.object_b and m.type = 'group' and m.delete_at is null
+    where r.type = 'group' and r.delete_at is null
+    group by r.object_a, r.object_b;`, `
+    insert ignore into t_conversation (userid, type, object_id, last_at, update_at)
+    select object_a, type, object_b, create_at, now()
+    from t_relation
+    where type in ('friend', 'group') and delete_at is null;`, `
+    insert ignore into t_conversation (userid, type, object_id, last_at, update_at)
+    select object_b, type, object_a, create_at, now()
+    from t_relation
+    where type = 'friend' and delete_at is null;`, `
+    update t_conversation c join t_message m
+        on m.snow_id = c.last_snow_id and m.belong = if(c.type = 'group', c.object_id, c.userid)
+    set c.last_from_id      = m.from_a,
+        c.last_content      = left(m.content, 32),
+        c.last_message_type = m.message_type,
+        c.last_recalled     = (m.status = 2)
+    where c.last_snow_id != '';`}
 )
 
 // InitDb 初始化客户端连接配置
@@ -111,11 +151,55 @@ func InitDb() {
 
 func modelsInit() {
 	zlog.Info("models initializing...")
-	e1 := db.AutoMigrate(&TUser{}, &TGroup{}, &TMessage{}, &TRelation{})
+	initConversation := !db.Migrator().HasTable(&TConversation{})
+	// 群聊名称改为唯一索引之前，重名的群聊中除了最早创建的之外，名称都加上「#群聊id」后缀
+	if db.Migrator().HasTable(&TGroup{}) && !db.Migrator().HasIndex(&TGroup{}, "idx_group_name") {
+		r := db.Exec("update t_group g join (select group_name, min(id) as keep_id from t_group group by group_name having count(*) > 1) d " +
+			"on g.group_name = d.group_name and g.id <> d.keep_id " +
+			"set g.group_name = concat(left(g.group_name, 31 - char_length(g.id)), '#', g.id)")
+		if r.Error != nil {
+			panic(errors.Wrap(r.Error, "处理重名的群聊失败"))
+		}
+	}
+	e1 := db.AutoMigrate(&TUser{}, &TGroup{}, &TMessage{}, &TRelation{}, &TMessageRevision{}, &TMessageReaction{}, &TMessageMention{}, &TFriendRequest{},
+		&TGroupInvite{}, &TGroupJoinRequest{}, &TConversation{}, &TConversationSeq{})
 	if e1 != nil {
 		err := errors.Wrap(e1, "初始化表失败")
 		panic(err)
 	}
+	// 只在第一次创建t_conversation时生成已有的会话
+	if initConversation {
+		for _, sql := range conversationInit {
+			if r := db.Exec(sql); r.Error != nil {
+				panic(errors.Wrap(r.Error, "初始化会话列表失败"))
+			}
+		}
+	}
+	// 私聊双方信箱中的同一条消息共用snowId，唯一索引由snow_id改为(belong, snow_id)，
+	// 撤回、编辑、引用预览等只按snow_id查询的场景使用非唯一的idx_snow_id
+	if db.Migrator().HasIndex(&TMessage{}, "idx_t_message_snow_id") {
+		if err := db.Migrator().DropIndex(&TMessage{}, "idx_t_message_snow_id"); err != nil {
+			panic(errors.Wrap(err, "删除t_message旧的snow_id唯一索引失败"))
+		}
+	}
+	// 群聊名称的普通索引由唯一索引idx_group_name代替
+	if db.Migrator().HasIndex(&TGroup{}, "idx_t_group_group_name") {
+		if err := db.Migrator().DropIndex(&TGroup{}, "idx_t_group_group_name"); err != nil {
+			panic(errors.Wrap(err, "删除t_group旧的group_name索引失败"))
+		}
+	}
+	// 历史消息改为按seq分页，(belong, type, snow_id)索引不再使用
+	if db.Migrator().HasIndex(&TMessage{}, "idx_belong_type_snow_id") {
+		if err := db.Migrator().DropIndex(&TMessage{}, "idx_belong_type_snow_id"); err != nil {
+			panic(errors.Wrap(err, "删除t_message旧的(belong, type, snow_id)索引失败"))
+		}
+	}
+	// 增加群成员角色之前创建的群聊，以群聊创建者作为群主
+	e4 := db.Exec("update t_relation join t_group on t_relation.object_b = t_group.id and t_relation.object_a = t_group.userid "+
+		"set t_relation.role = ? where t_relation.type = 'group' and t_relation.role = ?", GroupRoleOwner, GroupRoleMember)
+	if e4.Error != nil {
+		panic(errors.Wrap(e4.Error, "初始化群主角色失败"))
+	}
 	e2 := db.Exec("drop view v_group_message;")
 	if e2.Error != nil && !strings.Contains(e2.Error.Error(), "Unknown table") {
 		panic(e2)
@@ -2,6 +2,8 @@ package db
 
 import (
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,65 +27,327 @@ type TUser struct {
 }
 
 type TGroup struct {
-	ID        int64          `json:"id,omitempty" gorm:"primaryKey"`
-	Userid    int64          `json:"userid,omitempty" gorm:"type:bigint;not null;comment:'群聊创建者id'"`
-	GroupName string         `json:"groupName,omitempty" gorm:"type:varchar(32);index;comment:'群聊名称'"`
-	Notice    string         `json:"notice,omitempty" gorm:"type:varchar(1024);comment:'群聊公告'"`
-	CreateAt  time.Time      `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
-	UpdateAt  time.Time      `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
-	DeleteAt  gorm.DeletedAt // gorm 软删除
+	ID         int64          `json:"id,omitempty" gorm:"primaryKey"`
+	Userid     int64          `json:"userid,omitempty" gorm:"type:bigint;not null;comment:'群聊创建者id'"`
+	GroupName  string         `json:"groupName,omitempty" gorm:"type:varchar(32);uniqueIndex:idx_group_name;comment:'群聊名称，已经解散的群聊也会占用名称'"`
+	Notice     string         `json:"notice,omitempty" gorm:"type:varchar(1024);comment:'群聊公告'"`
+	Avatar     string         `json:"avatar,omitempty" gorm:"type:varchar(256);not null;default:'';comment:'群头像'"`
+	JoinPolicy int            `json:"joinPolicy,omitempty" gorm:"type:tinyint;default:1;not null;comment:'加入方式 1自由加入 2需要管理员审批 3仅限邀请'"`
+	MessageTTL int64          `json:"messageTtl,omitempty" gorm:"type:bigint;not null;default:0;comment:'群聊消息的存活时间，单位秒，0表示不过期'"`
+	CreateAt   time.Time      `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+	UpdateAt   time.Time      `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+	DeleteAt   gorm.DeletedAt // gorm 软删除
 }
 
 type TRelation struct {
-	ID       int64          `json:"id,omitempty" gorm:"primaryKey"`
-	Type     string         `json:"type,omitempty" gorm:"type:varchar(16);not null;comment:'关系类型，friend、group'"`
-	ObjectA  int64          `json:"objectA,omitempty" gorm:"type:bigint;not null;comment:'关系对象A，用户id'"`
-	ObjectB  int64          `json:"objectB,omitempty" gorm:"type:bigint;not null;comment:'关系对象B，用户id或群聊id'"` //如果type=group，那么此项必须为群聊id
-	CreateAt time.Time      `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
-	UpdateAt time.Time      `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
-	DeleteAt gorm.DeletedAt // gorm 软删除
+	ID         int64          `json:"id,omitempty" gorm:"primaryKey"`
+	Type       string         `json:"type,omitempty" gorm:"type:varchar(16);not null;comment:'关系类型，friend、group、block，block表示A拉黑了B'"`
+	ObjectA    int64          `json:"objectA,omitempty" gorm:"type:bigint;not null;comment:'关系对象A，用户id'"`
+	ObjectB    int64          `json:"objectB,omitempty" gorm:"type:bigint;not null;comment:'关系对象B，用户id或群聊id'"` //如果type=group，那么此项必须为群聊id
+	Role       int            `json:"role,omitempty" gorm:"type:tinyint;default:1;not null;comment:'type=group时成员在群聊中的角色 1成员 2管理员 3群主'"`
+	MuteUntil  *time.Time     `json:"muteUntil,omitempty" gorm:"type:datetime;comment:'type=group时成员的禁言截止时间'"`
+	MessageTTL int64          `json:"messageTtl,omitempty" gorm:"type:bigint;not null;default:0;comment:'type=friend时私聊消息的存活时间，单位秒，0表示不过期，双方的记录保持一致'"`
+	CreateAt   time.Time      `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+	UpdateAt   time.Time      `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+	DeleteAt   gorm.DeletedAt // gorm 软删除
 }
 
+// 群成员的角色，数值越大权限越高，只能管理角色比自己低的成员
+const (
+	GroupRoleMember = 1
+	GroupRoleAdmin  = 2
+	GroupRoleOwner  = 3
+)
+
 type TMessage struct {
 	ID          int64          `json:"id,omitempty" gorm:"primaryKey"`
-	Belong      int64          `json:"belong" gorm:"type:bigint;not null;comment:'信箱所有者id「userid or groupId」'"`
-	SnowID      string         `json:"snowId,omitempty" gorm:"type:varchar(512);uniqueIndex;comment:'消息雪花id'"`
-	Type        string         `json:"type,omitempty" gorm:"type:varchar(16);not null;comment:'消息类型，friend、group'"`
-	Content     string         `json:"content,omitempty" gorm:"type:varchar(1024);not null;comment:'消息内容'"`
+	Belong      int64          `json:"belong" gorm:"type:bigint;not null;uniqueIndex:idx_belong_snow_id;index:idx_belong_type_seq,priority:1;comment:'信箱所有者id「userid or groupId」'"`
+	SnowID      string         `json:"snowId,omitempty" gorm:"type:varchar(512);uniqueIndex:idx_belong_snow_id;index:idx_snow_id;comment:'消息雪花id，私聊双方信箱中的同一条消息snowId相同'"`
+	Seq         int64          `json:"seq" gorm:"type:bigint;not null;default:0;index;index:idx_belong_type_seq,priority:3;comment:'会话内单调递增的消息序号'"`
+	Watermark   int64          `json:"watermark" gorm:"type:bigint;not null;default:0;comment:'客户端生成的消息水印，与发送方共同构成发送的幂等键'"`
+	Status      int            `json:"status,omitempty" gorm:"type:tinyint;default:1;not null;comment:'消息状态 1正常 2已撤回'"`
+	Revision    int64          `json:"revision" gorm:"type:bigint;default:0;not null;comment:'消息版本号，每编辑一次加1'"`
+	EditedAt    *time.Time     `json:"editedAt,omitempty" gorm:"type:datetime;comment:'最后一次编辑时间'"`
+	ReplyTo     string         `json:"replyTo,omitempty" gorm:"type:varchar(512);not null;default:'';comment:'引用回复的消息snowId'"`
+	Mentions    string         `json:"mentions,omitempty" gorm:"type:varchar(1024);not null;default:'';comment:'群聊消息中@提及的用户id，以逗号分隔'"`
+	MentionAll  bool           `json:"mentionAll,omitempty" gorm:"not null;default:false;comment:'是否@所有人'"`
+	Type        string         `json:"type,omitempty" gorm:"type:varchar(16);not null;index:idx_belong_type_seq,priority:2;comment:'消息类型，friend、group'"`
+	Content     string         `json:"content,omitempty" gorm:"type:varchar(1024);not null;index:idx_content,class:FULLTEXT,option:WITH PARSER ngram;comment:'消息内容'"`
 	FromA       int64          `json:"fromA,omitempty" gorm:"type:bigint;not null;comment:'消息发送方，用户id'"`
 	ToB         int64          `json:"toB,omitempty" gorm:"type:bigint;not null;comment:'消息接收方，用户id或群聊id'"` //如果type=group，那么此项必须为群聊id
 	MessageType string         `json:"messageType,omitempty" gorm:"type:varchar(16);not null;comment:'消息类型，图片或者文字'"`
+	ExpireAt    *time.Time     `json:"expireAt,omitempty" gorm:"type:datetime;index;comment:'消息的过期时间，为空表示不过期'"`
 	CreateAt    time.Time      `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
 	UpdateAt    time.Time      `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
 	DeleteAt    gorm.DeletedAt // gorm 软删除
 }
 
-// db views model
+// TMessageRevision 消息被编辑之前的版本
+type TMessageRevision struct {
+	ID       int64     `json:"id,omitempty" gorm:"primaryKey"`
+	SnowID   string    `json:"snowId,omitempty" gorm:"type:varchar(512);not null;uniqueIndex:idx_snow_id_revision;comment:'消息雪花id'"`
+	Revision int64     `json:"revision" gorm:"type:bigint;not null;uniqueIndex:idx_snow_id_revision;comment:'该版本的版本号'"`
+	Content  string    `json:"content,omitempty" gorm:"type:varchar(1024);not null;comment:'该版本的消息内容'"`
+	CreateAt time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'该版本被替换的时间'"`
+}
 
-type VGroupMessage struct {
-	ID           int64     `json:"id"`
-	Belong       int64     `json:"belong"`
-	Userid       int64     `json:"userid"`
+// TMessageReaction 消息的表情回应，同一个用户对同一条消息的同一个表情只能回应一次
+type TMessageReaction struct {
+	ID       int64     `json:"id,omitempty" gorm:"primaryKey"`
+	SnowID   string    `json:"snowId,omitempty" gorm:"type:varchar(512);not null;uniqueIndex:idx_snow_id_userid_emoji;comment:'消息雪花id'"`
+	Userid   int64     `json:"userid,omitempty" gorm:"type:bigint;not null;uniqueIndex:idx_snow_id_userid_emoji;comment:'回应方id'"`
+	Emoji    string    `json:"emoji,omitempty" gorm:"type:varchar(32);not null;uniqueIndex:idx_snow_id_userid_emoji;comment:'表情'"`
+	CreateAt time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+}
+
+// TMessageMention 群聊消息对成员的@提及，@所有人时为群聊中除发送方以外的每个成员各保存一条
+type TMessageMention struct {
+	ID         int64     `json:"id,omitempty" gorm:"primaryKey"`
+	SnowID     string    `json:"snowId,omitempty" gorm:"type:varchar(512);not null;uniqueIndex:idx_snow_id_userid;comment:'消息雪花id'"`
+	Userid     int64     `json:"userid,omitempty" gorm:"type:bigint;not null;uniqueIndex:idx_snow_id_userid;index:idx_userid_resolved;comment:'被提及方id'"`
+	GroupId    int64     `json:"groupId,omitempty" gorm:"type:bigint;not null;comment:'群聊id'"`
+	FromA      int64     `json:"fromA,omitempty" gorm:"type:bigint;not null;comment:'提及方id'"`
+	Content    string    `json:"content,omitempty" gorm:"type:varchar(128);not null;comment:'消息内容的预览'"`
+	MentionAll bool      `json:"mentionAll,omitempty" gorm:"not null;default:false;comment:'是否由@所有人产生'"`
+	Resolved   bool      `json:"resolved,omitempty" gorm:"not null;default:false;index:idx_userid_resolved;comment:'被提及方是否已经处理'"`
+	CreateAt   time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+}
+
+// TFriendRequest 好友申请，只有处于待处理状态且没有过期的申请可以被同意或者拒绝
+type TFriendRequest struct {
+	ID       int64     `json:"id,omitempty" gorm:"primaryKey"`
+	FromA    int64     `json:"fromA,omitempty" gorm:"type:bigint;not null;index:idx_from_a_to_b;comment:'申请方id'"`
+	ToB      int64     `json:"toB,omitempty" gorm:"type:bigint;not null;index:idx_from_a_to_b;index:idx_to_b_state;comment:'接收方id'"`
+	Greeting string    `json:"greeting,omitempty" gorm:"type:varchar(128);not null;default:'';comment:'招呼语'"`
+	State    int       `json:"state,omitempty" gorm:"type:tinyint;default:1;not null;index:idx_to_b_state;comment:'申请状态 1待处理 2已同意 3已拒绝 4已过期'"`
+	ExpireAt time.Time `json:"expireAt,omitempty" gorm:"type:datetime;not null;comment:'过期时间'"`
+	CreateAt time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+	UpdateAt time.Time `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+}
+
+// 群聊的加入方式，持有有效邀请链接的用户不受加入方式限制
+const (
+	GroupJoinOpen     = 1
+	GroupJoinApproval = 2
+	GroupJoinInvite   = 3
+)
+
+// TGroupInvite 群聊邀请链接，由管理员创建，在过期之前可以被多次使用
+type TGroupInvite struct {
+	ID       int64     `json:"id,omitempty" gorm:"primaryKey"`
+	GroupId  int64     `json:"groupId,omitempty" gorm:"type:bigint;not null;index;comment:'群聊id'"`
+	Token    string    `json:"token,omitempty" gorm:"type:varchar(64);not null;uniqueIndex;comment:'邀请凭证'"`
+	Creator  int64     `json:"creator,omitempty" gorm:"type:bigint;not null;comment:'创建邀请链接的管理员id'"`
+	ExpireAt time.Time `json:"expireAt,omitempty" gorm:"type:datetime;not null;comment:'过期时间'"`
+	CreateAt time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+}
+
+// TGroupJoinRequest 申请加入需要审批的群聊，由群主或者管理员处理
+type TGroupJoinRequest struct {
+	ID       int64     `json:"id,omitempty" gorm:"primaryKey"`
+	GroupId  int64     `json:"groupId,omitempty" gorm:"type:bigint;not null;index:idx_group_id_state;comment:'群聊id'"`
+	Userid   int64     `json:"userid,omitempty" gorm:"type:bigint;not null;index;comment:'申请方id'"`
+	Reason   string    `json:"reason,omitempty" gorm:"type:varchar(128);not null;default:'';comment:'申请理由'"`
+	State    int       `json:"state,omitempty" gorm:"type:tinyint;default:1;not null;index:idx_group_id_state;comment:'申请状态 1待处理 2已同意 3已拒绝'"`
+	Handler  int64     `json:"handler,omitempty" gorm:"type:bigint;not null;default:0;comment:'处理申请的管理员id'"`
+	CreateAt time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+	UpdateAt time.Time `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+}
+
+// TConversation 用户的会话列表，每个用户在每个私聊或者群聊中有一条记录，发送消息时更新最后一条消息，
+// 会话列表按最后一条消息的时间倒序分页，不需要逐个会话查询消息
+type TConversation struct {
+	ID              int64     `json:"id,omitempty" gorm:"primaryKey"`
+	Userid          int64     `json:"userid,omitempty" gorm:"type:bigint;not null;uniqueIndex:idx_userid_type_object_id;index:idx_userid_last_at;comment:'会话所有者id'"`
+	Type            string    `json:"type,omitempty" gorm:"type:varchar(16);not null;uniqueIndex:idx_userid_type_object_id;comment:'会话类型，friend、group'"`
+	ObjectId        int64     `json:"objectId,omitempty" gorm:"type:bigint;not null;uniqueIndex:idx_userid_type_object_id;comment:'私聊为好友id，群聊为群聊id'"`
+	LastSnowID      string    `json:"lastSnowId,omitempty" gorm:"type:varchar(512);not null;default:'';index;comment:'最后一条消息的snowId'"`
+	LastFromId      int64     `json:"lastFromId,omitempty" gorm:"type:bigint;not null;default:0;comment:'最后一条消息的发送方id'"`
+	LastContent     string    `json:"lastContent,omitempty" gorm:"type:varchar(128);not null;default:'';comment:'最后一条消息内容的预览'"`
+	LastMessageType string    `json:"lastMessageType,omitempty" gorm:"type:varchar(16);not null;default:'';comment:'最后一条消息的类型'"`
+	LastRecalled    bool      `json:"lastRecalled,omitempty" gorm:"not null;default:false;comment:'最后一条消息是否已撤回'"`
+	LastAt          time.Time `json:"lastAt,omitempty" gorm:"type:datetime;not null;index:idx_userid_last_at;comment:'最后一条消息的时间，没有消息时为会话的创建时间'"`
+	Muted           bool      `json:"muted,omitempty" gorm:"not null;default:false;comment:'是否开启消息免打扰'"`
+	CreateAt        time.Time `json:"createAt,omitempty" gorm:"type:datetime;default:current_timestamp;not null;comment:'创建时间'"`
+	UpdateAt        time.Time `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+}
+
+// TConversationSeq 会话已经分配的最大消息序号，分配seq时同步写入，redis中的会话序号丢失后以此为起点，
+// 不依赖异步持久化的t_message
+type TConversationSeq struct {
+	ID             int64     `json:"id,omitempty" gorm:"primaryKey"`
+	ConversationId string    `json:"conversationId,omitempty" gorm:"type:varchar(64);not null;uniqueIndex;comment:'会话id'"`
+	Seq            int64     `json:"seq" gorm:"type:bigint;not null;default:0;comment:'已经分配的最大消息序号'"`
+	UpdateAt       time.Time `json:"updateAt,omitempty" gorm:"type:datetime;autoUpdateTime;not null;comment:'修改时间'"`
+}
+
+// VConversation 会话以及会话对象（好友或者群聊）和最后一条消息发送方的展示信息
+type VConversation struct {
+	ID              int64     `json:"id"`
+	Type            string    `json:"type"`
+	ObjectId        int64     `json:"objectId"`
+	Name            string    `json:"name"`
+	Avatar          string    `json:"avatar"`
+	LastSnowId      string    `json:"lastSnowId"`
+	LastFromId      int64     `json:"lastFromId"`
+	LastFromName    string    `json:"lastFromName"`
+	LastContent     string    `json:"lastContent"`
+	LastMessageType string    `json:"lastMessageType"`
+	LastRecalled    bool      `json:"lastRecalled"`
+	LastAt          time.Time `json:"lastAt"`
+	Muted           bool      `json:"muted"`
+	MessageTTL      int64     `json:"messageTtl"`
+}
+
+const (
+	GroupJoinPending  = 1
+	GroupJoinApproved = 2
+	GroupJoinRejected = 3
+)
+
+// VGroupJoinRequest 入群申请以及申请方的用户信息
+type VGroupJoinRequest struct {
+	ID       int64     `json:"id"`
+	GroupId  int64     `json:"groupId"`
+	Userid   int64     `json:"userid"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	Reason   string    `json:"reason"`
+	State    int       `json:"state"`
+	CreateAt time.Time `json:"createAt"`
+}
+
+const (
+	FriendRequestPending  = 1
+	FriendRequestAccepted = 2
+	FriendRequestRejected = 3
+	FriendRequestExpired  = 4
+)
+
+const (
+	MessageStatusNormal   = 1
+	MessageStatusRecalled = 2
+)
+
+// RecalledContent 已撤回消息在历史记录中展示的内容，与视图中的占位内容保持一致
+const RecalledContent = "该消息已被撤回"
+
+// VReplyPreview 被引用消息的预览
+type VReplyPreview struct {
+	SnowId       string `json:"snowId"`
+	Userid       int64  `json:"userid"`
+	FromUsername string `json:"fromUsername"`
+	MessageType  string `json:"messageType"`
+	Content      string `json:"content"`
+	Status       int    `json:"status"`
+}
+
+// VMention 用户尚未处理的@提及
+type VMention struct {
+	SnowId       string    `json:"snowId"`
 	GroupId      int64     `json:"groupId"`
-	Content      string    `json:"content"`
-	MessageType  string    `json:"messageType"`
 	GroupName    string    `json:"groupName"`
+	FromA        int64     `json:"fromA"`
 	FromUsername string    `json:"fromUsername"`
-	Avatar       string    `json:"avatar"`
-	SnowId       string    `json:"snowId"`
+	Content      string    `json:"content"`
+	MentionAll   bool      `json:"mentionAll"`
 	CreateAt     time.Time `json:"createAt"`
 }
 
-type VFriendMessage struct {
-	ID           int64     `json:"id"`
-	Belong       int64     `json:"belong"`
-	Userid       int64     `json:"userid"`
-	FriendId     int64     `json:"friendId"`
+// JoinMentions 把@提及的用户id转换为t_message.mentions中保存的格式
+func JoinMentions(mentions []int64) string {
+	idList := make([]string, 0, len(mentions))
+	for _, id := range mentions {
+		idList = append(idList, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(idList, ",")
+}
+
+// SplitMentions 解析t_message.mentions中保存的用户id
+func SplitMentions(mentions string) []int64 {
+	if mentions == "" {
+		return nil
+	}
+	var idList []int64
+	for _, val := range strings.Split(mentions, ",") {
+		if id, err := strconv.ParseInt(val, 10, 64); err == nil {
+			idList = append(idList, id)
+		}
+	}
+	return idList
+}
+
+// VMessageSearchResult 聊天记录的检索结果，ObjectId为结果所在的会话对象，私聊为对方id，群聊为群聊id
+type VMessageSearchResult struct {
+	SnowId       string    `json:"snowId"`
+	Type         string    `json:"type"`
+	ObjectId     int64     `json:"objectId"`
+	ObjectName   string    `json:"objectName"`
+	FromId       int64     `json:"fromId"`
+	FromUsername string    `json:"fromUsername"`
+	Avatar       string    `json:"avatar"`
 	Content      string    `json:"content"`
 	MessageType  string    `json:"messageType"`
-	FriendName   string    `json:"friendName"`
+	Seq          int64     `json:"seq"`
+	CreateAt     time.Time `json:"createAt"`
+	Highlights   [][2]int  `json:"highlights" gorm:"-"` // 关键词在Content中的位置，按字符计算的[start, end)区间
+}
+
+// VFriendRequest 好友申请以及双方的用户信息
+type VFriendRequest struct {
+	ID           int64     `json:"id"`
+	FromA        int64     `json:"fromA"`
 	FromUsername string    `json:"fromUsername"`
-	Avatar       string    `json:"avatar"`
-	SnowId       string    `json:"snowId"`
+	FromAvatar   string    `json:"fromAvatar"`
+	ToB          int64     `json:"toB"`
+	ToUsername   string    `json:"toUsername"`
+	ToAvatar     string    `json:"toAvatar"`
+	Greeting     string    `json:"greeting"`
+	State        int       `json:"state"`
+	ExpireAt     time.Time `json:"expireAt"`
 	CreateAt     time.Time `json:"createAt"`
 }
+
+// db views model
+
+type VGroupMessage struct {
+	ID           int64      `json:"id"`
+	Belong       int64      `json:"belong"`
+	Userid       int64      `json:"userid"`
+	GroupId      int64      `json:"groupId"`
+	Content      string     `json:"content"`
+	MessageType  string     `json:"messageType"`
+	GroupName    string     `json:"groupName"`
+	FromUsername string     `json:"fromUsername"`
+	Avatar       string     `json:"avatar"`
+	SnowId       string     `json:"snowId"`
+	Seq          int64      `json:"seq"`
+	Status       int        `json:"status"`
+	Revision     int64      `json:"revision"`
+	EditedAt     *time.Time `json:"editedAt"`
+	ReplyTo      string     `json:"replyTo"`
+	Mentions     string     `json:"mentions"`
+	MentionAll   bool       `json:"mentionAll"`
+	ExpireAt     *time.Time `json:"expireAt"`
+	CreateAt     time.Time  `json:"createAt"`
+}
+
+type VFriendMessage struct {
+	ID           int64      `json:"id"`
+	Belong       int64      `json:"belong"`
+	Userid       int64      `json:"userid"`
+	FriendId     int64      `json:"friendId"`
+	Content      string     `json:"content"`
+	MessageType  string     `json:"messageType"`
+	FriendName   string     `json:"friendName"`
+	FromUsername string     `json:"fromUsername"`
+	Avatar       string     `json:"avatar"`
+	SnowId       string     `json:"snowId"`
+	Seq          int64      `json:"seq"`
+	Status       int        `json:"status"`
+	Revision     int64      `json:"revision"`
+	EditedAt     *time.Time `json:"editedAt"`
+	ReplyTo      string     `json:"replyTo"`
+	ExpireAt     *time.Time `json:"expireAt"`
+	CreateAt     time.Time  `json:"createAt"`
+}
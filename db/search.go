@@ -0,0 +1,175 @@
+package db
+
+/**
+*Author: AxisZql
+*Date: 2022-8-6
+*DESC: message search abstraction, logic层只依赖MessageSearcher接口，默认由MySQL的FULLTEXT索引实现，
+*      接入其他搜索引擎时实现该接口并通过SetMessageSearcher替换，由实现自己负责维护索引
+ */
+
+import (
+	"axisChat/utils/zlog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MessageSearchQuery 聊天记录的检索条件，可见范围为用户自己的私聊信箱以及GroupIds中的群聊
+type MessageSearchQuery struct {
+	Userid      int64
+	GroupIds    []int64   // 用户当前加入的群聊
+	Keyword     string    // 以空白字符分隔的多个关键词需要同时匹配
+	Type        string    // 会话类型，为空时检索所有会话
+	ObjectId    int64     // Type不为空时的会话对象，私聊为好友id，群聊为群聊id
+	FromId      int64     // 发送方，为0时不限制
+	MessageType string    // 消息类型，为空时不限制
+	Start       time.Time // 发送时间的范围[Start, End)，为零值时不限制
+	End         time.Time
+	Before      string // snowId游标，获取该消息之前的结果
+	Limit       int
+}
+
+// MessageSearcher 聊天记录的检索，结果按snowId倒序排列，只返回没有被撤回的消息
+type MessageSearcher interface {
+	// Search 检索一页结果，hasMore表示是否还有更早的结果
+	Search(query *MessageSearchQuery, results *[]VMessageSearchResult) (hasMore bool, err error)
+}
+
+var (
+	searcherMutex   sync.RWMutex
+	defaultSearcher MessageSearcher = mysqlSearcher{}
+)
+
+// GetMessageSearcher 获取聊天记录的检索实现
+func GetMessageSearcher() MessageSearcher {
+	searcherMutex.RLock()
+	defer searcherMutex.RUnlock()
+	return defaultSearcher
+}
+
+// SetMessageSearcher 替换默认的检索实现
+func SetMessageSearcher(searcher MessageSearcher) {
+	searcherMutex.Lock()
+	defaultSearcher = searcher
+	searcherMutex.Unlock()
+}
+
+// MinSearchKeywordLength ngram分词的最小长度，比它短的关键词无法通过FULLTEXT索引匹配
+const MinSearchKeywordLength = 2
+
+// SplitSearchKeyword 把检索的内容拆分为关键词，双引号会破坏BOOLEAN MODE的语法，当作分隔符处理
+func SplitSearchKeyword(keyword string) []string {
+	return strings.Fields(strings.ReplaceAll(keyword, `"`, " "))
+}
+
+// mysqlSearcher 基于t_message.content上ngram分词的FULLTEXT索引，索引由MySQL在写入和编辑消息时维护
+type mysqlSearcher struct{}
+
+func (mysqlSearcher) Search(query *MessageSearchQuery, results *[]VMessageSearchResult) (hasMore bool, err error) {
+	terms := SplitSearchKeyword(query.Keyword)
+	db := GetDb()
+	tx := db.Table("t_message AS m").
+		Select("m.snow_id, m.type, m.from_a AS from_id, IFNULL(f.username, '') AS from_username, IFNULL(f.avatar, '') AS avatar, "+
+			"IF(m.type = 'group', m.to_b, IF(m.from_a = m.belong, m.to_b, m.from_a)) AS object_id, "+
+			"IFNULL(IF(m.type = 'group', g.group_name, peer.username), '') AS object_name, "+
+			"m.content, m.message_type, m.seq, m.create_at").
+		Joins("LEFT JOIN t_user AS f ON f.id = m.from_a").
+		Joins("LEFT JOIN t_user AS peer ON m.type = 'friend' AND peer.id = IF(m.from_a = m.belong, m.to_b, m.from_a)").
+		Joins("LEFT JOIN t_group AS g ON m.type = 'group' AND g.id = m.to_b").
+		Where("MATCH(m.content) AGAINST(? IN BOOLEAN MODE)", booleanQuery(terms)).
+		Where("m.status = ? AND m.delete_at IS NULL", MessageStatusNormal).
+		Where(messageNotExpired, time.Now())
+	switch query.Type {
+	case "friend":
+		tx = tx.Where("m.belong = ? AND m.type = 'friend' AND ((m.from_a = ? AND m.to_b = ?) OR (m.from_a = ? AND m.to_b = ?))",
+			query.Userid, query.Userid, query.ObjectId, query.ObjectId, query.Userid)
+	case "group":
+		tx = tx.Where("m.belong = ? AND m.type = 'group'", query.ObjectId)
+	default:
+		if len(query.GroupIds) == 0 {
+			tx = tx.Where("m.belong = ? AND m.type = 'friend'", query.Userid)
+		} else {
+			tx = tx.Where("(m.belong = ? AND m.type = 'friend') OR (m.belong IN ? AND m.type = 'group')", query.Userid, query.GroupIds)
+		}
+	}
+	if query.FromId != 0 {
+		tx = tx.Where("m.from_a = ?", query.FromId)
+	}
+	if query.MessageType != "" {
+		tx = tx.Where("m.message_type = ?", query.MessageType)
+	}
+	if !query.Start.IsZero() {
+		tx = tx.Where("m.create_at >= ?", query.Start)
+	}
+	if !query.End.IsZero() {
+		tx = tx.Where("m.create_at < ?", query.End)
+	}
+	// 结果跨越多个会话，seq之间无法比较，只按snowId的数值排序，snowId是varchar类型，需要先比较长度
+	if query.Before != "" {
+		tx = tx.Where(snowIdBefore, query.Before, query.Before, query.Before)
+	}
+	// 多查询一条用于判断是否还有更早的结果
+	r := tx.Order("LENGTH(m.snow_id) DESC, m.snow_id DESC").Limit(query.Limit + 1).Find(results)
+	if r.Error != nil {
+		zlog.Error(r.Error.Error())
+		return false, r.Error
+	}
+	if hasMore = len(*results) > query.Limit; hasMore {
+		*results = (*results)[:query.Limit]
+	}
+	for i := range *results {
+		(*results)[i].Highlights = HighlightRanges((*results)[i].Content, terms)
+	}
+	return hasMore, nil
+}
+
+// booleanQuery 每个关键词作为必须匹配的短语，短语中的运算符不会被解析
+func booleanQuery(terms []string) string {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrases = append(phrases, `+"`+term+`"`)
+	}
+	return strings.Join(phrases, " ")
+}
+
+// HighlightRanges 查找关键词在content中出现的位置，返回按字符（rune）计算的[start, end)区间，
+// 不区分大小写，重叠或者相邻的区间会被合并
+func HighlightRanges(content string, terms []string) [][2]int {
+	runes := []rune(content)
+	mark := make([]bool, len(runes))
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(runes); i++ {
+			if equalFoldRunes(runes[i:i+len(t)], t) {
+				for j := i; j < i+len(t); j++ {
+					mark[j] = true
+				}
+			}
+		}
+	}
+	var ranges [][2]int
+	for i := 0; i < len(mark); i++ {
+		if !mark[i] {
+			continue
+		}
+		start := i
+		for i < len(mark) && mark[i] {
+			i++
+		}
+		ranges = append(ranges, [2]int{start, i})
+	}
+	return ranges
+}
+
+func equalFoldRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] && unicode.ToLower(a[i]) != unicode.ToLower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
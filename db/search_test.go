@@ -0,0 +1,33 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightRanges(t *testing.T) {
+	cases := []struct {
+		content string
+		terms   []string
+		want    [][2]int
+	}{
+		{"今天一起吃饭吗，吃饭的地方你定", []string{"吃饭"}, [][2]int{{4, 6}, {8, 10}}},
+		// 不区分大小写
+		{"Hello hello", []string{"HELLO"}, [][2]int{{0, 5}, {6, 11}}},
+		// 重叠或者相邻的区间合并为一个
+		{"abcdef", []string{"abc", "bcd", "ef"}, [][2]int{{0, 6}}},
+		{"没有匹配", []string{"吃饭"}, nil},
+	}
+	for _, c := range cases {
+		if got := HighlightRanges(c.content, c.terms); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("HighlightRanges(%q, %v) = %v, want %v", c.content, c.terms, got, c.want)
+		}
+	}
+}
+
+func TestBooleanQuery(t *testing.T) {
+	terms := SplitSearchKeyword(`吃饭  "地方" +-*`)
+	if got, want := booleanQuery(terms), `+"吃饭" +"地方" +"+-*"`; got != want {
+		t.Fatalf("booleanQuery = %s, want %s", got, want)
+	}
+}
@@ -56,11 +56,7 @@ func (s *ServiceRegister) putKeyWithLease(lease int64) error {
 	if err != nil {
 		return err
 	}
-	//设置续租 定期发送需求请求
-	//KeepAlive使给定的租约永远有效。 如果发布到通道的keepalive响应没有立即被使用，
-	// 则租约客户端将至少每秒钟继续向etcd服务器发送保持活动请求，直到获取最新的响应为止。
-	//etcd client会自动发送ttl到etcd server，从而保证该租约一直有效
-	leaseRespChan, err := s.cli.KeepAlive(context.Background(), resp.ID)
+	leaseRespChan, err := keepAlive(s.cli, resp.ID)
 	if err != nil {
 		return err
 	}
@@ -72,6 +68,14 @@ func (s *ServiceRegister) putKeyWithLease(lease int64) error {
 	return nil
 }
 
+//keepAlive 设置续租 定期发送需求请求
+func keepAlive(cli *clientv3.Client, leaseID clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	//KeepAlive使给定的租约永远有效。 如果发布到通道的keepalive响应没有立即被使用，
+	// 则租约客户端将至少每秒钟继续向etcd服务器发送保持活动请求，直到获取最新的响应为止。
+	//etcd client会自动发送ttl到etcd server，从而保证该租约一直有效
+	return cli.KeepAlive(context.Background(), leaseID)
+}
+
 //ListenLeaseRespChan 监听 续租情况
 func (s *ServiceRegister) ListenLeaseRespChan() {
 	defer func() {
@@ -0,0 +1,97 @@
+package etcd
+
+import (
+	"axisChat/utils/zlog"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+// WorkerIdAllocator 通过租约在集群范围内为每个进程分配唯一的snowflake workerId，进程退出或者租约过期后workerId会被回收
+type WorkerIdAllocator struct {
+	cli      *clientv3.Client
+	prefix   string // workerId对应key的前缀，key为 prefix/workerId
+	maxId    int64
+	lease    int64
+	leaseID  clientv3.LeaseID
+	workerId int64
+	//租约keepalive相应chan
+	keepAliveChan <-chan *clientv3.LeaseKeepAliveResponse
+}
+
+// NewWorkerIdAllocator 新建workerId分配器，可以分配的workerId范围为[0,maxId]
+func NewWorkerIdAllocator(endpoints []string, prefix string, maxId int64, lease int64, dailTimeout int) (*WorkerIdAllocator, error) {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: time.Duration(dailTimeout) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &WorkerIdAllocator{
+		cli:      cli,
+		prefix:   prefix,
+		maxId:    maxId,
+		lease:    lease,
+		workerId: -1,
+	}, nil
+}
+
+// Acquire 从0开始依次尝试占用workerId，只有对应key不存在时才会写入成功，val用于记录占用该workerId的进程
+func (w *WorkerIdAllocator) Acquire(val string) (int64, error) {
+	resp, err := w.cli.Grant(context.Background(), w.lease)
+	if err != nil {
+		return -1, err
+	}
+	for id := int64(0); id <= w.maxId; id++ {
+		key := fmt.Sprintf("%s/%d", w.prefix, id)
+		txn, err := w.cli.Txn(context.Background()).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, val, clientv3.WithLease(resp.ID))).
+			Commit()
+		if err != nil {
+			_, _ = w.cli.Revoke(context.Background(), resp.ID)
+			return -1, err
+		}
+		if !txn.Succeeded {
+			continue
+		}
+		leaseRespChan, err := keepAlive(w.cli, resp.ID)
+		if err != nil {
+			_, _ = w.cli.Revoke(context.Background(), resp.ID)
+			return -1, err
+		}
+		w.leaseID = resp.ID
+		w.workerId = id
+		w.keepAliveChan = leaseRespChan
+		zlog.Info(fmt.Sprintf("Acquire worker id key:%s  val:%s  success!", key, val))
+		return id, nil
+	}
+	_, _ = w.cli.Revoke(context.Background(), resp.ID)
+	return -1, errors.New(fmt.Sprintf("all worker id under %s are in use", w.prefix))
+}
+
+// ListenLeaseRespChan 监听续租情况，租约失效后返回，此时workerId可能已经被其他进程占用
+func (w *WorkerIdAllocator) ListenLeaseRespChan() {
+	for {
+		select {
+		case _, ok := <-w.keepAliveChan:
+			if !ok {
+				zlog.Error(fmt.Sprintf("worker id %d 续租关闭了", w.workerId))
+				return
+			}
+		}
+	}
+}
+
+// Close 释放workerId
+func (w *WorkerIdAllocator) Close() error {
+	if _, err := w.cli.Revoke(context.Background(), w.leaseID); err != nil {
+		return err
+	}
+	zlog.Info(fmt.Sprintf("释放worker id %d", w.workerId))
+	return w.cli.Close()
+}
@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"axisChat/common"
 	"axisChat/config"
 	"axisChat/etcd"
 	"axisChat/proto"
@@ -29,6 +30,12 @@ func (logic *Logic) Run() {
 	//logic.ServerId = fmt.Sprintf("logic-%s", uuid.New().String())
 	conf := config.GetConfig().LogicRpc.Logic
 	logic.ServerId = conf.ServerId
+	// 消息的snowId在生产时分配，需要从etcd租用集群内唯一的snowflake workerId
+	if err := common.InitSnowflakeNode("logic"); err != nil {
+		panic(err)
+	}
+	// 会话设置了消息存活时间后，过期的消息由后台任务清理
+	go runExpireSweeper(logic.ServerId)
 	list := strings.Split(conf.RpcAddress, ";")
 	for _, val := range list {
 		err := initLogicRpcServer(val, logic.ServerId)
@@ -3,9 +3,13 @@ package logic
 import (
 	"axisChat/common"
 	"axisChat/db"
+	"axisChat/proto"
+	"axisChat/utils"
 	"axisChat/utils/zlog"
 	"encoding/json"
+	"fmt"
 	"github.com/pkg/errors"
+	"time"
 )
 
 /**
@@ -31,9 +35,9 @@ func Push(objectId int64, payload interface{}, op int) (err error) {
 		}
 	}
 	body, _ := json.Marshal(&msg)
-	err = common.TopicProduce(objectId, _type, body)
+	err = topicProduce(objectId, _type, body, payload)
 	if err != nil {
-		err = common.TopicProduce(objectId, _type, body) //重试一遍
+		err = topicProduce(objectId, _type, body, payload) //重试一遍
 		if err != nil {
 			zlog.Error(err.Error())
 			err = errors.New("推送消息-异常")
@@ -43,6 +47,254 @@ func Push(objectId int64, payload interface{}, op int) (err error) {
 	return
 }
 
+// PushChatEvent 把撤回等事件写入会话对应的topic，事件总是在对应的聊天消息之后被推送，payload中的Op为事件类型
+func PushChatEvent(_type string, objectId int64, payload interface{}) (err error) {
+	var op int
+	switch msg := payload.(type) {
+	case common.GroupMsg:
+		op = msg.Op
+	case common.FriendMsg:
+		op = msg.Op
+	}
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  op,
+		Msg: payload,
+	})
+	err = topicProduce(objectId, _type, body, payload)
+	if err != nil {
+		err = topicProduce(objectId, _type, body, payload) //重试一遍
+		if err != nil {
+			zlog.Error(err.Error())
+			err = errors.New("推送事件-异常")
+			return
+		}
+	}
+	return
+}
+
+// topicProduce 聊天消息或者事件设置了过期时间时，由消息队列在过期后把它从信箱中删除
+func topicProduce(objectId int64, _type string, body []byte, payload interface{}) error {
+	var expireAt string
+	switch msg := payload.(type) {
+	case common.GroupMsg:
+		expireAt = msg.ExpireAt
+	case common.FriendMsg:
+		expireAt = msg.ExpireAt
+	}
+	if t, err := time.Parse(time.RFC3339, expireAt); err == nil {
+		return common.TopicProduceExpiring(objectId, _type, body, t)
+	}
+	return common.TopicProduce(objectId, _type, body)
+}
+
+// 会话消息存活时间的缓存时间，修改存活时间时删除缓存
+const messageTTLCacheExpire = 10 * time.Minute
+
+// queryMessageTTL 获取会话的消息存活时间（秒），0表示消息不过期，私聊时objectA、objectB为双方的用户id，群聊时objectB为群聊id
+func queryMessageTTL(_type string, objectA, objectB int64) (int64, error) {
+	c := &common.CacheOptions{
+		Key:      fmt.Sprintf(common.ConversationTTL, common.ConversationId(_type, objectA, objectB)),
+		Duration: messageTTLCacheExpire,
+		Fun: func() (interface{}, error) {
+			var ttl int64
+			if _type == "group" {
+				var group db.TGroup
+				db.QueryGroupById(objectB, &group)
+				ttl = group.MessageTTL
+			} else {
+				ttl = db.QueryFriendMessageTTL(objectA, objectB)
+			}
+			return &ttl, nil
+		},
+		Receiver: new(int64),
+	}
+	res, err := c.GetSet()
+	if err != nil {
+		return 0, errors.New("系统异常")
+	}
+	ttl, _ := res.(*int64)
+	if ttl == nil {
+		return 0, nil
+	}
+	return *ttl, nil
+}
+
+// messageExpireAt 根据消息的发送时间和会话的消息存活时间计算过期时间，不过期时返回空字符串
+func messageExpireAt(createAt string, ttl int64) string {
+	if ttl <= 0 {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, createAt)
+	if err != nil {
+		return ""
+	}
+	return t.Add(time.Duration(ttl) * time.Second).Format(time.RFC3339)
+}
+
+// NextConversationSeq 分配会话内单调递增的消息序号，私聊时objectA、objectB为双方的用户id，群聊时objectB为群聊id，
+// 分配的序号同步写入t_conversation_seq之后才会被使用，redis中的序号丢失后不会重复分配
+func NextConversationSeq(_type string, objectA, objectB int64) (int64, error) {
+	conversationId := common.ConversationId(_type, objectA, objectB)
+	key := fmt.Sprintf(common.ConversationSeq, conversationId)
+	val, err := common.RedisGetString(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(val) == 0 {
+		// redis中的序号丢失（或者会话的第一条消息）时，以已经分配过的最大序号为起点，
+		// 引入t_conversation_seq之前的会话只能以已经持久化的消息为准
+		maxSeq, err := db.QueryConversationSeq(conversationId)
+		if err != nil {
+			return 0, err
+		}
+		persisted, err := db.QueryMaxMessageSeq(_type, objectA, objectB)
+		if err != nil {
+			return 0, err
+		}
+		if persisted > maxSeq {
+			maxSeq = persisted
+		}
+		if _, err = common.RedisSetNX(key, maxSeq, 0); err != nil {
+			return 0, err
+		}
+	}
+	seq, err := common.RedisIncr(key)
+	if err != nil {
+		return 0, err
+	}
+	if err = db.SaveConversationSeq(conversationId, seq); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// 发送幂等键的有效期，需要覆盖客户端超时重试的时间范围
+const sendIdempotencyExpire = 24 * time.Hour
+
+// 正在发送的幂等键在该时间内不会被重试的请求接管，超过时认为之前的发送进程已经崩溃
+const sendClaimLease = 30 * time.Second
+
+// 幂等键中记录的发送状态
+const (
+	sendStatusSending = "sending"
+	sendStatusFailed  = "failed"
+	sendStatusSent    = "sent"
+)
+
+// sentRecord 服务端为聊天消息分配的标识，同时也是幂等键中记录的发送状态，重试时沿用第一次分配的snowId和seq
+type sentRecord struct {
+	SnowId   string `json:"snowId"`
+	Seq      int64  `json:"seq"`
+	CreateAt string `json:"createAt"`
+	Status   string `json:"status,omitempty"`
+	ClaimAt  int64  `json:"claimAt,omitempty"` // 开始发送的unix时间戳
+}
+
+// SendChatMsg 在生产消息之前分配snowId、seq和时间戳，再调用produce把消息写入topic，
+// watermark不为0时以(发送方, watermark)为幂等键，重复发送直接返回第一次发送的结果，
+// 第一次发送失败时保留已经分配的snowId和seq，重试时重新生产同一条消息，接收方和persist层根据snowId去重
+func SendChatMsg(_type string, userid, objectId, watermark int64, produce func(record sentRecord) error) (reply *proto.SendReply, err error) {
+	reply = &proto.SendReply{Watermark: watermark}
+	record := sentRecord{CreateAt: time.Now().Format(time.RFC3339)}
+	record.SnowId, err = utils.GetSnowflakeId()
+	if err != nil {
+		zlog.Error(fmt.Sprintf("generate snowflake id err:%v", err))
+		return nil, errors.New("系统异常")
+	}
+	if watermark != 0 {
+		sent, e := claimSend(userid, watermark, &record)
+		if e != nil {
+			return nil, e
+		}
+		if sent {
+			// 客户端超时重试，直接返回第一次发送时分配的消息
+			reply.SnowId, reply.Seq, reply.CreateAt, reply.Duplicate = record.SnowId, record.Seq, record.CreateAt, true
+			return reply, nil
+		}
+	}
+	if record.Seq == 0 {
+		record.Seq, err = NextConversationSeq(_type, userid, objectId)
+		if err == nil && watermark != 0 {
+			// seq写入幂等键之后再生产消息，并发的重复请求和失败后的重试都能获取到同一个seq
+			err = saveSend(userid, watermark, record, sendStatusSending)
+		}
+	}
+	if err == nil {
+		err = produce(record)
+	}
+	if err != nil {
+		if watermark != 0 {
+			_ = saveSend(userid, watermark, record, sendStatusFailed)
+		}
+		return nil, errors.New("系统异常")
+	}
+	if watermark != 0 {
+		_ = saveSend(userid, watermark, record, sendStatusSent)
+	}
+	reply.SnowId, reply.Seq, reply.CreateAt = record.SnowId, record.Seq, record.CreateAt
+	return reply, nil
+}
+
+// claimSend 以(发送方, watermark)为幂等键占用本次发送，sent为true表示消息已经发送过，此时record为第一次发送的结果；
+// 之前的发送失败或者发送进程已经崩溃时接管幂等键，record沿用之前分配的snowId和seq；其他请求正在发送时返回错误
+func claimSend(userid, watermark int64, record *sentRecord) (sent bool, err error) {
+	key := fmt.Sprintf(common.SendIdempotency, userid, watermark)
+	record.Status, record.ClaimAt = sendStatusSending, time.Now().Unix()
+	body, _ := json.Marshal(record)
+	ok, err := common.RedisSetNX(key, body, sendIdempotencyExpire)
+	if err != nil {
+		return false, errors.New("系统异常")
+	}
+	if ok {
+		return false, nil
+	}
+	old, err := common.RedisGetString(key)
+	if err != nil {
+		return false, errors.New("系统异常")
+	}
+	if len(old) == 0 {
+		// 幂等键恰好过期，当作第一次发送
+		return claimSend(userid, watermark, record)
+	}
+	var prev sentRecord
+	if err = json.Unmarshal(old, &prev); err != nil {
+		zlog.Error(fmt.Sprintf("illegal send idempotency record %s err:%v", string(old), err))
+		return false, errors.New("系统异常")
+	}
+	switch {
+	case prev.Status == sendStatusSent || (prev.Status == "" && prev.Seq != 0):
+		// 升级之前的记录没有发送状态，发送成功后才会写入seq
+		*record = prev
+		return true, nil
+	case prev.Status == sendStatusSending && time.Since(time.Unix(prev.ClaimAt, 0)) < sendClaimLease:
+		return false, errors.New("消息正在发送中，请稍后重试")
+	}
+	claim := prev
+	claim.Status, claim.ClaimAt = sendStatusSending, time.Now().Unix()
+	body, _ = json.Marshal(&claim)
+	ok, err = common.RedisCompareAndSet(key, old, body, sendIdempotencyExpire)
+	if err != nil {
+		return false, errors.New("系统异常")
+	}
+	if !ok {
+		// 其他重试请求已经接管
+		return false, errors.New("消息正在发送中，请稍后重试")
+	}
+	*record = claim
+	return false, nil
+}
+
+// saveSend 更新幂等键中记录的发送状态，更新失败时只记录日志
+func saveSend(userid, watermark int64, record sentRecord, status string) error {
+	record.Status = status
+	body, _ := json.Marshal(&record)
+	err := common.RedisSetString(fmt.Sprintf(common.SendIdempotency, userid, watermark), body, sendIdempotencyExpire)
+	if err != nil {
+		zlog.Error(fmt.Sprintf("save send idempotency key userid=%d watermark=%d status=%s err:%v", userid, watermark, status, err))
+	}
+	return err
+}
+
 func PushUserInfo(userid int64, username string, friendId int64, op int) (err error) {
 	var msg interface{}
 	switch op {
@@ -71,14 +323,23 @@ func PushUserInfo(userid int64, username string, friendId int64, op int) (err er
 	return
 }
 
-func PushGroupInfo(userid, groupId int64, onlineCount int, userList []db.TUser, onlineUserId []int64) (err error) {
+func PushGroupInfo(userid, groupId int64, onlineCount int, userList []db.TUser, onlineUserId []int64, members []common.GroupMember) (err error) {
+	// 群名称、群公告和群头像随群聊信息一起推送，客户端据此更新群资料
+	group, err := queryGroupProfile(groupId)
+	if err != nil {
+		return err
+	}
 	msg := common.MsgSend{
 		Op: common.OpGroupInfoSend,
 		Msg: common.GroupInfoMsg{
 			GroupId:       groupId,
+			GroupName:     group.GroupName,
+			Notice:        group.Notice,
+			Avatar:        group.Avatar,
 			Count:         onlineCount,
 			UserArr:       userList,
 			OnlineUserIds: onlineUserId,
+			Members:       members,
 			Op:            common.OpGroupInfoSend,
 		},
 	}
@@ -87,6 +348,61 @@ func PushGroupInfo(userid, groupId int64, onlineCount int, userList []db.TUser,
 	return
 }
 
+// PushReactionEvent 表情回应的变化通过状态消息队列推送，不影响聊天消息的顺序投递
+func PushReactionEvent(event common.ReactionMsg) (err error) {
+	event.Op = common.OpMsgReactionSend
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  common.OpMsgReactionSend,
+		Msg: event,
+	})
+	err = common.RedisLPUSH(common.StatusMsgQueue, body)
+	return
+}
+
+// PushGroupDetach 通知connect层将离开群聊的成员的连接从GroupNode中删除
+func PushGroupDetach(event common.GroupDetachMsg) (err error) {
+	event.Op = common.OpGroupDetachSend
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  common.OpGroupDetachSend,
+		Msg: event,
+	})
+	err = common.RedisLPUSH(common.StatusMsgQueue, body)
+	return
+}
+
+// PushUnread 通过状态消息队列向用户推送会话未读数的变化
+func PushUnread(event common.UnreadMsg) (err error) {
+	event.Op = common.OpUnreadSend
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  common.OpUnreadSend,
+		Msg: event,
+	})
+	err = common.RedisLPUSH(common.StatusMsgQueue, body)
+	return
+}
+
+// PushGroupJoinRequest 通过状态消息队列通知群聊管理员有新的入群申请，或者通知申请方申请已被处理
+func PushGroupJoinRequest(event common.GroupJoinRequestMsg) (err error) {
+	event.Op = common.OpGroupJoinRequestSend
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  common.OpGroupJoinRequestSend,
+		Msg: event,
+	})
+	err = common.RedisLPUSH(common.StatusMsgQueue, body)
+	return
+}
+
+// PushFriendRequest 通过状态消息队列通知好友申请的接收方或者申请方
+func PushFriendRequest(event common.FriendRequestMsg) (err error) {
+	event.Op = common.OpFriendRequestSend
+	body, _ := json.Marshal(&common.MsgSend{
+		Op:  common.OpFriendRequestSend,
+		Msg: event,
+	})
+	err = common.RedisLPUSH(common.StatusMsgQueue, body)
+	return
+}
+
 func PushGroupCount(groupId int64, count int) (err error) {
 	msg := common.MsgSend{
 		Op: common.OpGroupOlineUserCountSend,
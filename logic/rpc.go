@@ -12,7 +12,10 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -50,7 +53,7 @@ func (s *ServerLogic) Connect(ctx context.Context, request *proto.ConnectRequest
 	}
 	// 在通知该用户加入的群聊、所有加好友，其的上线消息
 	groupIdList := db.QueryUserAllGroupId(user.ID)
-	friendIdList := db.QueryUserAllFriendId(user.ID)
+	friendIdList := queryVisibleFriendIds(user.ID)
 
 	// 更改对应用户的在线状态为在线
 	if err = common.RedisHSet(common.AllOnlineUser, fmt.Sprintf("%d", user.ID), "on"); err != nil {
@@ -93,7 +96,7 @@ func (s *ServerLogic) Connect(ctx context.Context, request *proto.ConnectRequest
 			id, _ := strconv.Atoi(k)
 			onlineUserIdList = append(onlineUserIdList, int64(id))
 		}
-		err = PushGroupInfo(user.ID, val, count, userList, onlineUserIdList)
+		err = PushGroupInfo(user.ID, val, count, userList, onlineUserIdList, queryGroupMembers(val))
 		if err != nil {
 			err = errors.New("系统异常")
 			return
@@ -135,7 +138,7 @@ func (s *ServerLogic) DisConnect(ctx context.Context, request *proto.DisConnectR
 	// 在通知该用户加入的群聊、所有加好友，其的上线消息
 
 	groupIdList := db.QueryUserAllGroupId(request.Userid)
-	friendIdList := db.QueryUserAllFriendId(request.Userid)
+	friendIdList := queryVisibleFriendIds(request.Userid)
 
 	// 更改对应用户的在线状态为下线,todo:不采取删除而是采取字段更改的原因是，删除对应field 频繁涉及空间的申请和释放
 	if err = common.RedisHSet(common.AllOnlineUser, fmt.Sprintf("%d", request.Userid), "off"); err != nil {
@@ -177,7 +180,7 @@ func (s *ServerLogic) DisConnect(ctx context.Context, request *proto.DisConnectR
 		}
 
 		// TODO:往该群聊的的topic中生产消息
-		err = PushGroupInfo(request.Userid, val, count, userList, onlineUserIdList)
+		err = PushGroupInfo(request.Userid, val, count, userList, onlineUserIdList, queryGroupMembers(val))
 		if err != nil {
 			err = errors.New("系统异常")
 			return
@@ -339,13 +342,18 @@ func (s *ServerLogic) AfterLogin(ctx context.Context, request *proto.AfterLoginR
 		id, _ := strconv.Atoi(K)
 		allOnlineUserId = append(allOnlineUserId, int64(id))
 	}
+	// 用户在各个会话中的未读数
+	var unreadMap map[string]string
+	unreadMap, err = common.RedisHGetAll(fmt.Sprintf(common.UserUnread, request.Userid))
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
 
-	// 录入所有好友的最近聊天记录
-	for _, id := range friendIdList {
-		var userInfo db.TUser
-		db.QueryUserById(id, &userInfo)
-		msgList := make([]db.VFriendMessage, 0)
-		db.QueryFriendMessageByPage(request.Userid, id, &msgList, 1, 16)
+	// 历史消息在打开会话时通过分页接口按需获取，这里只批量查询好友和群聊的信息
+	var friendList []db.TUser
+	db.QueryUsersByIds(friendIdList, &friendList)
+	for _, userInfo := range friendList {
 		tmp := &proto.FriendData{
 			Userid:   userInfo.ID,
 			Username: userInfo.Username,
@@ -355,58 +363,28 @@ func (s *ServerLogic) AfterLogin(ctx context.Context, request *proto.AfterLoginR
 			Avatar:   userInfo.Avatar,
 			CreateAt: userInfo.CreateAt.Format(time.RFC3339),
 		}
-		for _, m := range msgList {
-			mtmp := &proto.ChatMessage{
-				Id:           m.ID,
-				Userid:       m.Userid,
-				FriendId:     m.FriendId,
-				Content:      m.Content,
-				MessageType:  m.MessageType,
-				CreateAt:     m.CreateAt.Format(time.RFC3339),
-				FromUsername: m.FromUsername,
-				FriendName:   m.FriendName,
-				Avatar:       m.Avatar,
-				SnowId:       m.SnowId,
-			}
-			tmp.Messages = append(tmp.Messages, mtmp)
-		}
+		tmp.Unread, _ = strconv.ParseInt(unreadMap[common.ConversationId("friend", request.Userid, userInfo.ID)], 10, 64)
 		reply.FriendData = append(reply.FriendData, tmp)
 	}
 
-	// 录入所有群聊的聊天记录
-	for _, id := range groupIdList {
-		var groupInfo db.TGroup
-		db.QueryGroupById(id, &groupInfo)
-		msgList := make([]db.VGroupMessage, 0)
-		db.QueryGroupMessageByPage(id, &msgList, 1, 16)
+	var groupList []db.TGroup
+	db.QueryGroupsByIds(groupIdList, &groupList)
+	for _, groupInfo := range groupList {
 		tmp := &proto.GroupData{
 			GroupId:   groupInfo.ID,
 			GroupName: groupInfo.GroupName,
 			Notice:    groupInfo.Notice,
 			Userid:    groupInfo.ID,
 			CreateAt:  groupInfo.CreateAt.Format(time.RFC3339),
+			Avatar:    groupInfo.Avatar,
 		}
-		for _, m := range msgList {
-			mtmp := &proto.ChatMessage{
-				Id:           m.ID,
-				Userid:       m.Userid,
-				GroupId:      m.GroupId,
-				Content:      m.Content,
-				MessageType:  m.MessageType,
-				CreateAt:     m.CreateAt.Format(time.RFC3339),
-				FromUsername: m.FromUsername,
-				GroupName:    m.GroupName,
-				Avatar:       m.Avatar,
-				SnowId:       m.SnowId,
-			}
-			tmp.Messages = append(tmp.Messages, mtmp)
-		}
+		tmp.Unread, _ = strconv.ParseInt(unreadMap[common.ConversationId("group", request.Userid, groupInfo.ID)], 10, 64)
 		reply.GroupData = append(reply.GroupData, tmp)
 	}
 
-	for _, id := range allOnlineUserId {
-		var userInfo db.TUser
-		db.QueryUserById(id, &userInfo)
+	var onlineUserList []db.TUser
+	db.QueryUsersByIds(allOnlineUserId, &onlineUserList)
+	for _, userInfo := range onlineUserList {
 		tmp := &proto.UserData{
 			Userid:   userInfo.ID,
 			Avatar:   userInfo.Avatar,
@@ -481,6 +459,11 @@ func (s *ServerLogic) UpdateUserInfo(ctx context.Context, request *proto.UpdateU
 		err = errors.New("不存在该用户")
 		return
 	}
+	// 删除用户展示信息缓存，下次发送消息时重新加载
+	if err = common.RedisDelString(fmt.Sprintf(common.UserProfile, user.ID)); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
 	reply.User = &proto.User{
 		Id:       user.ID,
 		Username: user.Username,
@@ -528,7 +511,15 @@ func (s *ServerLogic) SearchUser(ctx context.Context, request *proto.SearchUserR
 	reply = new(proto.SearchUserReply)
 	var userList []db.TUser
 	db.SearchUserByUsername(request.Username, &userList)
+	// 拉黑了搜索方的用户不出现在搜索结果中
+	blockerMap := make(map[int64]struct{})
+	for _, id := range db.QueryUserBlockerId(request.Userid) {
+		blockerMap[id] = struct{}{}
+	}
 	for _, val := range userList {
+		if _, ok := blockerMap[val.ID]; ok {
+			continue
+		}
 		reply.UserList = append(reply.UserList, &proto.User{
 			Id:       val.ID,
 			Username: val.Username,
@@ -549,12 +540,14 @@ func (s *ServerLogic) SearchGroup(ctx context.Context, request *proto.SearchGrou
 	db.SearchGroupByGroupName(request.GroupName, &groupList)
 	for _, val := range groupList {
 		reply.GroupList = append(reply.GroupList, &proto.Group{
-			Id:        val.ID,
-			Userid:    val.ID,
-			GroupName: val.GroupName,
-			Notice:    val.Notice,
-			CreateAt:  val.CreateAt.Format(time.RFC3339),
-			UpdateAt:  val.UpdateAt.Format(time.RFC3339),
+			Id:         val.ID,
+			Userid:     val.ID,
+			GroupName:  val.GroupName,
+			Notice:     val.Notice,
+			CreateAt:   val.CreateAt.Format(time.RFC3339),
+			UpdateAt:   val.UpdateAt.Format(time.RFC3339),
+			JoinPolicy: int32(val.JoinPolicy),
+			Avatar:     val.Avatar,
 		})
 	}
 	return
@@ -565,7 +558,14 @@ func (s *ServerLogic) GetGroupMsgByPage(ctx context.Context, request *proto.GetG
 	var msgList []db.VGroupMessage
 	var userList []db.TUser
 	reply.Code = config.FailReplyCode
-	db.QueryGroupMessageByPage(request.GroupId, &msgList, int(request.Current), int(request.PageSize))
+	cursor, err := newMessageCursor(request.Before, request.After, request.Current, request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if reply.HasMore, err = db.QueryGroupMessageByPage(request.GroupId, &msgList, cursor); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
 	db.QueryGroupAllUser(request.GroupId, &userList)
 	reply.Code = config.SuccessReplyCode
 	for _, val := range msgList {
@@ -580,6 +580,14 @@ func (s *ServerLogic) GetGroupMsgByPage(ctx context.Context, request *proto.GetG
 			GroupName:    val.GroupName,
 			Avatar:       val.Avatar,
 			SnowId:       val.SnowId,
+			Seq:          val.Seq,
+			Recalled:     val.Status == db.MessageStatusRecalled,
+			EditedAt:     formatOptionalTime(val.EditedAt),
+			ExpireAt:     formatOptionalTime(val.ExpireAt),
+			Revision:     val.Revision,
+			ReplyTo:      val.ReplyTo,
+			Mentions:     db.SplitMentions(val.Mentions),
+			MentionAll:   val.MentionAll,
 		})
 	}
 	for _, val := range userList {
@@ -594,14 +602,41 @@ func (s *ServerLogic) GetGroupMsgByPage(ctx context.Context, request *proto.GetG
 			UpdateAt: val.UpdateAt.Format(time.RFC3339),
 		})
 	}
+	fillMessageExtras(reply.MessageArr)
 	return
 }
 
+// 历史消息每页的最大数目
+const maxMessagePageSize = 100
+
+// newMessageCursor 校验历史消息的分页参数，before和after为snowId，不能同时指定
+func newMessageCursor(before, after string, current, pageSize int64) (cursor db.MessageCursor, err error) {
+	if before != "" && after != "" {
+		return cursor, errors.New("before和after不能同时指定")
+	}
+	for _, snowId := range []string{before, after} {
+		if _, e := strconv.ParseInt(snowId, 10, 64); snowId != "" && e != nil {
+			return cursor, errors.New("无效的分页游标")
+		}
+	}
+	if pageSize > maxMessagePageSize {
+		pageSize = maxMessagePageSize
+	}
+	return db.MessageCursor{Before: before, After: after, Current: int(current), PageSize: int(pageSize)}, nil
+}
+
 func (s *ServerLogic) GetFriendMsgByPage(ctx context.Context, request *proto.GetFriendMsgByPageRequest) (reply *proto.GetFriendMsgByPageReply, err error) {
 	reply = new(proto.GetFriendMsgByPageReply)
 	var msgList []db.VFriendMessage
 	reply.Code = config.FailReplyCode
-	db.QueryFriendMessageByPage(request.Userid, request.FriendId, &msgList, int(request.Current), int(request.PageSize))
+	cursor, err := newMessageCursor(request.Before, request.After, request.Current, request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if reply.HasMore, err = db.QueryFriendMessageByPage(request.Userid, request.FriendId, &msgList, cursor); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
 	reply.Code = config.SuccessReplyCode
 	for _, val := range msgList {
 		reply.MessageArr = append(reply.MessageArr, &proto.ChatMessage{
@@ -615,8 +650,15 @@ func (s *ServerLogic) GetFriendMsgByPage(ctx context.Context, request *proto.Get
 			Avatar:       val.Avatar,
 			FromUsername: val.FromUsername,
 			SnowId:       val.SnowId,
+			Seq:          val.Seq,
+			Recalled:     val.Status == db.MessageStatusRecalled,
+			EditedAt:     formatOptionalTime(val.EditedAt),
+			ExpireAt:     formatOptionalTime(val.ExpireAt),
+			Revision:     val.Revision,
+			ReplyTo:      val.ReplyTo,
 		})
 	}
+	fillMessageExtras(reply.MessageArr)
 	return
 }
 
@@ -628,202 +670,2063 @@ func (s *ServerLogic) CreateGroup(ctx context.Context, request *proto.Group) (re
 		err = errors.New("该群聊名称已经被使用")
 		return
 	}
-	newGroup := &db.TGroup{
-		Userid:    request.Userid,
-		GroupName: request.GroupName,
-		Notice:    request.GroupName,
+	joinPolicy := int(request.JoinPolicy)
+	if joinPolicy == 0 {
+		joinPolicy = db.GroupJoinOpen
+	}
+	if joinPolicy < db.GroupJoinOpen || joinPolicy > db.GroupJoinInvite {
+		err = errors.New("不支持的加入方式")
+		return
 	}
-	db.CreateGroup(newGroup)
-	if newGroup.ID == 0 {
-		err = errors.New("系统异常，创建群聊失败")
+	newGroup := &db.TGroup{
+		Userid:     request.Userid,
+		GroupName:  request.GroupName,
+		Notice:     request.Notice,
+		Avatar:     request.Avatar,
+		JoinPolicy: joinPolicy,
+	}
+	if err = db.CreateGroup(newGroup); err != nil {
+		if err == db.ErrGroupNameUsed {
+			err = errors.New("该群聊名称已经被使用")
+		} else {
+			err = errors.New("系统异常，创建群聊失败")
+		}
 		return
 	}
 	return &proto.Group{
-		Id:        newGroup.ID,
-		Userid:    newGroup.Userid,
-		GroupName: newGroup.GroupName,
-		Notice:    newGroup.Notice,
-		CreateAt:  newGroup.CreateAt.Format(time.RFC3339),
-		UpdateAt:  newGroup.UpdateAt.Format(time.RFC3339),
+		Id:         newGroup.ID,
+		Userid:     newGroup.Userid,
+		GroupName:  newGroup.GroupName,
+		Notice:     newGroup.Notice,
+		CreateAt:   newGroup.CreateAt.Format(time.RFC3339),
+		UpdateAt:   newGroup.UpdateAt.Format(time.RFC3339),
+		JoinPolicy: int32(newGroup.JoinPolicy),
+		Avatar:     newGroup.Avatar,
 	}, nil
 }
 
-func (s *ServerLogic) AddGroup(ctx context.Context, request *proto.AddGroupRequest) (reply *empty.Empty, err error) {
-	reply = new(empty.Empty)
-	// 获取当前用户的serverId
-	var serverIdByte []byte
-	serverIdByte, err = common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, request.Userid))
-	if err != nil {
-		err = errors.New("系统异常，添加群聊失败")
+func (s *ServerLogic) AddGroup(ctx context.Context, request *proto.AddGroupRequest) (reply *proto.AddGroupReply, err error) {
+	reply = new(proto.AddGroupReply)
+	var groupInfo db.TGroup
+	db.QueryGroupById(request.GroupId, &groupInfo)
+	if groupInfo.ID == 0 {
+		err = errors.New("群聊不存在或者已经解散")
 		return
 	}
-	var user db.TUser
-	db.QueryUserById(request.Userid, &user)
+	var relation db.TRelation
+	db.QueryGroupRelation(request.Userid, request.GroupId, &relation)
+	// 群聊创建者以及已经是群成员的用户不受加入方式的限制
+	if groupInfo.Userid != request.Userid && relation.ID == 0 {
+		switch groupInfo.JoinPolicy {
+		case db.GroupJoinInvite:
+			err = permissionDenied("该群聊仅支持通过邀请链接加入")
+			return
+		case db.GroupJoinApproval:
+			reply.Pending = true
+			err = submitGroupJoinRequest(request.Userid, &groupInfo, request.Reason)
+			return
+		}
+	}
+	return reply, joinGroup(request.Userid, &groupInfo)
+}
+
+// joinGroup 将用户加入群聊，用户在线时同时更新群聊的在线信息，并向群聊的topic推送群聊信息变更的消息
+func joinGroup(userid int64, groupInfo *db.TGroup) (err error) {
 	relation := &db.TRelation{
 		Type:    "group",
-		ObjectA: request.Userid,
-		ObjectB: request.GroupId,
+		ObjectA: userid,
+		ObjectB: groupInfo.ID,
+		Role:    db.GroupRoleMember,
+	}
+	// 群聊创建者加入群聊时成为群主
+	if groupInfo.Userid == userid {
+		relation.Role = db.GroupRoleOwner
 	}
 	db.CreateRelation(relation)
 	if relation.ID == 0 {
-		err = errors.New("系统异常，添加群聊失败")
-		return
+		return errors.New("系统异常，添加群聊失败")
 	}
 
-	// 向群聊的topic推送新群聊状态变更的消息
-	groupIdList := db.QueryUserAllGroupId(user.ID)
-
-	// 更新该用户加入群聊的所有在redis中信息
-	var has bool
-	has, err = common.RedisIsNotExistHSet(fmt.Sprintf(common.GroupOnlineUser, request.GroupId), fmt.Sprintf("%d", user.ID), string(serverIdByte))
+	// 审批通过或者通过邀请链接加入时用户可能不在线，不在线的用户上线时再更新群聊的在线信息
+	online, err := common.RedisHGet(common.AllOnlineUser, fmt.Sprintf("%d", userid))
 	if err != nil {
-		err = errors.New("系统异常")
-		return
+		return errors.New("系统异常")
 	}
-	// 之前不在线的用户上线时才更新群聊在线人数
-	if !has {
-		// 更新hash table中对应群聊项的在线人数
-		err = common.RedisHINCRBY(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId), 1)
+	if online == "on" {
+		// 获取当前用户的serverId
+		var serverIdByte []byte
+		serverIdByte, err = common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, userid))
 		if err != nil {
-			err = errors.New("系统异常")
-			return
+			return errors.New("系统异常，添加群聊失败")
+		}
+		var has bool
+		has, err = common.RedisIsNotExistHSet(fmt.Sprintf(common.GroupOnlineUser, groupInfo.ID), fmt.Sprintf("%d", userid), string(serverIdByte))
+		if err != nil {
+			return errors.New("系统异常")
+		}
+		// 之前不在线的用户上线时才更新群聊在线人数
+		if !has {
+			// 更新hash table中对应群聊项的在线人数
+			err = common.RedisHINCRBY(common.GroupOnlineUserCount, fmt.Sprintf("%d", groupInfo.ID), 1)
+			if err != nil {
+				return errors.New("系统异常")
+			}
 		}
 	}
-	//往群聊对应topic写入群聊在线人数更新信息 TODO:need to test
-	var userList []db.TUser
-	db.QueryGroupAllUser(request.GroupId, &userList)
-	countStr, _ := common.RedisHGet(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId))
-	count, _ := strconv.Atoi(countStr)
-	var onlineUserIdList []int64
-	// 获取当前群聊的所有在线用户的id
-	var userMap map[string]string
-	userMap, err = common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, request.GroupId))
+	// 向群聊的topic推送群聊信息变更的消息
+	if err = pushGroupInfoUpdate(groupInfo.ID); err != nil {
+		return errors.New("系统异常")
+	}
+	// 在redis中写入当前用户的加入的所有群聊id
+	if err = updateUserGroupList(userid); err != nil {
+		return errors.New("系统异常")
+	}
+	createConversations(db.TConversation{Userid: userid, Type: "group", ObjectId: groupInfo.ID})
+	return nil
+}
+
+// 入群申请理由的最大长度，与t_group_join_request.reason的长度一致
+const maxJoinReasonLength = 128
+
+// submitGroupJoinRequest 提交入群申请，并实时通知群主和所有管理员
+func submitGroupJoinRequest(userid int64, groupInfo *db.TGroup, reason string) error {
+	if len([]rune(reason)) > maxJoinReasonLength {
+		return errors.New(fmt.Sprintf("申请理由不能超过%d个字符", maxJoinReasonLength))
+	}
+	var pending db.TGroupJoinRequest
+	db.QueryPendingGroupJoinRequest(userid, groupInfo.ID, &pending)
+	if pending.ID != 0 {
+		return errors.New("已经提交过入群申请，请等待管理员审批")
+	}
+	joinRequest := &db.TGroupJoinRequest{
+		GroupId: groupInfo.ID,
+		Userid:  userid,
+		Reason:  reason,
+		State:   db.GroupJoinPending,
+	}
+	if err := db.CreateGroupJoinRequest(joinRequest); err != nil {
+		return errors.New("系统异常")
+	}
+	var relationList []db.TRelation
+	db.QueryGroupRelations(groupInfo.ID, &relationList)
+	for _, r := range relationList {
+		if r.Role >= db.GroupRoleAdmin {
+			notifyGroupJoinRequest(joinRequest, groupInfo, r.ObjectA)
+		}
+	}
+	return nil
+}
+
+// notifyGroupJoinRequest 通知管理员有新的入群申请，或者通知申请方申请已被处理，推送失败时只记录日志
+func notifyGroupJoinRequest(joinRequest *db.TGroupJoinRequest, groupInfo *db.TGroup, belong int64) {
+	var user db.TUser
+	db.QueryUserById(joinRequest.Userid, &user)
+	err := PushGroupJoinRequest(common.GroupJoinRequestMsg{
+		RequestId: joinRequest.ID,
+		GroupId:   groupInfo.ID,
+		GroupName: groupInfo.GroupName,
+		Userid:    joinRequest.Userid,
+		Username:  user.Username,
+		Avatar:    user.Avatar,
+		Reason:    joinRequest.Reason,
+		State:     joinRequest.State,
+		Belong:    belong,
+	})
 	if err != nil {
-		err = errors.New("系统异常")
+		zlog.Error(fmt.Sprintf("push group join request id=%d err:%v", joinRequest.ID, err))
+	}
+}
+
+// 群资料各字段的最大长度，与t_group中对应字段的长度一致
+const (
+	maxGroupNameLength   = 32
+	maxGroupNoticeLength = 1024
+	maxGroupAvatarLength = 256
+)
+
+// UpdateGroup 群主和管理员修改群名称、群公告和群头像，修改后向群聊推送群聊信息变更事件，并在聊天记录中留下系统提示
+func (s *ServerLogic) UpdateGroup(ctx context.Context, request *proto.UpdateGroupRequest) (reply *proto.Group, err error) {
+	if request.GroupName == nil && request.Notice == nil && request.Avatar == nil {
+		return nil, errors.New("没有需要修改的群资料")
+	}
+	if request.GroupName != nil && request.GetGroupName() == "" {
+		return nil, errors.New("群名称不能为空")
+	}
+	if len([]rune(request.GetGroupName())) > maxGroupNameLength || len([]rune(request.GetNotice())) > maxGroupNoticeLength {
+		return nil, errors.New(fmt.Sprintf("群名称不能超过%d个字符，群公告不能超过%d个字符", maxGroupNameLength, maxGroupNoticeLength))
+	}
+	if len(request.GetAvatar()) > maxGroupAvatarLength {
+		return nil, errors.New("群头像地址过长")
+	}
+	if err = checkGroupAdmin(request.Userid, request.GroupId); err != nil {
+		return nil, err
+	}
+	var groupInfo db.TGroup
+	db.QueryGroupById(request.GroupId, &groupInfo)
+	if groupInfo.ID == 0 {
+		return nil, errors.New("群聊不存在或者已经解散")
+	}
+	var changes []string
+	updates := make(map[string]interface{})
+	if request.GroupName != nil && request.GetGroupName() != groupInfo.GroupName {
+		var oldGroup db.TGroup
+		db.QueryGroupByGroupName(request.GetGroupName(), &oldGroup)
+		if oldGroup.ID != 0 {
+			return nil, errors.New("该群聊名称已经被使用")
+		}
+		groupInfo.GroupName = request.GetGroupName()
+		updates["group_name"] = groupInfo.GroupName
+		changes = append(changes, fmt.Sprintf("将群名称修改为“%s”", groupInfo.GroupName))
+	}
+	if request.Notice != nil && request.GetNotice() != groupInfo.Notice {
+		groupInfo.Notice = request.GetNotice()
+		updates["notice"] = groupInfo.Notice
+		if groupInfo.Notice == "" {
+			changes = append(changes, "清空了群公告")
+		} else {
+			changes = append(changes, "更新了群公告")
+		}
+	}
+	if request.Avatar != nil && request.GetAvatar() != groupInfo.Avatar {
+		groupInfo.Avatar = request.GetAvatar()
+		updates["avatar"] = groupInfo.Avatar
+		changes = append(changes, "更换了群头像")
+	}
+	reply = &proto.Group{
+		Id:         groupInfo.ID,
+		Userid:     groupInfo.Userid,
+		GroupName:  groupInfo.GroupName,
+		Notice:     groupInfo.Notice,
+		Avatar:     groupInfo.Avatar,
+		JoinPolicy: int32(groupInfo.JoinPolicy),
+		CreateAt:   groupInfo.CreateAt.Format(time.RFC3339),
+	}
+	if len(changes) == 0 {
+		reply.UpdateAt = groupInfo.UpdateAt.Format(time.RFC3339)
+		return reply, nil
+	}
+	// 检查名称之后仍然可能与并发修改的群聊重名，由唯一索引保证
+	if err = db.UpdateGroup(groupInfo.ID, updates); err != nil {
+		if err == db.ErrGroupNameUsed {
+			return nil, errors.New("该群聊名称已经被使用")
+		}
+		return nil, errors.New("系统异常")
+	}
+	reply.UpdateAt = time.Now().Format(time.RFC3339)
+	// 删除群资料缓存之后推送的群聊信息才是修改后的内容
+	if err = common.RedisDelString(fmt.Sprintf(common.GroupProfile, groupInfo.ID)); err != nil {
+		return nil, errors.New("系统异常")
+	}
+	notifyGroupInfo(groupInfo.ID)
+	var operator db.TUser
+	db.QueryUserById(request.Userid, &operator)
+	pushGroupSystemMsg(groupInfo.ID, operator.Username+strings.Join(changes, "，"))
+	return reply, nil
+}
+
+// checkGroupAdmin 操作方必须是群主或者管理员
+func checkGroupAdmin(userid, groupId int64) error {
+	var relation db.TRelation
+	db.QueryGroupRelation(userid, groupId, &relation)
+	if relation.Role < db.GroupRoleAdmin {
+		return permissionDenied("只有群主和管理员可以进行该操作")
+	}
+	return nil
+}
+
+func (s *ServerLogic) SetJoinPolicy(ctx context.Context, request *proto.SetJoinPolicyRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if request.JoinPolicy < db.GroupJoinOpen || request.JoinPolicy > db.GroupJoinInvite {
+		err = errors.New("不支持的加入方式")
 		return
 	}
-	for k := range userMap {
-		id, _ := strconv.Atoi(k)
-		onlineUserIdList = append(onlineUserIdList, int64(id))
+	if err = checkGroupAdmin(request.Userid, request.GroupId); err != nil {
+		return
 	}
-	err = PushGroupInfo(user.ID, request.GroupId, count, userList, onlineUserIdList)
-	if err != nil {
+	if err = db.UpdateGroupJoinPolicy(request.GroupId, int(request.JoinPolicy)); err != nil {
 		err = errors.New("系统异常")
 		return
 	}
-	// 在redis中写入当前用户的加入的所有群聊id
-	b, _ := json.Marshal(groupIdList)
-	err = common.RedisSetString(fmt.Sprintf(common.UserGroupList, user.ID), b, 0)
-	if err != nil {
+	return reply, nil
+}
+
+const (
+	groupInviteExpire    = 24 * time.Hour     // 邀请链接默认的有效期
+	maxGroupInviteExpire = 7 * 24 * time.Hour // 邀请链接最长的有效期
+)
+
+func (s *ServerLogic) CreateGroupInvite(ctx context.Context, request *proto.CreateGroupInviteRequest) (reply *proto.CreateGroupInviteReply, err error) {
+	reply = new(proto.CreateGroupInviteReply)
+	expire := groupInviteExpire
+	if request.ExpireIn != 0 {
+		expire = time.Duration(request.ExpireIn) * time.Second
+	}
+	if expire <= 0 || expire > maxGroupInviteExpire {
+		err = errors.New("邀请链接的有效期最长为7天")
+		return
+	}
+	if err = checkGroupAdmin(request.Userid, request.GroupId); err != nil {
+		return
+	}
+	invite := &db.TGroupInvite{
+		GroupId:  request.GroupId,
+		Token:    utils.GetRandomToken(24),
+		Creator:  request.Userid,
+		ExpireAt: time.Now().Add(expire),
+	}
+	if err = db.CreateGroupInvite(invite); err != nil {
 		err = errors.New("系统异常")
 		return
 	}
-	return
+	reply.Token = invite.Token
+	reply.ExpireAt = invite.ExpireAt.Format(time.RFC3339)
+	return reply, nil
 }
 
-func (s *ServerLogic) AddFriend(ctx context.Context, request *proto.AddFriendRequest) (reply *empty.Empty, err error) {
-	reply = new(empty.Empty)
-	relation := &db.TRelation{
-		Type:    "friend",
-		ObjectA: request.Userid,
-		ObjectB: request.FriendId,
+// JoinGroupByInvite 持有有效邀请链接的用户可以直接加入群聊，不受群聊加入方式的限制，
+// 创建链接的管理员失去管理员身份后链接失效
+func (s *ServerLogic) JoinGroupByInvite(ctx context.Context, request *proto.JoinGroupByInviteRequest) (reply *proto.JoinGroupByInviteReply, err error) {
+	reply = new(proto.JoinGroupByInviteReply)
+	var invite db.TGroupInvite
+	db.QueryGroupInviteByToken(request.Token, &invite)
+	if invite.ID == 0 || checkGroupAdmin(invite.Creator, invite.GroupId) != nil {
+		err = errors.New("邀请链接不存在或者已经过期")
+		return
 	}
-	db.CreateRelation(relation)
-	if relation.ID == 0 {
-		err = errors.New("系统异常，添加群聊失败")
+	var groupInfo db.TGroup
+	db.QueryGroupById(invite.GroupId, &groupInfo)
+	if groupInfo.ID == 0 {
+		err = errors.New("群聊不存在或者已经解散")
 		return
 	}
-	return
+	var relation db.TRelation
+	db.QueryGroupRelation(request.Userid, invite.GroupId, &relation)
+	if relation.ID != 0 {
+		err = errors.New("你已经是该群聊的成员")
+		return
+	}
+	if err = joinGroup(request.Userid, &groupInfo); err != nil {
+		return
+	}
+	reply.GroupId = groupInfo.ID
+	return reply, nil
+}
+
+func (s *ServerLogic) ListJoinRequests(ctx context.Context, request *proto.ListJoinRequestsRequest) (reply *proto.ListJoinRequestsReply, err error) {
+	reply = new(proto.ListJoinRequestsReply)
+	if err = checkGroupAdmin(request.Userid, request.GroupId); err != nil {
+		return
+	}
+	var requestList []db.VGroupJoinRequest
+	db.QueryPendingGroupJoinRequests(request.GroupId, &requestList)
+	for _, r := range requestList {
+		reply.Requests = append(reply.Requests, &proto.GroupJoinRequest{
+			Id:       r.ID,
+			GroupId:  r.GroupId,
+			Userid:   r.Userid,
+			Username: r.Username,
+			Avatar:   r.Avatar,
+			Reason:   r.Reason,
+			CreateAt: r.CreateAt.Format(time.RFC3339),
+		})
+	}
+	return reply, nil
 }
 
-func (s *ServerLogic) Push(ctx context.Context, request *proto.PushRequest) (reply *empty.Empty, err error) {
+func (s *ServerLogic) RespondJoinRequest(ctx context.Context, request *proto.RespondJoinRequestRequest) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
-	payload := common.FriendMsg{
-		Userid:       request.Msg.Userid,
-		FriendId:     request.Msg.FriendId,
-		Content:      request.Msg.Content,
-		MessageType:  request.Msg.MessageType,
-		CreateAt:     request.Msg.CreateAt,
-		FriendName:   request.Msg.FriendName,
-		FromUsername: request.Msg.FromUsername,
-		Avatar:       request.Msg.Avatar,
-		Op:           common.OpFriendMsgSend,
-		Watermark:    request.Msg.Watermark,
+	var joinRequest db.TGroupJoinRequest
+	db.QueryGroupJoinRequestById(request.RequestId, &joinRequest)
+	if joinRequest.ID == 0 {
+		err = errors.New("入群申请不存在")
+		return
 	}
-	// 由于采用写扩散的机制，所以要同时往发送和接收方的topic中写入消息
-	payload.Belong = request.Msg.FriendId
-	err = Push(request.Msg.FriendId, payload, common.OpFriendMsgSend)
-	if err != nil {
-		err = errors.New("系统异常")
+	if err = checkGroupAdmin(request.Userid, joinRequest.GroupId); err != nil {
 		return
 	}
-	payload.Belong = request.Msg.Userid
-	err = Push(request.Msg.Userid, payload, common.OpFriendMsgSend)
+	var groupInfo db.TGroup
+	db.QueryGroupById(joinRequest.GroupId, &groupInfo)
+	if groupInfo.ID == 0 {
+		err = errors.New("群聊不存在或者已经解散")
+		return
+	}
+	ok, err := db.RespondGroupJoinRequest(&joinRequest, request.Userid, request.Approve)
 	if err != nil {
 		err = errors.New("系统异常")
 		return
 	}
+	if !ok {
+		err = errors.New("入群申请已经被处理")
+		return
+	}
+	joinRequest.State = db.GroupJoinRejected
+	if request.Approve {
+		joinRequest.State = db.GroupJoinApproved
+		if err = joinGroup(joinRequest.Userid, &groupInfo); err != nil {
+			return
+		}
+	}
+	notifyGroupJoinRequest(&joinRequest, &groupInfo, joinRequest.Userid)
 	return reply, nil
 }
 
-func (s *ServerLogic) PushRoom(ctx context.Context, request *proto.PushRoomRequest) (reply *empty.Empty, err error) {
+// AddFriend 好友关系需要对方同意后才会建立，这里只发送不带招呼语的好友申请
+func (s *ServerLogic) AddFriend(ctx context.Context, request *proto.AddFriendRequest) (reply *empty.Empty, err error) {
+	return s.SendFriendRequest(ctx, &proto.SendFriendRequestReq{
+		Userid:   request.Userid,
+		FriendId: request.FriendId,
+	})
+}
+
+const (
+	friendRequestExpire     = 7 * 24 * time.Hour // 好友申请的有效期
+	maxFriendGreetingLength = 128                // 招呼语的最大长度，与t_friend_request.greeting的长度一致
+)
+
+func (s *ServerLogic) SendFriendRequest(ctx context.Context, request *proto.SendFriendRequestReq) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
-	payload := common.GroupMsg{
-		Userid:       request.Msg.Userid,
-		GroupId:      request.Msg.GroupId,
-		Content:      request.Msg.Content,
-		MessageType:  request.Msg.MessageType,
-		CreateAt:     request.Msg.CreateAt,
-		GroupName:    request.Msg.GroupName,
-		FromUsername: request.Msg.FromUsername,
-		Avatar:       request.Msg.Avatar,
-		Op:           common.OpGroupMsgSend,
-		Watermark:    request.Msg.Watermark,
+	if request.Userid == request.FriendId {
+		err = errors.New("不能添加自己为好友")
+		return
 	}
-	err = Push(request.Msg.GroupId, payload, common.OpGroupMsgSend)
-	if err != nil {
+	if len([]rune(request.Greeting)) > maxFriendGreetingLength {
+		err = errors.New(fmt.Sprintf("招呼语不能超过%d个字符", maxFriendGreetingLength))
+		return
+	}
+	var friend db.TUser
+	db.QueryUserById(request.FriendId, &friend)
+	if friend.ID == 0 {
+		err = errors.New("用户不存在")
+		return
+	}
+	if isFriend(request.Userid, request.FriendId) {
+		err = errors.New("你们已经是好友了")
+		return
+	}
+	if db.IsBlocked(request.Userid, request.FriendId) {
+		err = errors.New("无法添加该用户为好友")
+		return
+	}
+	// 对方也向自己发送了好友申请时，直接同意对方的申请
+	var reverse db.TFriendRequest
+	db.QueryPendingFriendRequest(request.FriendId, request.Userid, &reverse)
+	if reverse.ID != 0 {
+		return reply, respondFriendRequest(&reverse, true)
+	}
+	var pending db.TFriendRequest
+	db.QueryPendingFriendRequest(request.Userid, request.FriendId, &pending)
+	if pending.ID != 0 {
+		err = errors.New("已经发送过好友申请，请等待对方处理")
+		return
+	}
+	friendRequest := &db.TFriendRequest{
+		FromA:    request.Userid,
+		ToB:      request.FriendId,
+		Greeting: request.Greeting,
+		State:    db.FriendRequestPending,
+		ExpireAt: time.Now().Add(friendRequestExpire),
+	}
+	if err = db.CreateFriendRequest(friendRequest); err != nil {
 		err = errors.New("系统异常")
 		return
 	}
+	notifyFriendRequest(friendRequest, friendRequest.ToB)
 	return reply, nil
 }
 
-func (s *ServerLogic) PushRoomCount(ctx context.Context, request *proto.PushRoomCountRequest) (reply *empty.Empty, err error) {
+func (s *ServerLogic) RespondFriendRequest(ctx context.Context, request *proto.RespondFriendRequestReq) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
-	countStr, _ := common.RedisHGet(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId))
-	count, _ := strconv.Atoi(countStr)
-	// TODO:往该群聊的的topic中生产消息
-	err = PushGroupCount(request.GroupId, count)
-	if err != nil {
-		err = errors.New("系统异常")
+	var friendRequest db.TFriendRequest
+	db.QueryFriendRequestById(request.RequestId, &friendRequest)
+	if friendRequest.ID == 0 || friendRequest.ToB != request.Userid {
+		err = errors.New("好友申请不存在")
 		return
 	}
+	err = respondFriendRequest(&friendRequest, request.Accept)
+	return reply, err
+}
+
+// respondFriendRequest 处理待处理的好友申请，同意后刷新双方的好友列表缓存，并通知申请方处理结果
+func respondFriendRequest(friendRequest *db.TFriendRequest, accept bool) error {
+	if friendRequest.State == db.FriendRequestPending && !friendRequest.ExpireAt.After(time.Now()) {
+		db.ExpireFriendRequests(friendRequest.ToB)
+		return errors.New("好友申请已经过期")
+	}
+	if accept && db.IsBlocked(friendRequest.FromA, friendRequest.ToB) {
+		return errors.New("无法添加该用户为好友")
+	}
+	ok, err := db.RespondFriendRequest(friendRequest, accept)
+	if err != nil {
+		return errors.New("系统异常")
+	}
+	if !ok {
+		return errors.New("好友申请已经被处理或者已经过期")
+	}
+	friendRequest.State = db.FriendRequestRejected
+	if accept {
+		friendRequest.State = db.FriendRequestAccepted
+		for _, userid := range []int64{friendRequest.FromA, friendRequest.ToB} {
+			if err = refreshUserFriendList(userid); err != nil {
+				return errors.New("系统异常")
+			}
+		}
+		createConversations(
+			db.TConversation{Userid: friendRequest.FromA, Type: "friend", ObjectId: friendRequest.ToB},
+			db.TConversation{Userid: friendRequest.ToB, Type: "friend", ObjectId: friendRequest.FromA},
+		)
+	}
+	notifyFriendRequest(friendRequest, friendRequest.FromA)
+	return nil
+}
+
+// notifyFriendRequest 通知好友申请的接收方有新的申请，或者通知申请方申请已被处理，推送失败时只记录日志
+func notifyFriendRequest(friendRequest *db.TFriendRequest, belong int64) {
+	var from db.TUser
+	db.QueryUserById(friendRequest.FromA, &from)
+	err := PushFriendRequest(common.FriendRequestMsg{
+		RequestId:    friendRequest.ID,
+		FromUserid:   friendRequest.FromA,
+		FromUsername: from.Username,
+		FromAvatar:   from.Avatar,
+		ToUserid:     friendRequest.ToB,
+		Greeting:     friendRequest.Greeting,
+		State:        friendRequest.State,
+		Belong:       belong,
+	})
+	if err != nil {
+		zlog.Error(fmt.Sprintf("push friend request id=%d err:%v", friendRequest.ID, err))
+	}
+}
+
+func (s *ServerLogic) ListFriendRequests(ctx context.Context, request *proto.ListFriendRequestsReq) (reply *proto.ListFriendRequestsReply, err error) {
+	reply = new(proto.ListFriendRequestsReply)
+	db.ExpireFriendRequests(request.Userid)
+	var requestList []db.VFriendRequest
+	db.QueryFriendRequests(request.Userid, &requestList)
+	for _, r := range requestList {
+		item := &proto.FriendRequest{
+			Id:           r.ID,
+			FromUserid:   r.FromA,
+			FromUsername: r.FromUsername,
+			FromAvatar:   r.FromAvatar,
+			ToUserid:     r.ToB,
+			ToUsername:   r.ToUsername,
+			ToAvatar:     r.ToAvatar,
+			Greeting:     r.Greeting,
+			State:        int32(r.State),
+			CreateAt:     r.CreateAt.Format(time.RFC3339),
+			ExpireAt:     r.ExpireAt.Format(time.RFC3339),
+		}
+		if r.ToB == request.Userid {
+			reply.Received = append(reply.Received, item)
+		} else {
+			reply.Sent = append(reply.Sent, item)
+		}
+	}
 	return reply, nil
 }
 
-func (s *ServerLogic) PushRoomInfo(ctx context.Context, request *proto.PushRoomInfoRequest) (reply *empty.Empty, err error) {
+func (s *ServerLogic) RemoveFriend(ctx context.Context, request *proto.RemoveFriendRequest) (reply *empty.Empty, err error) {
 	reply = new(empty.Empty)
-	var userList []db.TUser
-	db.QueryGroupAllUser(request.GroupId, &userList)
-	countStr, _ := common.RedisHGet(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId))
-	count, _ := strconv.Atoi(countStr)
-	var onlineUserIdList []int64
-	// 获取当前群聊的所有在线用户的id
-	var userMap map[string]string
-	userMap, err = common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, request.GroupId))
+	ok, err := db.DeleteFriendRelation(request.Userid, request.FriendId)
 	if err != nil {
 		err = errors.New("系统异常")
 		return
 	}
-	for k := range userMap {
-		id, _ := strconv.Atoi(k)
-		onlineUserIdList = append(onlineUserIdList, int64(id))
+	if !ok {
+		err = errors.New("对方不是你的好友")
+		return
 	}
-	// TODO:往该群聊的的topic中生产消息
-	err = PushGroupInfo(0, request.GroupId, count, userList, onlineUserIdList)
-	if err != nil {
+	for _, userid := range []int64{request.Userid, request.FriendId} {
+		if err = refreshUserFriendList(userid); err != nil {
+			err = errors.New("系统异常")
+			return
+		}
+	}
+	// 删除好友后双方的会话列表中不再展示该会话
+	for _, pair := range [][2]int64{{request.Userid, request.FriendId}, {request.FriendId, request.Userid}} {
+		if err = db.DeleteConversation(pair[0], "friend", pair[1]); err != nil {
+			err = errors.New("系统异常")
+			return
+		}
+	}
+	// 消息存活时间记录在好友关系中，重新添加好友后不再沿用
+	if err = common.RedisDelString(fmt.Sprintf(common.ConversationTTL, common.ConversationId("friend", request.Userid, request.FriendId))); err != nil {
 		err = errors.New("系统异常")
 		return
 	}
 	return reply, nil
 }
+
+// BlockUser 拉黑用户，拉黑后双方不能互发私聊消息、不能再发送好友申请，也不再收到对方的上下线通知，
+// 被拉黑的好友仍然保留好友关系，移出黑名单后恢复
+func (s *ServerLogic) BlockUser(ctx context.Context, request *proto.BlockUserRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if request.Userid == request.BlockId {
+		err = errors.New("不能拉黑自己")
+		return
+	}
+	var user db.TUser
+	db.QueryUserById(request.BlockId, &user)
+	if user.ID == 0 {
+		err = errors.New("用户不存在")
+		return
+	}
+	// 重复或者并发拉黑同一用户时只保留一条拉黑记录
+	if err = db.CreateBlockRelation(request.Userid, request.BlockId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, refreshBlockFriendList(request.Userid, request.BlockId)
+}
+
+func (s *ServerLogic) UnblockUser(ctx context.Context, request *proto.BlockUserRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if err = db.DeleteBlockRelation(request.Userid, request.BlockId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, refreshBlockFriendList(request.Userid, request.BlockId)
+}
+
+// refreshBlockFriendList 拉黑关系变化后，双方是好友时刷新双方的好友列表缓存
+func refreshBlockFriendList(userid, blockId int64) error {
+	if !isFriend(userid, blockId) {
+		return nil
+	}
+	for _, id := range []int64{userid, blockId} {
+		if err := refreshUserFriendList(id); err != nil {
+			return errors.New("系统异常")
+		}
+	}
+	return nil
+}
+
+func isFriend(userid, friendId int64) bool {
+	for _, id := range db.QueryUserAllFriendId(userid) {
+		if id == friendId {
+			return true
+		}
+	}
+	return false
+}
+
+// queryVisibleFriendIds 查询用户的好友id，不包括与用户存在拉黑关系的好友，上下线通知只发送给这些好友
+func queryVisibleFriendIds(userid int64) []int64 {
+	blockMap := make(map[int64]struct{})
+	for _, id := range db.QueryUserAllBlockId(userid) {
+		blockMap[id] = struct{}{}
+	}
+	friendIdList := make([]int64, 0)
+	for _, id := range db.QueryUserAllFriendId(userid) {
+		if _, ok := blockMap[id]; !ok {
+			friendIdList = append(friendIdList, id)
+		}
+	}
+	return friendIdList
+}
+
+// refreshUserFriendList 好友关系或者拉黑关系变化后，刷新redis中用户的好友列表缓存
+func refreshUserFriendList(userid int64) error {
+	b, _ := json.Marshal(queryVisibleFriendIds(userid))
+	return common.RedisSetString(fmt.Sprintf(common.UserFriendList, userid), b, 0)
+}
+
+// 用户和群聊展示信息缓存的有效期，信息更新时会主动删除缓存
+const profileCacheExpire = 30 * time.Minute
+
+// queryUserProfile 获取用户的用户名和头像，优先从缓存中读取
+func queryUserProfile(userid int64) (user *db.TUser, err error) {
+	c := &common.CacheOptions{
+		Key:      fmt.Sprintf(common.UserProfile, userid),
+		Duration: profileCacheExpire,
+		Fun: func() (interface{}, error) {
+			var u db.TUser
+			db.QueryUserById(userid, &u)
+			if u.ID == 0 {
+				return nil, nil
+			}
+			return &db.TUser{ID: u.ID, Username: u.Username, Avatar: u.Avatar}, nil
+		},
+		Receiver: new(db.TUser),
+	}
+	res, err := c.GetSet()
+	if err != nil {
+		return nil, errors.New("系统异常")
+	}
+	user, _ = res.(*db.TUser)
+	if user == nil || user.ID == 0 {
+		return nil, status.Error(codes.NotFound, "用户不存在")
+	}
+	return user, nil
+}
+
+// queryGroupProfile 获取群聊的群名称、群公告和群头像，优先从缓存中读取
+func queryGroupProfile(groupId int64) (group *db.TGroup, err error) {
+	c := &common.CacheOptions{
+		Key:      fmt.Sprintf(common.GroupProfile, groupId),
+		Duration: profileCacheExpire,
+		Fun: func() (interface{}, error) {
+			var g db.TGroup
+			db.QueryGroupById(groupId, &g)
+			if g.ID == 0 {
+				return nil, nil
+			}
+			return &db.TGroup{ID: g.ID, GroupName: g.GroupName, Notice: g.Notice, Avatar: g.Avatar}, nil
+		},
+		Receiver: new(db.TGroup),
+	}
+	res, err := c.GetSet()
+	if err != nil {
+		return nil, errors.New("系统异常")
+	}
+	group, _ = res.(*db.TGroup)
+	if group == nil || group.ID == 0 {
+		return nil, status.Error(codes.NotFound, "群聊不存在")
+	}
+	return group, nil
+}
+
+// permissionDenied 越权操作的错误，如向非好友发送消息、非群成员或者被禁言的成员在群聊中发言，api层据此返回CodeForbidden
+func permissionDenied(msg string) error {
+	return status.Error(codes.PermissionDenied, msg)
+}
+
+func (s *ServerLogic) Push(ctx context.Context, request *proto.PushRequest) (reply *proto.SendReply, err error) {
+	payload := common.FriendMsg{
+		Userid:      request.Msg.Userid,
+		FriendId:    request.Msg.FriendId,
+		Content:     request.Msg.Content,
+		MessageType: request.Msg.MessageType,
+		Op:          common.OpFriendMsgSend,
+		Watermark:   request.Msg.Watermark,
+		ReplyTo:     request.Msg.ReplyTo,
+	}
+	if !isFriend(request.Msg.Userid, request.Msg.FriendId) {
+		return nil, permissionDenied("对方不是你的好友")
+	}
+	if db.IsBlocked(request.Msg.Userid, request.Msg.FriendId) {
+		return nil, permissionDenied("你们之间存在拉黑关系，无法发送消息")
+	}
+	// 发送方和接收方的展示信息以服务端为准，不信任客户端提交的内容
+	sender, err := queryUserProfile(request.Msg.Userid)
+	if err != nil {
+		return nil, err
+	}
+	friend, err := queryUserProfile(request.Msg.FriendId)
+	if err != nil {
+		return nil, err
+	}
+	payload.FromUsername, payload.Avatar, payload.FriendName = sender.Username, sender.Avatar, friend.Username
+	if !checkReplyTo("friend", request.Msg.Userid, request.Msg.FriendId, request.Msg.ReplyTo) {
+		return nil, errors.New("引用的消息不存在")
+	}
+	ttl, err := queryMessageTTL("friend", request.Msg.Userid, request.Msg.FriendId)
+	if err != nil {
+		return nil, err
+	}
+	return SendChatMsg("friend", request.Msg.Userid, request.Msg.FriendId, request.Msg.Watermark, func(record sentRecord) error {
+		// 发送方和接收方信箱中的消息使用同一个snowId，持久化时根据(belong, snowId)去重
+		payload.SnowId, payload.Seq, payload.CreateAt = record.SnowId, record.Seq, record.CreateAt
+		payload.ExpireAt = messageExpireAt(record.CreateAt, ttl)
+		// 由于采用写扩散的机制，所以要同时往发送和接收方的topic中写入消息
+		payload.Belong = request.Msg.FriendId
+		if err := Push(request.Msg.FriendId, payload, common.OpFriendMsgSend); err != nil {
+			return err
+		}
+		payload.Belong = request.Msg.Userid
+		if err := Push(request.Msg.Userid, payload, common.OpFriendMsgSend); err != nil {
+			return err
+		}
+		cacheRecentMsg("friend", request.Msg.Userid, request.Msg.FriendId, payload.MessageType, record, payload.ExpireAt)
+		incrUnread("friend", request.Msg.Userid, request.Msg.FriendId, []int64{request.Msg.FriendId})
+		updateConversations("friend", request.Msg.Userid, request.Msg.FriendId, payload.Content, payload.MessageType, record,
+			[]int64{request.Msg.Userid, request.Msg.FriendId})
+		return nil
+	})
+}
+
+func (s *ServerLogic) PushRoom(ctx context.Context, request *proto.PushRoomRequest) (reply *proto.SendReply, err error) {
+	payload := common.GroupMsg{
+		Userid:      request.Msg.Userid,
+		GroupId:     request.Msg.GroupId,
+		Content:     request.Msg.Content,
+		MessageType: request.Msg.MessageType,
+		Op:          common.OpGroupMsgSend,
+		Watermark:   request.Msg.Watermark,
+		ReplyTo:     request.Msg.ReplyTo,
+	}
+	if err = checkGroupSpeak(request.Msg.Userid, request.Msg.GroupId); err != nil {
+		return nil, err
+	}
+	sender, err := queryUserProfile(request.Msg.Userid)
+	if err != nil {
+		return nil, err
+	}
+	group, err := queryGroupProfile(request.Msg.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	payload.FromUsername, payload.Avatar, payload.GroupName = sender.Username, sender.Avatar, group.GroupName
+	if !checkReplyTo("group", request.Msg.Userid, request.Msg.GroupId, request.Msg.ReplyTo) {
+		return nil, errors.New("引用的消息不存在")
+	}
+	notifyList, err := checkMentions(&payload, request.Msg.Mentions, request.Msg.MentionAll)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := queryMessageTTL("group", request.Msg.Userid, request.Msg.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return SendChatMsg("group", request.Msg.Userid, request.Msg.GroupId, request.Msg.Watermark, func(record sentRecord) error {
+		payload.SnowId, payload.Seq, payload.CreateAt = record.SnowId, record.Seq, record.CreateAt
+		payload.ExpireAt = messageExpireAt(record.CreateAt, ttl)
+		if err := Push(request.Msg.GroupId, payload, common.OpGroupMsgSend); err != nil {
+			return err
+		}
+		cacheRecentMsg("group", request.Msg.Userid, request.Msg.GroupId, payload.MessageType, record, payload.ExpireAt)
+		saveMentions(payload, notifyList)
+		members := queryGroupMemberIds(request.Msg.GroupId)
+		incrGroupUnread(request.Msg.Userid, request.Msg.GroupId, members)
+		updateConversations("group", request.Msg.Userid, request.Msg.GroupId, payload.Content, payload.MessageType, record, members)
+		return nil
+	})
+}
+
+// incrUnread 消息生产成功后增加接收方在该会话中的未读数，更新失败时只记录日志，不影响消息的发送
+func incrUnread(_type string, userid, objectId int64, receivers []int64) {
+	field := common.ConversationId(_type, userid, objectId)
+	for _, id := range receivers {
+		if err := common.RedisHINCRBY(fmt.Sprintf(common.UserUnread, id), field, 1); err != nil {
+			zlog.Error(fmt.Sprintf("incr unread userid=%d conversation=%s err:%v", id, field, err))
+		}
+	}
+}
+
+// incrGroupUnread 增加群聊中除发送方以外所有成员的未读数
+func incrGroupUnread(userid, groupId int64, members []int64) {
+	receivers := make([]int64, 0, len(members))
+	for _, id := range members {
+		if id != userid {
+			receivers = append(receivers, id)
+		}
+	}
+	incrUnread("group", userid, groupId, receivers)
+}
+
+// queryGroupMemberIds 查询群聊所有成员的id
+func queryGroupMemberIds(groupId int64) []int64 {
+	var relationList []db.TRelation
+	db.QueryGroupRelations(groupId, &relationList)
+	members := make([]int64, 0, len(relationList))
+	for _, r := range relationList {
+		members = append(members, r.ObjectA)
+	}
+	return members
+}
+
+// updateConversations 消息生产成功后更新owners各自会话中的最后一条消息，私聊中双方的会话对象互为对方，
+// 更新失败时只记录日志，不影响消息的发送
+func updateConversations(_type string, userid, objectId int64, content, messageType string, record sentRecord, owners []int64) {
+	lastAt, _ := time.Parse(time.RFC3339, record.CreateAt)
+	list := make([]db.TConversation, 0, len(owners))
+	for _, owner := range owners {
+		conversation := db.TConversation{
+			Userid:          owner,
+			Type:            _type,
+			ObjectId:        objectId,
+			LastSnowID:      record.SnowId,
+			LastFromId:      userid,
+			LastContent:     truncateContent(content),
+			LastMessageType: messageType,
+			LastAt:          lastAt,
+		}
+		if _type == "friend" && owner == objectId {
+			conversation.ObjectId = userid
+		}
+		list = append(list, conversation)
+	}
+	if err := db.UpsertConversations(list); err != nil {
+		zlog.Error(fmt.Sprintf("update conversations snowId=%s err:%v", record.SnowId, err))
+	}
+}
+
+// createConversations 建立好友关系或者加入群聊后创建会话，使还没有消息的会话也出现在会话列表中，创建失败时只记录日志
+func createConversations(list ...db.TConversation) {
+	now := time.Now()
+	for i := range list {
+		list[i].LastAt = now
+	}
+	if err := db.CreateConversations(list); err != nil {
+		zlog.Error(fmt.Sprintf("create conversations err:%v", err))
+	}
+}
+
+// MarkRead 将会话的未读数清零，并把未读数的变化推送给用户，使用户的其他设备同步未读数
+func (s *ServerLogic) MarkRead(ctx context.Context, request *proto.MarkReadRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if request.Type != "friend" && request.Type != "group" {
+		err = errors.New("不支持的会话类型")
+		return
+	}
+	key := fmt.Sprintf(common.UserUnread, request.Userid)
+	field := common.ConversationId(request.Type, request.Userid, request.ObjectId)
+	unreadStr, err := common.RedisHGet(key, field)
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	unread, _ := strconv.ParseInt(unreadStr, 10, 64)
+	if unread == 0 {
+		return reply, nil
+	}
+	// 减去读取到的未读数而不是直接删除，避免丢失在此期间新增的未读数
+	if err = common.RedisHINCRBY(key, field, -unread); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	err = PushUnread(common.UnreadMsg{
+		Type:     request.Type,
+		ObjectId: request.ObjectId,
+		Unread:   0,
+		Delta:    -unread,
+		Belong:   request.Userid,
+	})
+	if err != nil {
+		zlog.Error(fmt.Sprintf("push unread userid=%d conversation=%s err:%v", request.Userid, field, err))
+	}
+	return reply, nil
+}
+
+// 会话列表每页的默认数目和最大数目
+const (
+	defaultConversationLimit = 20
+	maxConversationLimit     = 100
+)
+
+// ListConversations 按最后一条消息的时间倒序分页获取会话列表，游标为上一页最后一个会话的(lastAt, id)，
+// 分页期间有新消息的会话会移动到第一页，不会在后续的页中重复出现
+func (s *ServerLogic) ListConversations(ctx context.Context, request *proto.ListConversationsRequest) (reply *proto.ListConversationsReply, err error) {
+	reply = new(proto.ListConversationsReply)
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = defaultConversationLimit
+	}
+	if limit > maxConversationLimit {
+		limit = maxConversationLimit
+	}
+	var (
+		lastAt time.Time
+		lastId int64
+	)
+	if request.Cursor != "" {
+		if lastAt, lastId, err = parseConversationCursor(request.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	var list []db.VConversation
+	// 多查询一条用于判断是否还有下一页
+	if err = db.QueryConversations(request.Userid, lastAt, lastId, limit+1, &list); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if len(list) > limit {
+		reply.HasMore = true
+		list = list[:limit]
+	}
+	unreadMap, err := common.RedisHGetAll(fmt.Sprintf(common.UserUnread, request.Userid))
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	for _, c := range list {
+		conversation := &proto.Conversation{
+			Type:            c.Type,
+			ObjectId:        c.ObjectId,
+			Name:            c.Name,
+			Avatar:          c.Avatar,
+			LastSnowId:      c.LastSnowId,
+			LastFromId:      c.LastFromId,
+			LastFromName:    c.LastFromName,
+			LastContent:     c.LastContent,
+			LastMessageType: c.LastMessageType,
+			LastRecalled:    c.LastRecalled,
+			LastAt:          c.LastAt.Format(time.RFC3339),
+			Muted:           c.Muted,
+			MessageTtl:      c.MessageTTL,
+		}
+		conversation.Unread, _ = strconv.ParseInt(unreadMap[common.ConversationId(c.Type, request.Userid, c.ObjectId)], 10, 64)
+		reply.Conversations = append(reply.Conversations, conversation)
+	}
+	if reply.HasMore {
+		last := list[len(list)-1]
+		reply.NextCursor = fmt.Sprintf("%d_%d", last.LastAt.Unix(), last.ID)
+	}
+	return reply, nil
+}
+
+// parseConversationCursor 解析ListConversations返回的nextCursor
+func parseConversationCursor(cursor string) (lastAt time.Time, id int64, err error) {
+	parts := strings.Split(cursor, "_")
+	if len(parts) == 2 {
+		sec, e1 := strconv.ParseInt(parts[0], 10, 64)
+		n, e2 := strconv.ParseInt(parts[1], 10, 64)
+		if e1 == nil && e2 == nil && n > 0 {
+			return time.Unix(sec, 0), n, nil
+		}
+	}
+	return lastAt, 0, errors.New("无效的分页游标")
+}
+
+// 聊天记录检索每页的默认结果数和最大结果数，以及最多同时匹配的关键词数
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxSearchKeywords  = 5
+)
+
+// SearchMessages 在用户自己的私聊信箱以及当前加入的群聊中检索消息，游标为上一页最后一条结果的snowId
+func (s *ServerLogic) SearchMessages(ctx context.Context, request *proto.SearchMessagesRequest) (reply *proto.SearchMessagesReply, err error) {
+	reply = new(proto.SearchMessagesReply)
+	terms := db.SplitSearchKeyword(request.Keyword)
+	if len(terms) == 0 {
+		return nil, errors.New("请输入搜索关键词")
+	}
+	if len(terms) > maxSearchKeywords {
+		return nil, errors.New(fmt.Sprintf("最多同时搜索%d个关键词", maxSearchKeywords))
+	}
+	for _, term := range terms {
+		if len([]rune(term)) < db.MinSearchKeywordLength {
+			return nil, errors.New(fmt.Sprintf("每个关键词至少需要%d个字符", db.MinSearchKeywordLength))
+		}
+	}
+	query := &db.MessageSearchQuery{
+		Userid:      request.Userid,
+		Keyword:     request.Keyword,
+		Type:        request.Type,
+		ObjectId:    request.ObjectId,
+		FromId:      request.FromId,
+		MessageType: request.MessageType,
+		Before:      request.Cursor,
+		Limit:       int(request.Limit),
+	}
+	switch request.Type {
+	case "":
+		query.GroupIds = db.QueryUserAllGroupId(request.Userid)
+	case "friend":
+		// 私聊只检索用户自己的信箱，删除好友之后仍然可以检索之前的聊天记录
+	case "group":
+		var relation db.TRelation
+		db.QueryGroupRelation(request.Userid, request.ObjectId, &relation)
+		if relation.ID == 0 {
+			return nil, permissionDenied("你不在该群聊中")
+		}
+	default:
+		return nil, errors.New("不支持的会话类型")
+	}
+	if request.Cursor != "" {
+		if _, e := strconv.ParseInt(request.Cursor, 10, 64); e != nil {
+			return nil, errors.New("无效的分页游标")
+		}
+	}
+	if request.Start != "" {
+		if query.Start, err = time.Parse(time.RFC3339, request.Start); err != nil {
+			return nil, errors.New("开始时间的格式错误")
+		}
+	}
+	if request.End != "" {
+		if query.End, err = time.Parse(time.RFC3339, request.End); err != nil {
+			return nil, errors.New("结束时间的格式错误")
+		}
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	var results []db.VMessageSearchResult
+	if reply.HasMore, err = db.GetMessageSearcher().Search(query, &results); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	for _, val := range results {
+		result := &proto.SearchResult{
+			SnowId:       val.SnowId,
+			Type:         val.Type,
+			ObjectId:     val.ObjectId,
+			ObjectName:   val.ObjectName,
+			FromId:       val.FromId,
+			FromUsername: val.FromUsername,
+			Avatar:       val.Avatar,
+			Content:      val.Content,
+			MessageType:  val.MessageType,
+			Seq:          val.Seq,
+			CreateAt:     val.CreateAt.Format(time.RFC3339),
+		}
+		for _, h := range val.Highlights {
+			result.Highlights = append(result.Highlights, &proto.HighlightRange{Start: int32(h[0]), End: int32(h[1])})
+		}
+		reply.Results = append(reply.Results, result)
+	}
+	if reply.HasMore {
+		reply.NextCursor = results[len(results)-1].SnowId
+	}
+	return reply, nil
+}
+
+// SetConversationMute 设置会话的消息免打扰，只能设置自己的好友或者所在的群聊
+func (s *ServerLogic) SetConversationMute(ctx context.Context, request *proto.SetConversationMuteRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	switch request.Type {
+	case "friend":
+		if !isFriend(request.Userid, request.ObjectId) {
+			err = errors.New("对方不是你的好友")
+			return
+		}
+	case "group":
+		var relation db.TRelation
+		db.QueryGroupRelation(request.Userid, request.ObjectId, &relation)
+		if relation.ID == 0 {
+			err = errors.New("你不在该群聊中")
+			return
+		}
+	default:
+		err = errors.New("不支持的会话类型")
+		return
+	}
+	err = db.SetConversationMute(&db.TConversation{
+		Userid:   request.Userid,
+		Type:     request.Type,
+		ObjectId: request.ObjectId,
+		LastAt:   time.Now(),
+		Muted:    request.Muted,
+	})
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+// 会话消息存活时间的范围，单位秒
+const (
+	minMessageTTL = 60
+	maxMessageTTL = 30 * 24 * 3600
+)
+
+// SetMessageTTL 设置会话的消息存活时间，只影响之后发送的消息，私聊双方都可以设置，群聊只有群主和管理员可以设置
+func (s *ServerLogic) SetMessageTTL(ctx context.Context, request *proto.SetMessageTTLRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if request.Ttl != 0 && (request.Ttl < minMessageTTL || request.Ttl > maxMessageTTL) {
+		err = errors.New(fmt.Sprintf("消息存活时间必须在%s到%s之间", describeMessageTTL(minMessageTTL), describeMessageTTL(maxMessageTTL)))
+		return
+	}
+	switch request.Type {
+	case "friend":
+		if !isFriend(request.Userid, request.ObjectId) {
+			err = permissionDenied("对方不是你的好友")
+			return
+		}
+		err = db.UpdateFriendMessageTTL(request.Userid, request.ObjectId, request.Ttl)
+	case "group":
+		if err = checkGroupAdmin(request.Userid, request.ObjectId); err != nil {
+			return
+		}
+		err = db.UpdateGroupMessageTTL(request.ObjectId, request.Ttl)
+	default:
+		err = errors.New("不支持的会话类型")
+		return
+	}
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	// 删除缓存之后发送的消息才会使用新的存活时间
+	key := fmt.Sprintf(common.ConversationTTL, common.ConversationId(request.Type, request.Userid, request.ObjectId))
+	if err = common.RedisDelString(key); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if request.Type == "group" {
+		var operator db.TUser
+		db.QueryUserById(request.Userid, &operator)
+		if request.Ttl == 0 {
+			pushGroupSystemMsg(request.ObjectId, fmt.Sprintf("%s关闭了消息定时删除", operator.Username))
+		} else {
+			pushGroupSystemMsg(request.ObjectId, fmt.Sprintf("%s将消息设置为%s后自动删除", operator.Username, describeMessageTTL(request.Ttl)))
+		}
+	}
+	return reply, nil
+}
+
+// describeMessageTTL 把消息存活时间转换为便于阅读的描述
+func describeMessageTTL(ttl int64) string {
+	switch {
+	case ttl%(24*3600) == 0:
+		return fmt.Sprintf("%d天", ttl/(24*3600))
+	case ttl%3600 == 0:
+		return fmt.Sprintf("%d小时", ttl/3600)
+	case ttl%60 == 0:
+		return fmt.Sprintf("%d分钟", ttl/60)
+	}
+	return fmt.Sprintf("%d秒", ttl)
+}
+
+// checkMentions 被@提及的用户必须是群聊成员，@所有人只有群主和管理员可以使用，校验通过后把去重的提及列表写入payload，
+// 返回需要提醒的成员（不包括发送方自己）
+func checkMentions(payload *common.GroupMsg, mentions []int64, mentionAll bool) (notifyList []int64, err error) {
+	if len(mentions) == 0 && !mentionAll {
+		return nil, nil
+	}
+	if mentionAll {
+		var relation db.TRelation
+		db.QueryGroupRelation(payload.Userid, payload.GroupId, &relation)
+		if relation.Role < db.GroupRoleAdmin {
+			return nil, errors.New("只有群主和管理员可以@所有人")
+		}
+	}
+	var userList []db.TUser
+	db.QueryGroupAllUser(payload.GroupId, &userList)
+	memberMap := make(map[int64]struct{}, len(userList))
+	for _, user := range userList {
+		memberMap[user.ID] = struct{}{}
+	}
+	mentionMap := make(map[int64]struct{}, len(mentions))
+	for _, id := range mentions {
+		if _, ok := memberMap[id]; !ok {
+			return nil, errors.New("被@的用户不在该群聊中")
+		}
+		if _, ok := mentionMap[id]; ok {
+			continue
+		}
+		mentionMap[id] = struct{}{}
+		payload.Mentions = append(payload.Mentions, id)
+	}
+	payload.MentionAll = mentionAll
+	if mentionAll {
+		mentionMap = memberMap
+	}
+	for id := range mentionMap {
+		if id != payload.Userid {
+			notifyList = append(notifyList, id)
+		}
+	}
+	return notifyList, nil
+}
+
+// saveMentions 为被提及的成员保存@提及记录，消息已经写入topic，保存失败时只记录日志不影响发送结果
+func saveMentions(payload common.GroupMsg, notifyList []int64) {
+	content := truncateContent(payload.Content)
+	if payload.MessageType == "image" {
+		content = "[图片]"
+	}
+	mentions := make([]db.TMessageMention, 0, len(notifyList))
+	for _, id := range notifyList {
+		mentions = append(mentions, db.TMessageMention{
+			SnowID:     payload.SnowId,
+			Userid:     id,
+			GroupId:    payload.GroupId,
+			FromA:      payload.Userid,
+			Content:    content,
+			MentionAll: payload.MentionAll,
+		})
+	}
+	if err := db.SaveMentions(mentions); err != nil {
+		zlog.Error(fmt.Sprintf("save mentions of snowId=%s err:%v", payload.SnowId, err))
+	}
+}
+
+func (s *ServerLogic) ListMentions(ctx context.Context, request *proto.ListMentionsRequest) (reply *proto.ListMentionsReply, err error) {
+	reply = &proto.ListMentionsReply{Badges: make(map[int64]int64)}
+	var mentions []db.VMention
+	db.QueryUnresolvedMentions(request.Userid, &mentions)
+	for _, m := range mentions {
+		reply.Mentions = append(reply.Mentions, &proto.Mention{
+			SnowId:       m.SnowId,
+			GroupId:      m.GroupId,
+			GroupName:    m.GroupName,
+			Userid:       m.FromA,
+			FromUsername: m.FromUsername,
+			Content:      m.Content,
+			MentionAll:   m.MentionAll,
+			CreateAt:     m.CreateAt.Format(time.RFC3339),
+		})
+		reply.Badges[m.GroupId]++
+	}
+	return reply, nil
+}
+
+func (s *ServerLogic) ResolveMentions(ctx context.Context, request *proto.ResolveMentionsRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if err = db.ResolveMentions(request.Userid, request.GroupId, request.SnowId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+// 没有配置撤回时间范围时，默认发送之后2分钟内可以撤回
+const defaultRecallWindow = 2 * time.Minute
+
+const (
+	// 刚发送的消息的缓存时间，需要覆盖persist层的持久化延迟
+	recentMsgCacheExpire = time.Hour
+	// 编辑刚发送的消息时等待persist层写入的最长时间
+	editPersistWait = 3 * time.Second
+	// 等待消息写入db时查询的间隔
+	persistPollInterval = 200 * time.Millisecond
+)
+
+// cacheRecentMsg 消息生产成功后缓存撤回和表情回应需要的信息，缓存失败时只记录日志，此时只能等待消息写入db
+func cacheRecentMsg(_type string, userid, objectId int64, messageType string, record sentRecord, expireAt string) {
+	msg := db.TMessage{
+		SnowID:      record.SnowId,
+		Seq:         record.Seq,
+		Status:      db.MessageStatusNormal,
+		Type:        _type,
+		FromA:       userid,
+		ToB:         objectId,
+		MessageType: messageType,
+	}
+	msg.CreateAt, _ = time.Parse(time.RFC3339, record.CreateAt)
+	if t, err := time.Parse(time.RFC3339, expireAt); err == nil {
+		msg.ExpireAt = &t
+	}
+	saveRecentMsg(msg)
+}
+
+func saveRecentMsg(msg db.TMessage) {
+	body, _ := json.Marshal(&msg)
+	if err := common.RedisSetString(fmt.Sprintf(common.RecentMsg, msg.SnowID), body, recentMsgCacheExpire); err != nil {
+		zlog.Error(fmt.Sprintf("cache recent msg snowId=%s err:%v", msg.SnowID, err))
+	}
+}
+
+// queryMessage 查询snowId对应的消息，消息由persist层异步写入db，还没有写入时从发送时的缓存中获取，此时persisted为false
+func queryMessage(snowId string) (msg db.TMessage, persisted bool, err error) {
+	db.QueryMessageBySnowId(snowId, &msg)
+	if msg.ID != 0 {
+		return msg, true, nil
+	}
+	body, err := common.RedisGetString(fmt.Sprintf(common.RecentMsg, snowId))
+	if err != nil {
+		return msg, false, errors.New("系统异常")
+	}
+	if len(body) == 0 || json.Unmarshal(body, &msg) != nil || msg.SnowID == "" {
+		return db.TMessage{}, false, status.Error(codes.NotFound, "消息不存在")
+	}
+	if msg.ExpireAt != nil && !msg.ExpireAt.After(time.Now()) {
+		return db.TMessage{}, false, status.Error(codes.NotFound, "消息不存在")
+	}
+	return msg, false, nil
+}
+
+// waitMessagePersisted 等待消息被persist层写入db，超时后返回codes.Unavailable，客户端可以据此提示消息正在保存
+func waitMessagePersisted(snowId string, timeout time.Duration) (msg db.TMessage, err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		db.QueryMessageBySnowId(snowId, &msg)
+		if msg.ID != 0 {
+			return msg, nil
+		}
+		if time.Now().After(deadline) {
+			return msg, status.Error(codes.Unavailable, "消息正在保存，请稍后重试")
+		}
+		time.Sleep(persistPollInterval)
+	}
+}
+
+func (s *ServerLogic) RecallMessage(ctx context.Context, request *proto.RecallMessageRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	msg, persisted, err := queryMessage(request.SnowId)
+	if err != nil {
+		return
+	}
+	if msg.FromA != request.Userid {
+		err = errors.New("只能撤回自己发送的消息")
+		return
+	}
+	if msg.Status == db.MessageStatusRecalled {
+		return reply, nil
+	}
+	window := time.Duration(config.GetConfig().LogicRpc.Logic.RecallWindow) * time.Minute
+	if window <= 0 {
+		window = defaultRecallWindow
+	}
+	if time.Since(msg.CreateAt) > window {
+		err = errors.New(fmt.Sprintf("只能撤回%v内发送的消息", window))
+		return
+	}
+	// 消息还没有写入db时更新不到记录，persist层写入消息之后会处理同一个topic中的撤回事件
+	if err = db.RecallMessage(request.SnowId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if !persisted {
+		msg.Status = db.MessageStatusRecalled
+		saveRecentMsg(msg)
+	}
+	// 撤回的是会话的最后一条消息时，会话列表中不再展示原内容
+	if e := db.UpdateConversationPreview(request.SnowId, db.RecalledContent, true); e != nil {
+		zlog.Error(fmt.Sprintf("update conversation preview snowId=%s err:%v", request.SnowId, e))
+	}
+	// 撤回事件与原消息写入同一个topic，在线的接收方据此把对应的消息替换为撤回提示
+	switch msg.Type {
+	case "group":
+		// 撤回的消息中的@提及不再需要处理
+		_ = db.ResolveMentionsBySnowId(msg.SnowID)
+		err = PushChatEvent("group", msg.ToB, common.GroupMsg{
+			Userid:      msg.FromA,
+			GroupId:     msg.ToB,
+			Content:     db.RecalledContent,
+			MessageType: "text",
+			Op:          common.OpMsgRecallSend,
+			Seq:         msg.Seq,
+			SnowId:      msg.SnowID,
+			ExpireAt:    formatOptionalTime(msg.ExpireAt),
+		})
+	case "friend":
+		payload := common.FriendMsg{
+			Userid:      msg.FromA,
+			FriendId:    msg.ToB,
+			Content:     db.RecalledContent,
+			MessageType: "text",
+			Op:          common.OpMsgRecallSend,
+			Seq:         msg.Seq,
+			SnowId:      msg.SnowID,
+			ExpireAt:    formatOptionalTime(msg.ExpireAt),
+		}
+		payload.Belong = msg.ToB
+		if err = PushChatEvent("friend", msg.ToB, payload); err == nil {
+			payload.Belong = msg.FromA
+			err = PushChatEvent("friend", msg.FromA, payload)
+		}
+	}
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+func (s *ServerLogic) EditMessage(ctx context.Context, request *proto.EditMessageRequest) (reply *proto.EditMessageReply, err error) {
+	reply = new(proto.EditMessageReply)
+	if request.Content == "" {
+		err = errors.New("消息内容不能为空")
+		return
+	}
+	msg, persisted, err := queryMessage(request.SnowId)
+	if err != nil {
+		return
+	}
+	if msg.FromA != request.Userid {
+		err = errors.New("只能编辑自己发送的消息")
+		return
+	}
+	if !persisted {
+		// 编辑需要基于db中的版本号，等待persist层写入刚发送的消息，其他信箱中之后写入的记录由persist层应用编辑事件补齐
+		if msg, err = waitMessagePersisted(request.SnowId, editPersistWait); err != nil {
+			return
+		}
+	}
+	if msg.MessageType != "text" || msg.Status == db.MessageStatusRecalled {
+		err = errors.New("只能编辑未撤回的文字消息")
+		return
+	}
+	editedAt := time.Now()
+	ok, err := db.EditMessage(&msg, request.Content, editedAt)
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if !ok {
+		err = errors.New("消息已经被修改，请刷新后重试")
+		return
+	}
+	reply.Revision = msg.Revision + 1
+	reply.EditedAt = editedAt.Format(time.RFC3339)
+	if e := db.UpdateConversationPreview(msg.SnowID, truncateContent(request.Content), false); e != nil {
+		zlog.Error(fmt.Sprintf("update conversation preview snowId=%s err:%v", msg.SnowID, e))
+	}
+	// 编辑事件与原消息写入同一个topic，在线的客户端据此原地更新消息内容
+	switch msg.Type {
+	case "group":
+		err = PushChatEvent("group", msg.ToB, common.GroupMsg{
+			Userid:      msg.FromA,
+			GroupId:     msg.ToB,
+			Content:     request.Content,
+			MessageType: msg.MessageType,
+			Op:          common.OpMsgEditSend,
+			Seq:         msg.Seq,
+			SnowId:      msg.SnowID,
+			Revision:    reply.Revision,
+			EditedAt:    reply.EditedAt,
+			ExpireAt:    formatOptionalTime(msg.ExpireAt),
+		})
+	case "friend":
+		payload := common.FriendMsg{
+			Userid:      msg.FromA,
+			FriendId:    msg.ToB,
+			Content:     request.Content,
+			MessageType: msg.MessageType,
+			Op:          common.OpMsgEditSend,
+			Seq:         msg.Seq,
+			SnowId:      msg.SnowID,
+			Revision:    reply.Revision,
+			EditedAt:    reply.EditedAt,
+			ExpireAt:    formatOptionalTime(msg.ExpireAt),
+		}
+		payload.Belong = msg.ToB
+		if err = PushChatEvent("friend", msg.ToB, payload); err == nil {
+			payload.Belong = msg.FromA
+			err = PushChatEvent("friend", msg.FromA, payload)
+		}
+	}
+	if err != nil {
+		// 编辑已经保存，客户端刷新历史记录时可以获取最新的内容
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+func (s *ServerLogic) AddReaction(ctx context.Context, request *proto.ReactionRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	msg, err := checkReaction(request)
+	if err != nil {
+		return
+	}
+	created, err := db.AddReaction(&db.TMessageReaction{
+		SnowID: request.SnowId,
+		Userid: request.Userid,
+		Emoji:  request.Emoji,
+	})
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if created {
+		pushReactionEvent(msg, request, "add")
+	}
+	return reply, nil
+}
+
+func (s *ServerLogic) RemoveReaction(ctx context.Context, request *proto.ReactionRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	msg, err := checkReaction(request)
+	if err != nil {
+		return
+	}
+	deleted, err := db.RemoveReaction(request.SnowId, request.Userid, request.Emoji)
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if deleted {
+		pushReactionEvent(msg, request, "remove")
+	}
+	return reply, nil
+}
+
+// 表情的最大长度，与t_message_reaction.emoji的长度一致
+const maxEmojiLength = 32
+
+// checkReaction 只有会话的参与者才能回应消息，返回被回应的消息
+func checkReaction(request *proto.ReactionRequest) (msg db.TMessage, err error) {
+	if request.Emoji == "" || len([]rune(request.Emoji)) > maxEmojiLength {
+		err = errors.New("表情不合法")
+		return
+	}
+	// 表情回应单独保存，不依赖消息已经写入db
+	if msg, _, err = queryMessage(request.SnowId); err != nil {
+		return
+	}
+	if msg.Status == db.MessageStatusRecalled {
+		err = errors.New("消息已被撤回")
+		return
+	}
+	switch msg.Type {
+	case "group":
+		for _, groupId := range db.QueryUserAllGroupId(request.Userid) {
+			if groupId == msg.ToB {
+				return
+			}
+		}
+	case "friend":
+		if msg.FromA == request.Userid || msg.ToB == request.Userid {
+			return
+		}
+	}
+	err = errors.New("只能回应自己所在会话中的消息")
+	return
+}
+
+// pushReactionEvent 通知会话中在线的参与者，推送失败不影响回应本身，客户端刷新历史记录时可以获取最新的回应
+func pushReactionEvent(msg db.TMessage, request *proto.ReactionRequest, action string) {
+	event := common.ReactionMsg{
+		SnowId: msg.SnowID,
+		Userid: request.Userid,
+		Emoji:  request.Emoji,
+		Action: action,
+	}
+	var err error
+	switch msg.Type {
+	case "group":
+		event.GroupId = msg.ToB
+		err = PushReactionEvent(event)
+	case "friend":
+		for _, belong := range []int64{msg.FromA, msg.ToB} {
+			event.Belong = belong
+			if e := PushReactionEvent(event); e != nil {
+				err = e
+			}
+		}
+	}
+	if err != nil {
+		zlog.Error(fmt.Sprintf("push reaction event snowId=%s err:%v", msg.SnowID, err))
+	}
+}
+
+// 引用消息预览中最多展示的字符数
+const replyPreviewLength = 32
+
+// truncateContent 截取消息内容的前若干个字符作为预览
+func truncateContent(content string) string {
+	if runes := []rune(content); len(runes) > replyPreviewLength {
+		return string(runes[:replyPreviewLength])
+	}
+	return content
+}
+
+// checkReplyTo 被引用的消息必须属于同一个会话，replyTo为空时表示不是引用回复
+func checkReplyTo(_type string, userid, objectId int64, replyTo string) bool {
+	if replyTo == "" {
+		return true
+	}
+	var msg db.TMessage
+	db.QueryMessageBySnowId(replyTo, &msg)
+	if msg.ID == 0 || msg.Type != _type {
+		return false
+	}
+	if _type == "group" {
+		return msg.ToB == objectId
+	}
+	return (msg.FromA == userid && msg.ToB == objectId) || (msg.FromA == objectId && msg.ToB == userid)
+}
+
+// fillMessageExtras 为历史记录中的消息填充引用预览和表情回应
+func fillMessageExtras(msgList []*proto.ChatMessage) {
+	fillReplyPreviews(msgList)
+	fillReactions(msgList)
+}
+
+// fillReactions 按表情聚合消息的回应，表情按第一次被回应的先后顺序排列
+func fillReactions(msgList []*proto.ChatMessage) {
+	snowIds := make([]string, 0, len(msgList))
+	for _, m := range msgList {
+		snowIds = append(snowIds, m.SnowId)
+	}
+	var reactions []db.TMessageReaction
+	db.QueryReactions(snowIds, &reactions)
+	reactionMap := make(map[string][]*proto.Reaction)
+	for _, r := range reactions {
+		var reaction *proto.Reaction
+		for _, val := range reactionMap[r.SnowID] {
+			if val.Emoji == r.Emoji {
+				reaction = val
+				break
+			}
+		}
+		if reaction == nil {
+			reaction = &proto.Reaction{Emoji: r.Emoji}
+			reactionMap[r.SnowID] = append(reactionMap[r.SnowID], reaction)
+		}
+		reaction.Count++
+		reaction.Userids = append(reaction.Userids, r.Userid)
+	}
+	for _, m := range msgList {
+		m.Reactions = reactionMap[m.SnowId]
+	}
+}
+
+// fillReplyPreviews 为历史记录中的引用回复填充被引用消息的预览，被引用的消息已经撤回时只展示撤回提示
+func fillReplyPreviews(msgList []*proto.ChatMessage) {
+	var snowIds []string
+	for _, m := range msgList {
+		if m.ReplyTo != "" {
+			snowIds = append(snowIds, m.ReplyTo)
+		}
+	}
+	if len(snowIds) == 0 {
+		return
+	}
+	var previews []db.VReplyPreview
+	db.QueryReplyPreviews(snowIds, &previews)
+	previewMap := make(map[string]*proto.ReplyPreview, len(previews))
+	for _, p := range previews {
+		preview := &proto.ReplyPreview{
+			SnowId:       p.SnowId,
+			Userid:       p.Userid,
+			FromUsername: p.FromUsername,
+			MessageType:  p.MessageType,
+			Content:      p.Content,
+		}
+		if p.Status == db.MessageStatusRecalled {
+			preview.MessageType, preview.Content, preview.Recalled = "text", db.RecalledContent, true
+		} else {
+			preview.Content = truncateContent(p.Content)
+		}
+		previewMap[p.SnowId] = preview
+	}
+	for _, m := range msgList {
+		// 被引用的消息已经被删除时不返回预览
+		m.ReplyPreview = previewMap[m.ReplyTo]
+	}
+}
+
+// formatOptionalTime 时间为空（消息没有被编辑过或者不会过期）时返回空字符串
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (s *ServerLogic) PushRoomCount(ctx context.Context, request *proto.PushRoomCountRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	countStr, _ := common.RedisHGet(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId))
+	count, _ := strconv.Atoi(countStr)
+	// TODO:往该群聊的的topic中生产消息
+	err = PushGroupCount(request.GroupId, count)
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+func (s *ServerLogic) PushRoomInfo(ctx context.Context, request *proto.PushRoomInfoRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if err = pushGroupInfoUpdate(request.GroupId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+// pushGroupInfoUpdate 向群聊推送最新的成员列表、在线状态以及成员的角色和禁言状态
+func pushGroupInfoUpdate(groupId int64) error {
+	var userList []db.TUser
+	db.QueryGroupAllUser(groupId, &userList)
+	countStr, _ := common.RedisHGet(common.GroupOnlineUserCount, fmt.Sprintf("%d", groupId))
+	count, _ := strconv.Atoi(countStr)
+	var onlineUserIdList []int64
+	// 获取当前群聊的所有在线用户的id
+	userMap, err := common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, groupId))
+	if err != nil {
+		return err
+	}
+	for k := range userMap {
+		id, _ := strconv.Atoi(k)
+		onlineUserIdList = append(onlineUserIdList, int64(id))
+	}
+	return PushGroupInfo(0, groupId, count, userList, onlineUserIdList, queryGroupMembers(groupId))
+}
+
+// queryGroupMembers 查询群聊中所有成员的角色和禁言状态，已经过期的禁言不再返回
+func queryGroupMembers(groupId int64) []common.GroupMember {
+	var relationList []db.TRelation
+	db.QueryGroupRelations(groupId, &relationList)
+	members := make([]common.GroupMember, 0, len(relationList))
+	for _, r := range relationList {
+		member := common.GroupMember{Userid: r.ObjectA, Role: r.Role}
+		if r.MuteUntil != nil && r.MuteUntil.After(time.Now()) {
+			member.MuteUntil = r.MuteUntil.Format(time.RFC3339)
+		}
+		members = append(members, member)
+	}
+	return members
+}
+
+// 禁言时长的上限
+const maxMuteDuration = 30 * 24 * time.Hour
+
+func (s *ServerLogic) SetMemberRole(ctx context.Context, request *proto.SetMemberRoleRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if request.Role != db.GroupRoleMember && request.Role != db.GroupRoleAdmin {
+		err = errors.New("只能设置为管理员或者普通成员")
+		return
+	}
+	operator, member, err := checkGroupManage(request.Userid, request.GroupId, request.MemberId)
+	if err != nil {
+		return
+	}
+	if operator.Role != db.GroupRoleOwner {
+		err = errors.New("只有群主可以设置管理员")
+		return
+	}
+	if member.Role == int(request.Role) {
+		return reply, nil
+	}
+	if err = db.UpdateMemberRole(request.MemberId, request.GroupId, int(request.Role)); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	notifyGroupInfo(request.GroupId)
+	return reply, nil
+}
+
+func (s *ServerLogic) KickMember(ctx context.Context, request *proto.KickMemberRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	if _, _, err = checkGroupManage(request.Userid, request.GroupId, request.MemberId); err != nil {
+		return
+	}
+	if err = removeGroupMember(request.MemberId, request.GroupId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	notifyGroupInfo(request.GroupId)
+	return reply, nil
+}
+
+func (s *ServerLogic) MuteMember(ctx context.Context, request *proto.MuteMemberRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	duration := time.Duration(request.Duration) * time.Second
+	if duration < 0 || duration > maxMuteDuration {
+		err = errors.New(fmt.Sprintf("禁言时长不能超过%v", maxMuteDuration))
+		return
+	}
+	if _, _, err = checkGroupManage(request.Userid, request.GroupId, request.MemberId); err != nil {
+		return
+	}
+	var until *time.Time
+	if duration > 0 {
+		t := time.Now().Add(duration)
+		until = &t
+	}
+	if err = db.UpdateMemberMute(request.MemberId, request.GroupId, until); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	notifyGroupInfo(request.GroupId)
+	return reply, nil
+}
+
+func (s *ServerLogic) TransferOwnership(ctx context.Context, request *proto.TransferOwnershipRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	operator, _, err := checkGroupManage(request.Userid, request.GroupId, request.MemberId)
+	if err != nil {
+		return
+	}
+	if operator.Role != db.GroupRoleOwner {
+		err = errors.New("只有群主可以转让群聊")
+		return
+	}
+	if err = db.TransferOwnership(request.GroupId, request.Userid, request.MemberId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	notifyGroupInfo(request.GroupId)
+	return reply, nil
+}
+
+// checkGroupManage 操作方和被操作的成员都必须在群聊中，且操作方的角色必须比被操作的成员高
+func checkGroupManage(userid, groupId, memberId int64) (operator, member db.TRelation, err error) {
+	if userid == memberId {
+		err = errors.New("不能对自己进行该操作")
+		return
+	}
+	db.QueryGroupRelation(userid, groupId, &operator)
+	if operator.ID == 0 {
+		err = errors.New("你不在该群聊中")
+		return
+	}
+	db.QueryGroupRelation(memberId, groupId, &member)
+	if member.ID == 0 {
+		err = errors.New("该用户不在群聊中")
+		return
+	}
+	if operator.Role <= member.Role {
+		err = errors.New("没有权限管理该成员")
+		return
+	}
+	return
+}
+
+// removeGroupMember 删除成员关系，更新该成员在redis中的群聊列表和群聊的在线状态，
+// 并通知connect层停止向该成员推送群聊的消息
+func removeGroupMember(userid, groupId int64) error {
+	if err := db.DeleteGroupRelation(userid, groupId); err != nil {
+		return err
+	}
+	if err := updateUserGroupList(userid); err != nil {
+		return err
+	}
+	// 离开群聊后不再展示该群聊的未读数和会话
+	if _, err := common.RedisHDel(fmt.Sprintf(common.UserUnread, userid), common.ConversationId("group", userid, groupId)); err != nil {
+		return err
+	}
+	if err := db.DeleteConversation(userid, "group", groupId); err != nil {
+		return err
+	}
+	serverId, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, userid))
+	if err != nil {
+		return err
+	}
+	has, err := common.RedisHDel(fmt.Sprintf(common.GroupOnlineUser, groupId), fmt.Sprintf("%d", userid))
+	if err != nil {
+		return err
+	}
+	if !has {
+		// 不在线的成员重新连接时会根据最新的群聊列表加入GroupNode
+		return nil
+	}
+	if err = common.RedisHINCRBY(common.GroupOnlineUserCount, fmt.Sprintf("%d", groupId), -1); err != nil {
+		return err
+	}
+	return PushGroupDetach(common.GroupDetachMsg{
+		GroupId:   groupId,
+		Userids:   []int64{userid},
+		ServerIds: []string{string(serverId)},
+	})
+}
+
+// updateUserGroupList 在redis中写入用户当前加入的所有群聊id，connect层在用户连接时据此将连接加入GroupNode
+func updateUserGroupList(userid int64) error {
+	b, _ := json.Marshal(db.QueryUserAllGroupId(userid))
+	return common.RedisSetString(fmt.Sprintf(common.UserGroupList, userid), b, 0)
+}
+
+func (s *ServerLogic) LeaveGroup(ctx context.Context, request *proto.LeaveGroupRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	var relation db.TRelation
+	db.QueryGroupRelation(request.Userid, request.GroupId, &relation)
+	if relation.ID == 0 {
+		err = errors.New("你不在该群聊中")
+		return
+	}
+	if relation.Role == db.GroupRoleOwner {
+		err = errors.New("群主不能退出群聊，请先转让群主或者解散群聊")
+		return
+	}
+	if err = removeGroupMember(request.Userid, request.GroupId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	var user db.TUser
+	db.QueryUserById(request.Userid, &user)
+	pushGroupSystemMsg(request.GroupId, fmt.Sprintf("%s退出了群聊", user.Username))
+	notifyGroupInfo(request.GroupId)
+	return reply, nil
+}
+
+func (s *ServerLogic) DissolveGroup(ctx context.Context, request *proto.DissolveGroupRequest) (reply *empty.Empty, err error) {
+	reply = new(empty.Empty)
+	var relation db.TRelation
+	db.QueryGroupRelation(request.Userid, request.GroupId, &relation)
+	if relation.Role != db.GroupRoleOwner {
+		err = errors.New("只有群主可以解散群聊")
+		return
+	}
+	var relationList []db.TRelation
+	db.QueryGroupRelations(request.GroupId, &relationList)
+	// 清理在线状态之前记录在线成员所在的connect实例
+	onlineUser, err := common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, request.GroupId))
+	if err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	// 解散提示写入群聊的topic，作为该群聊的最后一条消息
+	pushGroupSystemMsg(request.GroupId, "该群聊已被群主解散")
+	if err = db.DissolveGroup(request.GroupId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if err = db.DeleteGroupConversations(request.GroupId); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	event := common.GroupDetachMsg{
+		GroupId:   request.GroupId,
+		Dissolved: true,
+	}
+	for _, r := range relationList {
+		event.Userids = append(event.Userids, r.ObjectA)
+		if e := updateUserGroupList(r.ObjectA); e != nil {
+			zlog.Error(fmt.Sprintf("update user=%d group list err:%v", r.ObjectA, e))
+		}
+	}
+	for _, serverId := range onlineUser {
+		event.ServerIds = append(event.ServerIds, serverId)
+	}
+	// 群聊不再有在线人数后，task层会停止消费该群聊的topic
+	if _, err = common.RedisHDel(common.GroupOnlineUserCount, fmt.Sprintf("%d", request.GroupId)); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if err = common.RedisDelString(fmt.Sprintf(common.GroupOnlineUser, request.GroupId)); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if err = common.RedisDelString(fmt.Sprintf(common.GroupProfile, request.GroupId)); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	if err = PushGroupDetach(event); err != nil {
+		err = errors.New("系统异常")
+		return
+	}
+	return reply, nil
+}
+
+// pushGroupSystemMsg 以系统的身份向群聊发送提示消息，发送失败时只记录日志
+func pushGroupSystemMsg(groupId int64, content string) {
+	payload := common.GroupMsg{
+		Userid:      common.SystemUserid,
+		GroupId:     groupId,
+		Content:     content,
+		MessageType: "system",
+		Op:          common.OpGroupMsgSend,
+	}
+	ttl, err := queryMessageTTL("group", common.SystemUserid, groupId)
+	if err != nil {
+		zlog.Error(fmt.Sprintf("push system msg to group=%d err:%v", groupId, err))
+		return
+	}
+	_, err = SendChatMsg("group", common.SystemUserid, groupId, 0, func(record sentRecord) error {
+		payload.SnowId, payload.Seq, payload.CreateAt = record.SnowId, record.Seq, record.CreateAt
+		payload.ExpireAt = messageExpireAt(record.CreateAt, ttl)
+		if err := Push(groupId, payload, common.OpGroupMsgSend); err != nil {
+			return err
+		}
+		members := queryGroupMemberIds(groupId)
+		incrGroupUnread(common.SystemUserid, groupId, members)
+		updateConversations("group", common.SystemUserid, groupId, content, payload.MessageType, record, members)
+		return nil
+	})
+	if err != nil {
+		zlog.Error(fmt.Sprintf("push system msg to group=%d err:%v", groupId, err))
+	}
+}
+
+// notifyGroupInfo 成员变更已经生效，推送失败时只记录日志，客户端刷新后可以获取最新的成员信息
+func notifyGroupInfo(groupId int64) {
+	if err := pushGroupInfoUpdate(groupId); err != nil {
+		zlog.Error(fmt.Sprintf("push group info groupId=%d err:%v", groupId, err))
+	}
+}
+
+// checkGroupSpeak 只有没有被禁言的群成员可以在群聊中发送消息
+func checkGroupSpeak(userid, groupId int64) error {
+	var relation db.TRelation
+	db.QueryGroupRelation(userid, groupId, &relation)
+	if relation.ID == 0 {
+		return permissionDenied("你不在该群聊中")
+	}
+	if relation.MuteUntil != nil && relation.MuteUntil.After(time.Now()) {
+		return permissionDenied(fmt.Sprintf("你已被禁言，解除时间为%s", relation.MuteUntil.Format("2006-01-02 15:04:05")))
+	}
+	return nil
+}
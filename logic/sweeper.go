@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"axisChat/common"
+	"axisChat/db"
+	"axisChat/utils/zlog"
+	"fmt"
+	"time"
+)
+
+/**
+*Author:AxisZql
+*Date:2022-8-8
+*DESC:定时清理会话中已经过期的消息，包括db中的消息记录以及支持删除单条消息的信箱，
+*     多个logic实例通过分布式锁保证同一时间只有一个实例在清理
+ */
+
+const (
+	// 清理过期消息的间隔，过期的消息在被清理之前已经不会在历史记录中返回
+	expireSweepInterval = time.Minute
+	// 每次最多删除的记录数，超过时下一轮继续删除，避免长时间占用db
+	expireSweepBatch = 500
+	// 分布式锁的有效期（秒），略短于清理间隔，使下一轮可以重新获取
+	expireSweepLockExpire = 50
+)
+
+// runExpireSweeper 周期性地清理过期消息，logic层启动时在后台运行
+func runExpireSweeper(serverId string) {
+	ticker := time.NewTicker(expireSweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		locker, err := common.NewRedisLocker(common.ExpireSweeperLock, serverId)
+		if err != nil {
+			zlog.Error(fmt.Sprintf("create expire sweeper lock err:%v", err))
+			continue
+		}
+		locker.SetExpire(expireSweepLockExpire)
+		if ok, err := locker.Acquire(); err != nil || !ok {
+			continue
+		}
+		sweepExpiredMessages(time.Now())
+	}
+}
+
+// sweepExpiredMessages 物理删除now之前过期的消息，失败时只记录日志，下一轮重试
+func sweepExpiredMessages(now time.Time) {
+	snowIds, err := db.DeleteExpiredMessages(now, expireSweepBatch)
+	if err != nil {
+		zlog.Error(fmt.Sprintf("delete expired messages err:%v", err))
+	} else if len(snowIds) > 0 {
+		zlog.Info(fmt.Sprintf("deleted %d expired messages", len(snowIds)))
+	}
+	removed, err := common.RemoveExpiredMessages(now, expireSweepBatch)
+	if err != nil {
+		zlog.Error(fmt.Sprintf("remove expired messages from mq err:%v", err))
+	} else if removed > 0 {
+		zlog.Info(fmt.Sprintf("removed %d expired messages from mq", removed))
+	}
+}
@@ -0,0 +1,372 @@
+package persist
+
+import (
+	"axisChat/common"
+	"axisChat/db"
+	"axisChat/utils/zlog"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+/**
+*Author: AxisZql
+*Date: 2022-7-26
+*DESC: persist layer, 以独立的消费组读取有新消息的聊天消息topic，按(belong, snowId)幂等地批量写入t_message，
+*      消息的持久化不再依赖接收方在线，也不会因为connect实例崩溃而丢失
+ */
+
+const (
+	persistBatchSize     = 100                    // 每批最多持久化的消息数目
+	persistBatchWait     = time.Second            // 凑够一批消息的最长等待时间
+	persistIdleTimeout   = 30 * time.Second       // topic超过该时间没有新消息时停止持久化，释放消费者
+	persistRetryInterval = time.Second            // 写入db或者读取topic失败后的重试间隔
+	pendingTopicInterval = 200 * time.Millisecond // 查询等待持久化的topic的间隔
+	maxPersistTopics     = 1024                   // 同时持久化的topic数目上限，超过时新登记的topic等待下一次查询
+)
+
+type Persist struct {
+	mutex  sync.Mutex
+	topics map[string]struct{} // 正在持久化的topic
+}
+
+func New() *Persist {
+	return &Persist{
+		topics: make(map[string]struct{}),
+	}
+}
+
+func (p *Persist) Run() {
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	go migrateLetterBox()
+	go p.watchPendingTopics()
+}
+
+// watchPendingTopics logic层生产消息之后把topic登记到common.PersistPendingTopic中，persist层认领之后持久化其中的消息，
+// 一段时间没有新消息后停止，消费者的数目只与最近有消息的用户和群聊有关
+func (p *Persist) watchPendingTopics() {
+	ticker := time.NewTicker(pendingTopicInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		pending, err := common.RedisHGetAll(common.PersistPendingTopic)
+		if err != nil {
+			zlog.Error(fmt.Sprintf("query pending persist topics err:%v", err))
+			continue
+		}
+		for topic := range pending {
+			p.mutex.Lock()
+			_, running := p.topics[topic]
+			full := len(p.topics) >= maxPersistTopics
+			p.mutex.Unlock()
+			// 正在持久化的topic会读取到新的消息，停止之后下一次查询时再认领
+			if running || full {
+				continue
+			}
+			// 先删除登记再读取，之后写入的消息会重新登记；多个persist实例中只有删除成功的实例认领该topic
+			if has, err := common.RedisHDel(common.PersistPendingTopic, topic); err != nil || !has {
+				continue
+			}
+			p.mutex.Lock()
+			p.topics[topic] = struct{}{}
+			p.mutex.Unlock()
+			go p.consumeTopic(topic)
+		}
+	}
+}
+
+// consumeTopic 持久化topic中的消息直到空闲，reader出现异常时重新打开，从消费组上一次提交的偏移量继续
+func (p *Persist) consumeTopic(topic string) {
+	defer func() {
+		p.mutex.Lock()
+		delete(p.topics, topic)
+		p.mutex.Unlock()
+	}()
+	for {
+		reader, err := common.GetConsumeReader(common.PersistConsumerSuffix, topic)
+		if err != nil {
+			time.Sleep(persistRetryInterval)
+			continue
+		}
+		err = persistTopic(reader, topic)
+		if e := reader.Close(); e != nil {
+			zlog.Error(e.Error())
+		}
+		if err == nil {
+			zlog.Debug(fmt.Sprintf("stop persisting idle topic=%s", topic))
+			return
+		}
+		zlog.Error(fmt.Sprintf("persist topic=%s get err: %v", topic, err))
+		time.Sleep(persistRetryInterval)
+	}
+}
+
+// migrateLetterBox 升级之前connect层把已经推送的聊天消息暂存在用户和群聊的信箱中，定时写入db，
+// 升级之后不再写入信箱，persist层启动时把信箱中剩余的消息写入db，全部写入之后记录标记，之后不再执行
+func migrateLetterBox() {
+	for {
+		migrated, err := common.RedisGetString(common.LetterBoxMigrated)
+		if err == nil && len(migrated) != 0 {
+			return
+		}
+		if err == nil {
+			err = flushLetterBoxes()
+		}
+		if err == nil {
+			if err = common.RedisSetString(common.LetterBoxMigrated, []byte("1"), 0); err == nil {
+				zlog.Info("success flush all letter boxes")
+				return
+			}
+		}
+		zlog.Error(fmt.Sprintf("flush letter boxes err:%v", err))
+		time.Sleep(persistRetryInterval)
+	}
+}
+
+func flushLetterBoxes() error {
+	userIdList, err := db.QueryAllUserId()
+	if err != nil {
+		return err
+	}
+	for _, id := range userIdList {
+		if err = flushLetterBox(fmt.Sprintf(common.UserLetterBox, id)); err != nil {
+			return err
+		}
+	}
+	groupIdList, err := db.QueryAllGroupId()
+	if err != nil {
+		return err
+	}
+	for _, id := range groupIdList {
+		if err = flushLetterBox(fmt.Sprintf(common.GroupLetterBox, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushLetterBox 写入信箱中的消息后删除信箱，已经写入过的消息会因为(belong, snow_id)唯一索引被忽略
+func flushLetterBox(key string) error {
+	box, err := common.RedisHGetAll(key)
+	if err != nil || len(box) == 0 {
+		return err
+	}
+	msgList := letterBoxMessages(box)
+	if err = db.SaveMsgInBatches(msgList); err != nil {
+		return err
+	}
+	zlog.Info(fmt.Sprintf("flush %d msg from letter box %s", len(msgList), key))
+	return common.RedisDelString(key)
+}
+
+// letterBoxMessages 解析信箱中以snowId为field、db.TMessage为value暂存的消息，没有snowId的消息无法幂等写入
+func letterBoxMessages(box map[string]string) []db.TMessage {
+	msgList := make([]db.TMessage, 0, len(box))
+	for snowId, payload := range box {
+		var msg db.TMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil || msg.SnowID == "" {
+			zlog.Error(fmt.Sprintf("skip illegal letter box msg snowId=%s", snowId))
+			continue
+		}
+		msg.ID = 0
+		// 信箱中的消息没有发送时间，以写入的时间代替
+		if msg.CreateAt.IsZero() {
+			msg.CreateAt = time.Now()
+		}
+		msgList = append(msgList, msg)
+	}
+	return msgList
+}
+
+type fetchResp struct {
+	msg common.MQMessage
+	err error
+}
+
+// persistTopic 批量读取topic中的消息并写入db，只有整批写入成功后才提交偏移量，
+// 因此进程崩溃后重复读取到的消息会因为(belong, snow_id)唯一索引被忽略。
+// 超过persistIdleTimeout没有新的消息时写入剩余的消息并返回nil
+func persistTopic(reader common.MQConsumer, topic string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgChannel := make(chan fetchResp, persistBatchSize)
+	go func() {
+		for {
+			msg, err := reader.Fetch(ctx)
+			select {
+			case msgChannel <- fetchResp{msg: msg, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var (
+		batch []db.TMessage
+		last  *common.MQMessage // 当前批次中最后一条消息，写入成功后提交其偏移量
+	)
+	flush := func() {
+		if last == nil {
+			return
+		}
+		// 写入失败时不断重试，不提交偏移量也不读取新的消息，保证消息不会丢失
+		for db.SaveMsgInBatches(batch) != nil {
+			time.Sleep(persistRetryInterval)
+		}
+		_ = common.TopicConsumerConfirm(reader, *last)
+		batch, last = nil, nil
+	}
+	lastActive := time.Now()
+	timer := time.NewTimer(persistBatchWait)
+	defer timer.Stop()
+	for {
+		select {
+		case resp := <-msgChannel:
+			lastActive = time.Now()
+			if resp.err != nil {
+				flush()
+				return resp.err
+			}
+			msg := resp.msg
+			if event, ok := toChatEvent(msg.Value); ok {
+				// 先写入之前的消息，被撤回或者编辑的消息可能还在当前批次中，写入之后再应用事件
+				flush()
+				for applyChatEvent(event) != nil {
+					time.Sleep(persistRetryInterval)
+				}
+			}
+			last = &msg
+			if dbMsg, ok := toDbMessage(msg.Value); ok {
+				batch = append(batch, dbMsg)
+			} else {
+				// 撤回、编辑等事件不需要写入消息记录
+				zlog.Debug(fmt.Sprintf("skip persist topic=%s offset=%d msg=%s", topic, msg.Offset, string(msg.Value)))
+			}
+			if len(batch) >= persistBatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			if time.Since(lastActive) >= persistIdleTimeout {
+				return nil
+			}
+			timer.Reset(persistBatchWait)
+		}
+	}
+}
+
+// chatEvent 撤回和编辑事件，logic层处理事件时只能更新已经写入db的消息记录，
+// persist层写入同一个topic中的消息之后再应用一次，使私聊双方信箱中的记录保持一致
+type chatEvent struct {
+	Op  int `json:"op"`
+	Msg struct {
+		SnowId   string `json:"snowId"`
+		Content  string `json:"content"`
+		Revision int64  `json:"revision"`
+		EditedAt string `json:"editedAt"`
+	} `json:"msg"`
+}
+
+// toChatEvent 解析撤回和编辑事件，其他消息返回false
+func toChatEvent(value []byte) (event chatEvent, ok bool) {
+	if err := json.Unmarshal(value, &event); err != nil || event.Msg.SnowId == "" {
+		return chatEvent{}, false
+	}
+	switch event.Op {
+	case common.OpMsgRecallSend:
+		return event, true
+	case common.OpMsgEditSend:
+		return event, event.Msg.Revision > 0
+	}
+	return chatEvent{}, false
+}
+
+func applyChatEvent(event chatEvent) error {
+	if event.Op == common.OpMsgRecallSend {
+		return db.RecallMessage(event.Msg.SnowId)
+	}
+	editedAt, err := time.Parse(time.RFC3339, event.Msg.EditedAt)
+	if err != nil {
+		editedAt = time.Now()
+	}
+	return db.ApplyMessageEdit(event.Msg.SnowId, event.Msg.Content, event.Msg.Revision, editedAt)
+}
+
+// toDbMessage 把topic中的聊天消息转换为db中的消息记录，不是聊天消息或者没有snowId的消息不会被持久化
+func toDbMessage(value []byte) (db.TMessage, bool) {
+	var msgOp struct {
+		Op int `json:"op"`
+	}
+	if err := json.Unmarshal(value, &msgOp); err != nil {
+		return db.TMessage{}, false
+	}
+	var dbMsg db.TMessage
+	var createAt, expireAt string
+	switch msgOp.Op {
+	case common.OpGroupMsgSend:
+		payload := common.MsgSend{Msg: new(common.GroupMsg)}
+		if err := json.Unmarshal(value, &payload); err != nil {
+			return db.TMessage{}, false
+		}
+		msg := payload.Msg.(*common.GroupMsg)
+		dbMsg = db.TMessage{
+			Belong:      msg.GroupId,
+			SnowID:      msg.SnowId,
+			Seq:         msg.Seq,
+			Watermark:   msg.Watermark,
+			Type:        "group",
+			Content:     msg.Content,
+			FromA:       msg.Userid,
+			ToB:         msg.GroupId,
+			MessageType: msg.MessageType,
+			ReplyTo:     msg.ReplyTo,
+			Mentions:    db.JoinMentions(msg.Mentions),
+			MentionAll:  msg.MentionAll,
+		}
+		createAt, expireAt = msg.CreateAt, msg.ExpireAt
+	case common.OpFriendMsgSend:
+		payload := common.MsgSend{Msg: new(common.FriendMsg)}
+		if err := json.Unmarshal(value, &payload); err != nil {
+			return db.TMessage{}, false
+		}
+		msg := payload.Msg.(*common.FriendMsg)
+		dbMsg = db.TMessage{
+			Belong:      msg.Belong,
+			SnowID:      msg.SnowId,
+			Seq:         msg.Seq,
+			Watermark:   msg.Watermark,
+			Type:        "friend",
+			Content:     msg.Content,
+			FromA:       msg.Userid,
+			ToB:         msg.FriendId,
+			MessageType: msg.MessageType,
+			ReplyTo:     msg.ReplyTo,
+		}
+		createAt, expireAt = msg.CreateAt, msg.ExpireAt
+	default:
+		return db.TMessage{}, false
+	}
+	// 升级之前生产的消息没有snowId，无法幂等地写入
+	if dbMsg.SnowID == "" {
+		return db.TMessage{}, false
+	}
+	// 发送时间以logic层生产消息时的时间为准，不受持久化延迟的影响，升级之前的消息没有发送时间时以持久化的时间代替，
+	// 同一批次中的记录都带有发送时间，避免批量写入时部分记录使用db的默认值
+	dbMsg.CreateAt = time.Now()
+	if t, err := time.Parse(time.RFC3339, createAt); err == nil {
+		dbMsg.CreateAt = t
+	}
+	if t, err := time.Parse(time.RFC3339, expireAt); err == nil {
+		// 积压的消息在持久化之前已经过期时不再写入
+		if !t.After(time.Now()) {
+			return db.TMessage{}, false
+		}
+		dbMsg.ExpireAt = &t
+	}
+	return dbMsg, true
+}
@@ -0,0 +1,114 @@
+package persist
+
+import (
+	"axisChat/common"
+	"axisChat/db"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToDbMessage(t *testing.T) {
+	body, _ := json.Marshal(common.MsgSend{
+		Op: common.OpFriendMsgSend,
+		Msg: common.FriendMsg{
+			Userid:   1,
+			FriendId: 2,
+			Content:  "hi",
+			Belong:   2,
+			Seq:      3,
+			SnowId:   "1024",
+			CreateAt: "2022-08-01T10:00:00+08:00",
+			Op:       common.OpFriendMsgSend,
+		},
+	})
+	msg, ok := toDbMessage(body)
+	if !ok {
+		t.Fatal("friend msg should be persisted")
+	}
+	if msg.Belong != 2 || msg.FromA != 1 || msg.ToB != 2 || msg.Type != "friend" || msg.Seq != 3 || msg.SnowID != "1024" {
+		t.Fatalf("unexpected friend msg %+v", msg)
+	}
+	// 发送时间以生产消息时的时间为准
+	if want, _ := time.Parse(time.RFC3339, "2022-08-01T10:00:00+08:00"); !msg.CreateAt.Equal(want) {
+		t.Fatalf("friend msg createAt = %v, want %v", msg.CreateAt, want)
+	}
+
+	body, _ = json.Marshal(common.MsgSend{
+		Op:  common.OpGroupMsgSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5, Content: "hi", SnowId: "2048", Mentions: []int64{2, 3}, CreateAt: "2022-08-01T10:00:01+08:00"},
+	})
+	msg, ok = toDbMessage(body)
+	if !ok || msg.Belong != 5 || msg.ToB != 5 || msg.Type != "group" || msg.Mentions != "2,3" || msg.CreateAt.Format(time.RFC3339) != "2022-08-01T10:00:01+08:00" {
+		t.Fatalf("unexpected group msg %+v", msg)
+	}
+
+	// 没有snowId的消息无法幂等写入
+	body, _ = json.Marshal(common.MsgSend{
+		Op:  common.OpGroupMsgSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5},
+	})
+	if _, ok = toDbMessage(body); ok {
+		t.Fatal("msg without snowId should be skipped")
+	}
+
+	// 过期时间随消息一起持久化，已经过期的消息不再写入
+	expireAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	body, _ = json.Marshal(common.MsgSend{
+		Op:  common.OpGroupMsgSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5, SnowId: "4096", ExpireAt: expireAt.Format(time.RFC3339)},
+	})
+	if msg, ok = toDbMessage(body); !ok || msg.ExpireAt == nil || !msg.ExpireAt.Equal(expireAt) {
+		t.Fatalf("unexpected expiring msg %+v", msg)
+	}
+	body, _ = json.Marshal(common.MsgSend{
+		Op:  common.OpGroupMsgSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5, SnowId: "8192", ExpireAt: time.Now().Add(-time.Minute).Format(time.RFC3339)},
+	})
+	if _, ok = toDbMessage(body); ok {
+		t.Fatal("expired msg should be skipped")
+	}
+}
+
+func TestLetterBoxMessages(t *testing.T) {
+	body, _ := json.Marshal(db.TMessage{Belong: 2, SnowID: "1024", Type: "friend", Content: "hi", FromA: 1, ToB: 2, MessageType: "text"})
+	msgList := letterBoxMessages(map[string]string{"1024": string(body), "2048": "illegal"})
+	if len(msgList) != 1 || msgList[0].SnowID != "1024" || msgList[0].Belong != 2 || msgList[0].CreateAt.IsZero() {
+		t.Fatalf("unexpected letter box msg %+v", msgList)
+	}
+}
+
+func TestToChatEvent(t *testing.T) {
+	body, _ := json.Marshal(common.MsgSend{
+		Op:  common.OpMsgRecallSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5, SnowId: "2048", Op: common.OpMsgRecallSend},
+	})
+	if event, ok := toChatEvent(body); !ok || event.Op != common.OpMsgRecallSend || event.Msg.SnowId != "2048" {
+		t.Fatalf("unexpected recall event %+v, %v", event, ok)
+	}
+	// 撤回事件本身不写入消息记录
+	if _, ok := toDbMessage(body); ok {
+		t.Fatal("recall event should not be persisted")
+	}
+
+	body, _ = json.Marshal(common.MsgSend{
+		Op: common.OpMsgEditSend,
+		Msg: common.FriendMsg{Userid: 1, FriendId: 2, Belong: 2, SnowId: "1024", Content: "edited",
+			Revision: 2, EditedAt: "2022-08-01T10:00:00+08:00", Op: common.OpMsgEditSend},
+	})
+	event, ok := toChatEvent(body)
+	if !ok || event.Op != common.OpMsgEditSend || event.Msg.SnowId != "1024" || event.Msg.Content != "edited" || event.Msg.Revision != 2 {
+		t.Fatalf("unexpected edit event %+v, %v", event, ok)
+	}
+	if _, ok = toDbMessage(body); ok {
+		t.Fatal("edit event should not be persisted")
+	}
+
+	body, _ = json.Marshal(common.MsgSend{
+		Op:  common.OpGroupMsgSend,
+		Msg: common.GroupMsg{Userid: 1, GroupId: 5, SnowId: "2048"},
+	})
+	if _, ok = toChatEvent(body); ok {
+		t.Fatal("chat msg is not an event")
+	}
+}
@@ -25,6 +25,62 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 聊天消息的投递结果
+type PushResult int32
+
+const (
+	PushResult_UNKNOWN       PushResult = 0
+	PushResult_COMMITTED     PushResult = 1 // 消息成功写入客户端连接并提交了偏移量
+	PushResult_DROPPED       PushResult = 2 // 目标连接的发送缓冲区已满或者写入连接失败
+	PushResult_USER_NOT_HERE PushResult = 3 // 目标用户（群聊）没有连接在当前connect实例上
+	PushResult_HANDED_OFF    PushResult = 4 // 消息已经写入目标连接的发送缓冲区，但在等待时间内还没有被提交
+)
+
+// Enum value maps for PushResult.
+var (
+	PushResult_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "COMMITTED",
+		2: "DROPPED",
+		3: "USER_NOT_HERE",
+		4: "HANDED_OFF",
+	}
+	PushResult_value = map[string]int32{
+		"UNKNOWN":       0,
+		"COMMITTED":     1,
+		"DROPPED":       2,
+		"USER_NOT_HERE": 3,
+		"HANDED_OFF":    4,
+	}
+)
+
+func (x PushResult) Enum() *PushResult {
+	p := new(PushResult)
+	*p = x
+	return p
+}
+
+func (x PushResult) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PushResult) Descriptor() protoreflect.EnumDescriptor {
+	return file_connect_proto_enumTypes[0].Descriptor()
+}
+
+func (PushResult) Type() protoreflect.EnumType {
+	return &file_connect_proto_enumTypes[0]
+}
+
+func (x PushResult) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PushResult.Descriptor instead.
+func (PushResult) EnumDescriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{0}
+}
+
 // 推送过来的消息包括对应消息的topic名称，partition、offset（提交消息确认时offset+1）
 type KafkaMsgInfo struct {
 	state         protoimpl.MessageState
@@ -317,17 +373,18 @@ func (x *PushFriendOfflineMsgReq) GetMsg() *PushFriendOfflineMsgReq_Msg {
 	return nil
 }
 
-type PushGroupMsgReq struct {
+type PushEventMsgReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Msg       *PushGroupMsgReq_Msg `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
-	KafkaInfo *KafkaMsgInfo        `protobuf:"bytes,2,opt,name=kafkaInfo,proto3" json:"kafkaInfo,omitempty"`
+	GroupId int64  `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"` // 不为0时推送给群聊中所有在线的成员
+	Belong  int64  `protobuf:"varint,2,opt,name=belong,proto3" json:"belong,omitempty"`   // groupId为0时推送给该用户
+	Body    []byte `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
 }
 
-func (x *PushGroupMsgReq) Reset() {
-	*x = PushGroupMsgReq{}
+func (x *PushEventMsgReq) Reset() {
+	*x = PushEventMsgReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -335,13 +392,13 @@ func (x *PushGroupMsgReq) Reset() {
 	}
 }
 
-func (x *PushGroupMsgReq) String() string {
+func (x *PushEventMsgReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushGroupMsgReq) ProtoMessage() {}
+func (*PushEventMsgReq) ProtoMessage() {}
 
-func (x *PushGroupMsgReq) ProtoReflect() protoreflect.Message {
+func (x *PushEventMsgReq) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -353,36 +410,45 @@ func (x *PushGroupMsgReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushGroupMsgReq.ProtoReflect.Descriptor instead.
-func (*PushGroupMsgReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use PushEventMsgReq.ProtoReflect.Descriptor instead.
+func (*PushEventMsgReq) Descriptor() ([]byte, []int) {
 	return file_connect_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *PushGroupMsgReq) GetMsg() *PushGroupMsgReq_Msg {
+func (x *PushEventMsgReq) GetGroupId() int64 {
 	if x != nil {
-		return x.Msg
+		return x.GroupId
 	}
-	return nil
+	return 0
 }
 
-func (x *PushGroupMsgReq) GetKafkaInfo() *KafkaMsgInfo {
+func (x *PushEventMsgReq) GetBelong() int64 {
 	if x != nil {
-		return x.KafkaInfo
+		return x.Belong
+	}
+	return 0
+}
+
+func (x *PushEventMsgReq) GetBody() []byte {
+	if x != nil {
+		return x.Body
 	}
 	return nil
 }
 
-type PushFriendMsgReq struct {
+type DetachGroupChannelReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Msg       *PushFriendMsgReq_Msg `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
-	KafkaInfo *KafkaMsgInfo         `protobuf:"bytes,2,opt,name=kafkaInfo,proto3" json:"kafkaInfo,omitempty"`
+	GroupId   int64   `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"`
+	Userids   []int64 `protobuf:"varint,2,rep,packed,name=userids,proto3" json:"userids,omitempty"` // 离开群聊的成员
+	Dissolved bool    `protobuf:"varint,3,opt,name=dissolved,proto3" json:"dissolved,omitempty"`    // 群聊解散时删除整个GroupNode
+	Body      []byte  `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`               // 推送给离开群聊的成员的事件
 }
 
-func (x *PushFriendMsgReq) Reset() {
-	*x = PushFriendMsgReq{}
+func (x *DetachGroupChannelReq) Reset() {
+	*x = DetachGroupChannelReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -390,13 +456,13 @@ func (x *PushFriendMsgReq) Reset() {
 	}
 }
 
-func (x *PushFriendMsgReq) String() string {
+func (x *DetachGroupChannelReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushFriendMsgReq) ProtoMessage() {}
+func (*DetachGroupChannelReq) ProtoMessage() {}
 
-func (x *PushFriendMsgReq) ProtoReflect() protoreflect.Message {
+func (x *DetachGroupChannelReq) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -408,36 +474,50 @@ func (x *PushFriendMsgReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushFriendMsgReq.ProtoReflect.Descriptor instead.
-func (*PushFriendMsgReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use DetachGroupChannelReq.ProtoReflect.Descriptor instead.
+func (*DetachGroupChannelReq) Descriptor() ([]byte, []int) {
 	return file_connect_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *PushFriendMsgReq) GetMsg() *PushFriendMsgReq_Msg {
+func (x *DetachGroupChannelReq) GetGroupId() int64 {
 	if x != nil {
-		return x.Msg
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *DetachGroupChannelReq) GetUserids() []int64 {
+	if x != nil {
+		return x.Userids
 	}
 	return nil
 }
 
-func (x *PushFriendMsgReq) GetKafkaInfo() *KafkaMsgInfo {
+func (x *DetachGroupChannelReq) GetDissolved() bool {
 	if x != nil {
-		return x.KafkaInfo
+		return x.Dissolved
+	}
+	return false
+}
+
+func (x *DetachGroupChannelReq) GetBody() []byte {
+	if x != nil {
+		return x.Body
 	}
 	return nil
 }
 
-type KafkaMsgInfo_Header struct {
+type PushGroupMsgReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Msg       *PushGroupMsgReq_Msg `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	KafkaInfo *KafkaMsgInfo        `protobuf:"bytes,2,opt,name=kafkaInfo,proto3" json:"kafkaInfo,omitempty"`
 }
 
-func (x *KafkaMsgInfo_Header) Reset() {
-	*x = KafkaMsgInfo_Header{}
+func (x *PushGroupMsgReq) Reset() {
+	*x = PushGroupMsgReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -445,13 +525,13 @@ func (x *KafkaMsgInfo_Header) Reset() {
 	}
 }
 
-func (x *KafkaMsgInfo_Header) String() string {
+func (x *PushGroupMsgReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*KafkaMsgInfo_Header) ProtoMessage() {}
+func (*PushGroupMsgReq) ProtoMessage() {}
 
-func (x *KafkaMsgInfo_Header) ProtoReflect() protoreflect.Message {
+func (x *PushGroupMsgReq) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -463,41 +543,36 @@ func (x *KafkaMsgInfo_Header) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use KafkaMsgInfo_Header.ProtoReflect.Descriptor instead.
-func (*KafkaMsgInfo_Header) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{0, 0}
+// Deprecated: Use PushGroupMsgReq.ProtoReflect.Descriptor instead.
+func (*PushGroupMsgReq) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *KafkaMsgInfo_Header) GetKey() string {
+func (x *PushGroupMsgReq) GetMsg() *PushGroupMsgReq_Msg {
 	if x != nil {
-		return x.Key
+		return x.Msg
 	}
-	return ""
+	return nil
 }
 
-func (x *KafkaMsgInfo_Header) GetValue() []byte {
+func (x *PushGroupMsgReq) GetKafkaInfo() *KafkaMsgInfo {
 	if x != nil {
-		return x.Value
+		return x.KafkaInfo
 	}
 	return nil
 }
 
-type PushGroupInfoMsgReq_Msg struct {
+type PushFriendMsgReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	GroupId int64 `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"`
-	// @inject_tag: json:"count"
-	Count   int32   `protobuf:"varint,2,opt,name=count,proto3" json:"count"`
-	UserArr []*User `protobuf:"bytes,3,rep,name=userArr,proto3" json:"userArr,omitempty"` // 群成员列表
-	Op      int32   `protobuf:"varint,4,opt,name=op,proto3" json:"op,omitempty"`
-	// @inject_tag: json:"onlineUserIds"
-	OnlineUserIds []int64 `protobuf:"varint,5,rep,packed,name=onlineUserIds,proto3" json:"onlineUserIds"`
+	Msg       *PushFriendMsgReq_Msg `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	KafkaInfo *KafkaMsgInfo         `protobuf:"bytes,2,opt,name=kafkaInfo,proto3" json:"kafkaInfo,omitempty"`
 }
 
-func (x *PushGroupInfoMsgReq_Msg) Reset() {
-	*x = PushGroupInfoMsgReq_Msg{}
+func (x *PushFriendMsgReq) Reset() {
+	*x = PushFriendMsgReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -505,13 +580,13 @@ func (x *PushGroupInfoMsgReq_Msg) Reset() {
 	}
 }
 
-func (x *PushGroupInfoMsgReq_Msg) String() string {
+func (x *PushFriendMsgReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushGroupInfoMsgReq_Msg) ProtoMessage() {}
+func (*PushFriendMsgReq) ProtoMessage() {}
 
-func (x *PushGroupInfoMsgReq_Msg) ProtoReflect() protoreflect.Message {
+func (x *PushFriendMsgReq) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -523,59 +598,35 @@ func (x *PushGroupInfoMsgReq_Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushGroupInfoMsgReq_Msg.ProtoReflect.Descriptor instead.
-func (*PushGroupInfoMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{1, 0}
-}
-
-func (x *PushGroupInfoMsgReq_Msg) GetGroupId() int64 {
-	if x != nil {
-		return x.GroupId
-	}
-	return 0
-}
-
-func (x *PushGroupInfoMsgReq_Msg) GetCount() int32 {
-	if x != nil {
-		return x.Count
-	}
-	return 0
+// Deprecated: Use PushFriendMsgReq.ProtoReflect.Descriptor instead.
+func (*PushFriendMsgReq) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *PushGroupInfoMsgReq_Msg) GetUserArr() []*User {
+func (x *PushFriendMsgReq) GetMsg() *PushFriendMsgReq_Msg {
 	if x != nil {
-		return x.UserArr
+		return x.Msg
 	}
 	return nil
 }
 
-func (x *PushGroupInfoMsgReq_Msg) GetOp() int32 {
-	if x != nil {
-		return x.Op
-	}
-	return 0
-}
-
-func (x *PushGroupInfoMsgReq_Msg) GetOnlineUserIds() []int64 {
+func (x *PushFriendMsgReq) GetKafkaInfo() *KafkaMsgInfo {
 	if x != nil {
-		return x.OnlineUserIds
+		return x.KafkaInfo
 	}
 	return nil
 }
 
-type PushGroupCountMsgReq_Msg struct {
+type PushMsgReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	GroupId int64 `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"`
-	// @inject_tag: json:"count"
-	Count int32 `protobuf:"varint,2,opt,name=count,proto3" json:"count"`
-	Op    int32 `protobuf:"varint,3,opt,name=op,proto3" json:"op,omitempty"`
+	Result PushResult `protobuf:"varint,1,opt,name=result,proto3,enum=PushResult" json:"result,omitempty"`
 }
 
-func (x *PushGroupCountMsgReq_Msg) Reset() {
-	*x = PushGroupCountMsgReq_Msg{}
+func (x *PushMsgReply) Reset() {
+	*x = PushMsgReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -583,13 +634,13 @@ func (x *PushGroupCountMsgReq_Msg) Reset() {
 	}
 }
 
-func (x *PushGroupCountMsgReq_Msg) String() string {
+func (x *PushMsgReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushGroupCountMsgReq_Msg) ProtoMessage() {}
+func (*PushMsgReply) ProtoMessage() {}
 
-func (x *PushGroupCountMsgReq_Msg) ProtoReflect() protoreflect.Message {
+func (x *PushMsgReply) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -601,45 +652,28 @@ func (x *PushGroupCountMsgReq_Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushGroupCountMsgReq_Msg.ProtoReflect.Descriptor instead.
-func (*PushGroupCountMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{2, 0}
-}
-
-func (x *PushGroupCountMsgReq_Msg) GetGroupId() int64 {
-	if x != nil {
-		return x.GroupId
-	}
-	return 0
-}
-
-func (x *PushGroupCountMsgReq_Msg) GetCount() int32 {
-	if x != nil {
-		return x.Count
-	}
-	return 0
+// Deprecated: Use PushMsgReply.ProtoReflect.Descriptor instead.
+func (*PushMsgReply) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *PushGroupCountMsgReq_Msg) GetOp() int32 {
+func (x *PushMsgReply) GetResult() PushResult {
 	if x != nil {
-		return x.Op
+		return x.Result
 	}
-	return 0
+	return PushResult_UNKNOWN
 }
 
-type PushFriendOnlineMsgReq_Msg struct {
+type PushStreamReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	FriendId   int64  `protobuf:"varint,1,opt,name=friendId,proto3" json:"friendId,omitempty"` //上线方id
-	FriendName string `protobuf:"bytes,2,opt,name=friendName,proto3" json:"friendName,omitempty"`
-	Op         int32  `protobuf:"varint,3,opt,name=op,proto3" json:"op,omitempty"`
-	Belong     int64  `protobuf:"varint,4,opt,name=belong,proto3" json:"belong,omitempty"` // 消息接收方id
+	Items []*PushStreamReq_Item `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 }
 
-func (x *PushFriendOnlineMsgReq_Msg) Reset() {
-	*x = PushFriendOnlineMsgReq_Msg{}
+func (x *PushStreamReq) Reset() {
+	*x = PushStreamReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -647,13 +681,13 @@ func (x *PushFriendOnlineMsgReq_Msg) Reset() {
 	}
 }
 
-func (x *PushFriendOnlineMsgReq_Msg) String() string {
+func (x *PushStreamReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushFriendOnlineMsgReq_Msg) ProtoMessage() {}
+func (*PushStreamReq) ProtoMessage() {}
 
-func (x *PushFriendOnlineMsgReq_Msg) ProtoReflect() protoreflect.Message {
+func (x *PushStreamReq) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -665,51 +699,28 @@ func (x *PushFriendOnlineMsgReq_Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushFriendOnlineMsgReq_Msg.ProtoReflect.Descriptor instead.
-func (*PushFriendOnlineMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{3, 0}
-}
-
-func (x *PushFriendOnlineMsgReq_Msg) GetFriendId() int64 {
-	if x != nil {
-		return x.FriendId
-	}
-	return 0
-}
-
-func (x *PushFriendOnlineMsgReq_Msg) GetFriendName() string {
-	if x != nil {
-		return x.FriendName
-	}
-	return ""
-}
-
-func (x *PushFriendOnlineMsgReq_Msg) GetOp() int32 {
-	if x != nil {
-		return x.Op
-	}
-	return 0
+// Deprecated: Use PushStreamReq.ProtoReflect.Descriptor instead.
+func (*PushStreamReq) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *PushFriendOnlineMsgReq_Msg) GetBelong() int64 {
+func (x *PushStreamReq) GetItems() []*PushStreamReq_Item {
 	if x != nil {
-		return x.Belong
+		return x.Items
 	}
-	return 0
+	return nil
 }
 
-type PushFriendOfflineMsgReq_Msg struct {
+type PushStreamReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	FriendId int64 `protobuf:"varint,1,opt,name=friendId,proto3" json:"friendId,omitempty"`
-	Op       int32 `protobuf:"varint,2,opt,name=op,proto3" json:"op,omitempty"`
-	Belong   int64 `protobuf:"varint,3,opt,name=belong,proto3" json:"belong,omitempty"` //消息接收方id
+	Results []*PushStreamReply_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
 }
 
-func (x *PushFriendOfflineMsgReq_Msg) Reset() {
-	*x = PushFriendOfflineMsgReq_Msg{}
+func (x *PushStreamReply) Reset() {
+	*x = PushStreamReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_connect_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -717,13 +728,13 @@ func (x *PushFriendOfflineMsgReq_Msg) Reset() {
 	}
 }
 
-func (x *PushFriendOfflineMsgReq_Msg) String() string {
+func (x *PushStreamReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushFriendOfflineMsgReq_Msg) ProtoMessage() {}
+func (*PushStreamReply) ProtoMessage() {}
 
-func (x *PushFriendOfflineMsgReq_Msg) ProtoReflect() protoreflect.Message {
+func (x *PushStreamReply) ProtoReflect() protoreflect.Message {
 	mi := &file_connect_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -735,55 +746,418 @@ func (x *PushFriendOfflineMsgReq_Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushFriendOfflineMsgReq_Msg.ProtoReflect.Descriptor instead.
-func (*PushFriendOfflineMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{4, 0}
-}
-
-func (x *PushFriendOfflineMsgReq_Msg) GetFriendId() int64 {
-	if x != nil {
-		return x.FriendId
-	}
-	return 0
-}
-
-func (x *PushFriendOfflineMsgReq_Msg) GetOp() int32 {
-	if x != nil {
-		return x.Op
-	}
-	return 0
+// Deprecated: Use PushStreamReply.ProtoReflect.Descriptor instead.
+func (*PushStreamReply) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *PushFriendOfflineMsgReq_Msg) GetBelong() int64 {
+func (x *PushStreamReply) GetResults() []*PushStreamReply_Result {
 	if x != nil {
-		return x.Belong
+		return x.Results
 	}
-	return 0
+	return nil
 }
 
-type PushGroupMsgReq_Msg struct {
+type KafkaMsgInfo_Header struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Userid       int64  `protobuf:"varint,1,opt,name=userid,proto3" json:"userid,omitempty"`
-	GroupId      int64  `protobuf:"varint,2,opt,name=groupId,proto3" json:"groupId,omitempty"`
-	Content      string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	MessageType  string `protobuf:"bytes,4,opt,name=messageType,proto3" json:"messageType,omitempty"`
-	GroupName    string `protobuf:"bytes,5,opt,name=groupName,proto3" json:"groupName,omitempty"`
-	FromUsername string `protobuf:"bytes,6,opt,name=fromUsername,proto3" json:"fromUsername,omitempty"`
-	CreateAt     string `protobuf:"bytes,7,opt,name=createAt,proto3" json:"createAt,omitempty"`
-	Op           int32  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`
-	SnowId       string `protobuf:"bytes,9,opt,name=snowId,proto3" json:"snowId,omitempty"`
-	Avatar       string `protobuf:"bytes,10,opt,name=avatar,proto3" json:"avatar,omitempty"`
-	Belong       int64  `protobuf:"varint,11,opt,name=belong,proto3" json:"belong,omitempty"`
-	Watermark    int64  `protobuf:"varint,12,opt,name=watermark,proto3" json:"watermark,omitempty"`
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *KafkaMsgInfo_Header) Reset() {
+	*x = KafkaMsgInfo_Header{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KafkaMsgInfo_Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KafkaMsgInfo_Header) ProtoMessage() {}
+
+func (x *KafkaMsgInfo_Header) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KafkaMsgInfo_Header.ProtoReflect.Descriptor instead.
+func (*KafkaMsgInfo_Header) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *KafkaMsgInfo_Header) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KafkaMsgInfo_Header) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type PushGroupInfoMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GroupId int64 `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"`
+	// @inject_tag: json:"count"
+	Count   int32   `protobuf:"varint,2,opt,name=count,proto3" json:"count"`
+	UserArr []*User `protobuf:"bytes,3,rep,name=userArr,proto3" json:"userArr,omitempty"` // 群成员列表
+	Op      int32   `protobuf:"varint,4,opt,name=op,proto3" json:"op,omitempty"`
+	// @inject_tag: json:"onlineUserIds"
+	OnlineUserIds []int64 `protobuf:"varint,5,rep,packed,name=onlineUserIds,proto3" json:"onlineUserIds"`
+	// @inject_tag: json:"members"
+	Members []*GroupMember `protobuf:"bytes,6,rep,name=members,proto3" json:"members"` // 群成员的角色和禁言状态
+	// @inject_tag: json:"groupName"
+	GroupName string `protobuf:"bytes,7,opt,name=groupName,proto3" json:"groupName"`
+	// @inject_tag: json:"notice"
+	Notice string `protobuf:"bytes,8,opt,name=notice,proto3" json:"notice"`
+	// @inject_tag: json:"avatar"
+	Avatar string `protobuf:"bytes,9,opt,name=avatar,proto3" json:"avatar"`
+}
+
+func (x *PushGroupInfoMsgReq_Msg) Reset() {
+	*x = PushGroupInfoMsgReq_Msg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PushGroupInfoMsgReq_Msg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushGroupInfoMsgReq_Msg) ProtoMessage() {}
+
+func (x *PushGroupInfoMsgReq_Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushGroupInfoMsgReq_Msg.ProtoReflect.Descriptor instead.
+func (*PushGroupInfoMsgReq_Msg) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetUserArr() []*User {
+	if x != nil {
+		return x.UserArr
+	}
+	return nil
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetOnlineUserIds() []int64 {
+	if x != nil {
+		return x.OnlineUserIds
+	}
+	return nil
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetMembers() []*GroupMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetGroupName() string {
+	if x != nil {
+		return x.GroupName
+	}
+	return ""
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetNotice() string {
+	if x != nil {
+		return x.Notice
+	}
+	return ""
+}
+
+func (x *PushGroupInfoMsgReq_Msg) GetAvatar() string {
+	if x != nil {
+		return x.Avatar
+	}
+	return ""
+}
+
+type PushGroupCountMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GroupId int64 `protobuf:"varint,1,opt,name=groupId,proto3" json:"groupId,omitempty"`
+	// @inject_tag: json:"count"
+	Count int32 `protobuf:"varint,2,opt,name=count,proto3" json:"count"`
+	Op    int32 `protobuf:"varint,3,opt,name=op,proto3" json:"op,omitempty"`
+}
+
+func (x *PushGroupCountMsgReq_Msg) Reset() {
+	*x = PushGroupCountMsgReq_Msg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PushGroupCountMsgReq_Msg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushGroupCountMsgReq_Msg) ProtoMessage() {}
+
+func (x *PushGroupCountMsgReq_Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushGroupCountMsgReq_Msg.ProtoReflect.Descriptor instead.
+func (*PushGroupCountMsgReq_Msg) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{2, 0}
+}
+
+func (x *PushGroupCountMsgReq_Msg) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *PushGroupCountMsgReq_Msg) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PushGroupCountMsgReq_Msg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+type PushFriendOnlineMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FriendId   int64  `protobuf:"varint,1,opt,name=friendId,proto3" json:"friendId,omitempty"` //上线方id
+	FriendName string `protobuf:"bytes,2,opt,name=friendName,proto3" json:"friendName,omitempty"`
+	Op         int32  `protobuf:"varint,3,opt,name=op,proto3" json:"op,omitempty"`
+	Belong     int64  `protobuf:"varint,4,opt,name=belong,proto3" json:"belong,omitempty"` // 消息接收方id
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) Reset() {
+	*x = PushFriendOnlineMsgReq_Msg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushFriendOnlineMsgReq_Msg) ProtoMessage() {}
+
+func (x *PushFriendOnlineMsgReq_Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushFriendOnlineMsgReq_Msg.ProtoReflect.Descriptor instead.
+func (*PushFriendOnlineMsgReq_Msg) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{3, 0}
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) GetFriendId() int64 {
+	if x != nil {
+		return x.FriendId
+	}
+	return 0
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) GetFriendName() string {
+	if x != nil {
+		return x.FriendName
+	}
+	return ""
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *PushFriendOnlineMsgReq_Msg) GetBelong() int64 {
+	if x != nil {
+		return x.Belong
+	}
+	return 0
+}
+
+type PushFriendOfflineMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FriendId int64 `protobuf:"varint,1,opt,name=friendId,proto3" json:"friendId,omitempty"`
+	Op       int32 `protobuf:"varint,2,opt,name=op,proto3" json:"op,omitempty"`
+	Belong   int64 `protobuf:"varint,3,opt,name=belong,proto3" json:"belong,omitempty"` //消息接收方id
+}
+
+func (x *PushFriendOfflineMsgReq_Msg) Reset() {
+	*x = PushFriendOfflineMsgReq_Msg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PushFriendOfflineMsgReq_Msg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushFriendOfflineMsgReq_Msg) ProtoMessage() {}
+
+func (x *PushFriendOfflineMsgReq_Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushFriendOfflineMsgReq_Msg.ProtoReflect.Descriptor instead.
+func (*PushFriendOfflineMsgReq_Msg) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{4, 0}
+}
+
+func (x *PushFriendOfflineMsgReq_Msg) GetFriendId() int64 {
+	if x != nil {
+		return x.FriendId
+	}
+	return 0
+}
+
+func (x *PushFriendOfflineMsgReq_Msg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *PushFriendOfflineMsgReq_Msg) GetBelong() int64 {
+	if x != nil {
+		return x.Belong
+	}
+	return 0
+}
+
+type PushGroupMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Userid       int64   `protobuf:"varint,1,opt,name=userid,proto3" json:"userid,omitempty"`
+	GroupId      int64   `protobuf:"varint,2,opt,name=groupId,proto3" json:"groupId,omitempty"`
+	Content      string  `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	MessageType  string  `protobuf:"bytes,4,opt,name=messageType,proto3" json:"messageType,omitempty"`
+	GroupName    string  `protobuf:"bytes,5,opt,name=groupName,proto3" json:"groupName,omitempty"`
+	FromUsername string  `protobuf:"bytes,6,opt,name=fromUsername,proto3" json:"fromUsername,omitempty"`
+	CreateAt     string  `protobuf:"bytes,7,opt,name=createAt,proto3" json:"createAt,omitempty"`
+	Op           int32   `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`
+	SnowId       string  `protobuf:"bytes,9,opt,name=snowId,proto3" json:"snowId,omitempty"`
+	Avatar       string  `protobuf:"bytes,10,opt,name=avatar,proto3" json:"avatar,omitempty"`
+	Belong       int64   `protobuf:"varint,11,opt,name=belong,proto3" json:"belong,omitempty"`
+	Watermark    int64   `protobuf:"varint,12,opt,name=watermark,proto3" json:"watermark,omitempty"`
+	Seq          int64   `protobuf:"varint,13,opt,name=seq,proto3" json:"seq,omitempty"`           // 会话内单调递增的消息序号
+	Revision     int64   `protobuf:"varint,14,opt,name=revision,proto3" json:"revision,omitempty"` // 编辑事件中消息编辑后的版本号
+	EditedAt     string  `protobuf:"bytes,15,opt,name=editedAt,proto3" json:"editedAt,omitempty"`
+	ReplyTo      string  `protobuf:"bytes,16,opt,name=replyTo,proto3" json:"replyTo,omitempty"`           // 引用回复的消息snowId
+	Mentions     []int64 `protobuf:"varint,17,rep,packed,name=mentions,proto3" json:"mentions,omitempty"` // @提及的用户id
+	MentionAll   bool    `protobuf:"varint,18,opt,name=mentionAll,proto3" json:"mentionAll,omitempty"`    // 是否@所有人
+	ExpireAt     string  `protobuf:"bytes,19,opt,name=expireAt,proto3" json:"expireAt,omitempty"`         // 会话设置了消息存活时间时消息的过期时间
 }
 
 func (x *PushGroupMsgReq_Msg) Reset() {
 	*x = PushGroupMsgReq_Msg{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_connect_proto_msgTypes[12]
+		mi := &file_connect_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -796,7 +1170,7 @@ func (x *PushGroupMsgReq_Msg) String() string {
 func (*PushGroupMsgReq_Msg) ProtoMessage() {}
 
 func (x *PushGroupMsgReq_Msg) ProtoReflect() protoreflect.Message {
-	mi := &file_connect_proto_msgTypes[12]
+	mi := &file_connect_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -809,7 +1183,7 @@ func (x *PushGroupMsgReq_Msg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PushGroupMsgReq_Msg.ProtoReflect.Descriptor instead.
 func (*PushGroupMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{5, 0}
+	return file_connect_proto_rawDescGZIP(), []int{7, 0}
 }
 
 func (x *PushGroupMsgReq_Msg) GetUserid() int64 {
@@ -889,49 +1263,265 @@ func (x *PushGroupMsgReq_Msg) GetBelong() int64 {
 	return 0
 }
 
-func (x *PushGroupMsgReq_Msg) GetWatermark() int64 {
+func (x *PushGroupMsgReq_Msg) GetWatermark() int64 {
+	if x != nil {
+		return x.Watermark
+	}
+	return 0
+}
+
+func (x *PushGroupMsgReq_Msg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PushGroupMsgReq_Msg) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *PushGroupMsgReq_Msg) GetEditedAt() string {
+	if x != nil {
+		return x.EditedAt
+	}
+	return ""
+}
+
+func (x *PushGroupMsgReq_Msg) GetReplyTo() string {
+	if x != nil {
+		return x.ReplyTo
+	}
+	return ""
+}
+
+func (x *PushGroupMsgReq_Msg) GetMentions() []int64 {
+	if x != nil {
+		return x.Mentions
+	}
+	return nil
+}
+
+func (x *PushGroupMsgReq_Msg) GetMentionAll() bool {
+	if x != nil {
+		return x.MentionAll
+	}
+	return false
+}
+
+func (x *PushGroupMsgReq_Msg) GetExpireAt() string {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return ""
+}
+
+type PushFriendMsgReq_Msg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Userid       int64  `protobuf:"varint,1,opt,name=userid,proto3" json:"userid,omitempty"`
+	FriendId     int64  `protobuf:"varint,2,opt,name=friendId,proto3" json:"friendId,omitempty"`
+	Content      string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	MessageType  string `protobuf:"bytes,4,opt,name=messageType,proto3" json:"messageType,omitempty"`
+	FriendName   string `protobuf:"bytes,5,opt,name=friendName,proto3" json:"friendName,omitempty"`
+	FromUsername string `protobuf:"bytes,6,opt,name=fromUsername,proto3" json:"fromUsername,omitempty"`
+	CreateAt     string `protobuf:"bytes,7,opt,name=createAt,proto3" json:"createAt,omitempty"`
+	Op           int32  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`
+	SnowId       string `protobuf:"bytes,9,opt,name=snowId,proto3" json:"snowId,omitempty"`
+	Avatar       string `protobuf:"bytes,10,opt,name=avatar,proto3" json:"avatar,omitempty"`
+	Belong       int64  `protobuf:"varint,11,opt,name=belong,proto3" json:"belong,omitempty"` // 该条消息的所属于的信箱
+	Watermark    int64  `protobuf:"varint,12,opt,name=watermark,proto3" json:"watermark,omitempty"`
+	Seq          int64  `protobuf:"varint,13,opt,name=seq,proto3" json:"seq,omitempty"`           // 会话内单调递增的消息序号
+	Revision     int64  `protobuf:"varint,14,opt,name=revision,proto3" json:"revision,omitempty"` // 编辑事件中消息编辑后的版本号
+	EditedAt     string `protobuf:"bytes,15,opt,name=editedAt,proto3" json:"editedAt,omitempty"`
+	ReplyTo      string `protobuf:"bytes,16,opt,name=replyTo,proto3" json:"replyTo,omitempty"`   // 引用回复的消息snowId
+	ExpireAt     string `protobuf:"bytes,17,opt,name=expireAt,proto3" json:"expireAt,omitempty"` // 会话设置了消息存活时间时消息的过期时间
+}
+
+func (x *PushFriendMsgReq_Msg) Reset() {
+	*x = PushFriendMsgReq_Msg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PushFriendMsgReq_Msg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushFriendMsgReq_Msg) ProtoMessage() {}
+
+func (x *PushFriendMsgReq_Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushFriendMsgReq_Msg.ProtoReflect.Descriptor instead.
+func (*PushFriendMsgReq_Msg) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{8, 0}
+}
+
+func (x *PushFriendMsgReq_Msg) GetUserid() int64 {
+	if x != nil {
+		return x.Userid
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetFriendId() int64 {
+	if x != nil {
+		return x.FriendId
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetFriendName() string {
+	if x != nil {
+		return x.FriendName
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetFromUsername() string {
+	if x != nil {
+		return x.FromUsername
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetCreateAt() string {
+	if x != nil {
+		return x.CreateAt
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetSnowId() string {
+	if x != nil {
+		return x.SnowId
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetAvatar() string {
+	if x != nil {
+		return x.Avatar
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetBelong() int64 {
+	if x != nil {
+		return x.Belong
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetWatermark() int64 {
+	if x != nil {
+		return x.Watermark
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *PushFriendMsgReq_Msg) GetEditedAt() string {
+	if x != nil {
+		return x.EditedAt
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetReplyTo() string {
+	if x != nil {
+		return x.ReplyTo
+	}
+	return ""
+}
+
+func (x *PushFriendMsgReq_Msg) GetExpireAt() string {
 	if x != nil {
-		return x.Watermark
+		return x.ExpireAt
 	}
-	return 0
+	return ""
 }
 
-type PushFriendMsgReq_Msg struct {
+type PushStreamReq_Item struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Userid       int64  `protobuf:"varint,1,opt,name=userid,proto3" json:"userid,omitempty"`
-	FriendId     int64  `protobuf:"varint,2,opt,name=friendId,proto3" json:"friendId,omitempty"`
-	Content      string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	MessageType  string `protobuf:"bytes,4,opt,name=messageType,proto3" json:"messageType,omitempty"`
-	FriendName   string `protobuf:"bytes,5,opt,name=friendName,proto3" json:"friendName,omitempty"`
-	FromUsername string `protobuf:"bytes,6,opt,name=fromUsername,proto3" json:"fromUsername,omitempty"`
-	CreateAt     string `protobuf:"bytes,7,opt,name=createAt,proto3" json:"createAt,omitempty"`
-	Op           int32  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`
-	SnowId       string `protobuf:"bytes,9,opt,name=snowId,proto3" json:"snowId,omitempty"`
-	Avatar       string `protobuf:"bytes,10,opt,name=avatar,proto3" json:"avatar,omitempty"`
-	Belong       int64  `protobuf:"varint,11,opt,name=belong,proto3" json:"belong,omitempty"` // 该条消息的所属于的信箱
-	Watermark    int64  `protobuf:"varint,12,opt,name=watermark,proto3" json:"watermark,omitempty"`
+	Seq       int64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"` // task层分配的序号，connect层回传投递结果时原样返回
+	GroupMsg  *PushGroupMsgReq_Msg  `protobuf:"bytes,2,opt,name=groupMsg,proto3" json:"groupMsg,omitempty"`
+	FriendMsg *PushFriendMsgReq_Msg `protobuf:"bytes,3,opt,name=friendMsg,proto3" json:"friendMsg,omitempty"`
+	KafkaInfo *KafkaMsgInfo         `protobuf:"bytes,4,opt,name=kafkaInfo,proto3" json:"kafkaInfo,omitempty"`
 }
 
-func (x *PushFriendMsgReq_Msg) Reset() {
-	*x = PushFriendMsgReq_Msg{}
+func (x *PushStreamReq_Item) Reset() {
+	*x = PushStreamReq_Item{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_connect_proto_msgTypes[13]
+		mi := &file_connect_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PushFriendMsgReq_Msg) String() string {
+func (x *PushStreamReq_Item) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PushFriendMsgReq_Msg) ProtoMessage() {}
+func (*PushStreamReq_Item) ProtoMessage() {}
 
-func (x *PushFriendMsgReq_Msg) ProtoReflect() protoreflect.Message {
-	mi := &file_connect_proto_msgTypes[13]
+func (x *PushStreamReq_Item) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -942,93 +1532,92 @@ func (x *PushFriendMsgReq_Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PushFriendMsgReq_Msg.ProtoReflect.Descriptor instead.
-func (*PushFriendMsgReq_Msg) Descriptor() ([]byte, []int) {
-	return file_connect_proto_rawDescGZIP(), []int{6, 0}
+// Deprecated: Use PushStreamReq_Item.ProtoReflect.Descriptor instead.
+func (*PushStreamReq_Item) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{10, 0}
 }
 
-func (x *PushFriendMsgReq_Msg) GetUserid() int64 {
+func (x *PushStreamReq_Item) GetSeq() int64 {
 	if x != nil {
-		return x.Userid
+		return x.Seq
 	}
 	return 0
 }
 
-func (x *PushFriendMsgReq_Msg) GetFriendId() int64 {
+func (x *PushStreamReq_Item) GetGroupMsg() *PushGroupMsgReq_Msg {
 	if x != nil {
-		return x.FriendId
+		return x.GroupMsg
 	}
-	return 0
+	return nil
 }
 
-func (x *PushFriendMsgReq_Msg) GetContent() string {
+func (x *PushStreamReq_Item) GetFriendMsg() *PushFriendMsgReq_Msg {
 	if x != nil {
-		return x.Content
+		return x.FriendMsg
 	}
-	return ""
+	return nil
 }
 
-func (x *PushFriendMsgReq_Msg) GetMessageType() string {
+func (x *PushStreamReq_Item) GetKafkaInfo() *KafkaMsgInfo {
 	if x != nil {
-		return x.MessageType
+		return x.KafkaInfo
 	}
-	return ""
+	return nil
 }
 
-func (x *PushFriendMsgReq_Msg) GetFriendName() string {
-	if x != nil {
-		return x.FriendName
-	}
-	return ""
-}
+type PushStreamReply_Result struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (x *PushFriendMsgReq_Msg) GetFromUsername() string {
-	if x != nil {
-		return x.FromUsername
-	}
-	return ""
+	Seq    int64      `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Result PushResult `protobuf:"varint,2,opt,name=result,proto3,enum=PushResult" json:"result,omitempty"`
 }
 
-func (x *PushFriendMsgReq_Msg) GetCreateAt() string {
-	if x != nil {
-		return x.CreateAt
+func (x *PushStreamReply_Result) Reset() {
+	*x = PushStreamReply_Result{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_connect_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return ""
 }
 
-func (x *PushFriendMsgReq_Msg) GetOp() int32 {
-	if x != nil {
-		return x.Op
-	}
-	return 0
+func (x *PushStreamReply_Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *PushFriendMsgReq_Msg) GetSnowId() string {
-	if x != nil {
-		return x.SnowId
+func (*PushStreamReply_Result) ProtoMessage() {}
+
+func (x *PushStreamReply_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_connect_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *PushFriendMsgReq_Msg) GetAvatar() string {
-	if x != nil {
-		return x.Avatar
-	}
-	return ""
+// Deprecated: Use PushStreamReply_Result.ProtoReflect.Descriptor instead.
+func (*PushStreamReply_Result) Descriptor() ([]byte, []int) {
+	return file_connect_proto_rawDescGZIP(), []int{11, 0}
 }
 
-func (x *PushFriendMsgReq_Msg) GetBelong() int64 {
+func (x *PushStreamReply_Result) GetSeq() int64 {
 	if x != nil {
-		return x.Belong
+		return x.Seq
 	}
 	return 0
 }
 
-func (x *PushFriendMsgReq_Msg) GetWatermark() int64 {
+func (x *PushStreamReply_Result) GetResult() PushResult {
 	if x != nil {
-		return x.Watermark
+		return x.Result
 	}
-	return 0
+	return PushResult_UNKNOWN
 }
 
 var File_connect_proto protoreflect.FileDescriptor
@@ -1055,12 +1644,12 @@ var file_connect_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x1a, 0x30, 0x0a, 0x06, 0x48, 0x65,
 	0x61, 0x64, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xd0, 0x01, 0x0a,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xc6, 0x02, 0x0a,
 	0x13, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x73,
 	0x67, 0x52, 0x65, 0x71, 0x12, 0x2a, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x18, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x6e, 0x66,
 	0x6f, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67,
-	0x1a, 0x8c, 0x01, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75,
+	0x1a, 0x82, 0x02, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75,
 	0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70,
 	0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72,
@@ -1068,119 +1657,202 @@ var file_connect_proto_rawDesc = []byte{
 	0x52, 0x07, 0x75, 0x73, 0x65, 0x72, 0x41, 0x72, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18,
 	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x24, 0x0a, 0x0d, 0x6f, 0x6e, 0x6c,
 	0x69, 0x6e, 0x65, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x03,
-	0x52, 0x0d, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x73, 0x22,
-	0x8a, 0x01, 0x0a, 0x14, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75,
-	0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x2b, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75,
-	0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67,
-	0x52, 0x03, 0x6d, 0x73, 0x67, 0x1a, 0x45, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x0a, 0x07,
-	0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x67,
-	0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02,
-	0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x22, 0xb2, 0x01, 0x0a,
-	0x16, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e,
-	0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x2d, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e,
-	0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73,
-	0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x1a, 0x69, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x1a, 0x0a,
-	0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x72, 0x69,
-	0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x66,
-	0x72, 0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c,
-	0x6f, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e,
-	0x67, 0x22, 0x94, 0x01, 0x0a, 0x17, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64,
-	0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x2e, 0x0a,
-	0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x50, 0x75, 0x73,
-	0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73,
-	0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x1a, 0x49, 0x0a,
-	0x03, 0x4d, 0x73, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64,
-	0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70,
-	0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x22, 0xb0, 0x03, 0x0a, 0x0f, 0x50, 0x75, 0x73,
-	0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x26, 0x0a, 0x03,
-	0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x50, 0x75, 0x73, 0x68,
-	0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52,
-	0x03, 0x6d, 0x73, 0x67, 0x12, 0x2b, 0x0a, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66,
-	0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x4d,
-	0x73, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66,
-	0x6f, 0x1a, 0xc7, 0x02, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65,
-	0x72, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x69,
-	0x64, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63,
-	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
-	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70,
-	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x6f, 0x75,
-	0x70, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x55, 0x73, 0x65,
-	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x72, 0x6f,
-	0x6d, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x72, 0x65,
-	0x61, 0x74, 0x65, 0x41, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x72, 0x65,
-	0x61, 0x74, 0x65, 0x41, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x18,
-	0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x12, 0x16, 0x0a,
-	0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61,
-	0x76, 0x61, 0x74, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18,
-	0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x12, 0x1c, 0x0a,
-	0x09, 0x77, 0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x09, 0x77, 0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x22, 0xb6, 0x03, 0x0a, 0x10,
-	0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71,
-	0x12, 0x27, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
-	0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71,
-	0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12, 0x2b, 0x0a, 0x09, 0x6b, 0x61, 0x66,
-	0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6b,
-	0x61, 0x66, 0x6b, 0x61, 0x4d, 0x73, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x6b, 0x61, 0x66,
-	0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0xcb, 0x02, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x16,
-	0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
-	0x75, 0x73, 0x65, 0x72, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64,
-	0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64,
-	0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x0b,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e,
-	0x0a, 0x0a, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0a, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22,
-	0x0a, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61,
-	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x74, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x74, 0x12, 0x0e,
-	0x0a, 0x02, 0x6f, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x16,
-	0x0a, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
-	0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72,
-	0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x12, 0x16,
-	0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
-	0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x77, 0x61, 0x74, 0x65, 0x72, 0x6d,
-	0x61, 0x72, 0x6b, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x77, 0x61, 0x74, 0x65, 0x72,
-	0x6d, 0x61, 0x72, 0x6b, 0x32, 0x9c, 0x03, 0x0a, 0x0c, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74,
-	0x4c, 0x61, 0x79, 0x65, 0x72, 0x12, 0x40, 0x0a, 0x10, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f,
-	0x75, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x73, 0x67, 0x12, 0x14, 0x2e, 0x50, 0x75, 0x73, 0x68,
-	0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x42, 0x0a, 0x11, 0x50, 0x75, 0x73, 0x68, 0x47,
-	0x72, 0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x15, 0x2e, 0x50,
-	0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67,
-	0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x46, 0x0a, 0x13, 0x50,
-	0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d,
-	0x73, 0x67, 0x12, 0x17, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f,
-	0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x12, 0x48, 0x0a, 0x14, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e,
-	0x64, 0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x2e, 0x50, 0x75,
-	0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d,
-	0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x38, 0x0a,
-	0x0c, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x12, 0x10, 0x2e,
-	0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x3a, 0x0a, 0x0d, 0x50, 0x75, 0x73, 0x68, 0x46,
-	0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x12, 0x11, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46,
-	0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x0d, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x73, 0x12,
+	0x26, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x07,
+	0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x6f, 0x75,
+	0x70, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61,
+	0x76, 0x61, 0x74, 0x61, 0x72, 0x22, 0x8a, 0x01, 0x0a, 0x14, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x2b,
+	0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x50, 0x75,
+	0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52,
+	0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x1a, 0x45, 0x0a, 0x03, 0x4d,
+	0x73, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02,
+	0x6f, 0x70, 0x22, 0xb2, 0x01, 0x0a, 0x16, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e,
+	0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x2d, 0x0a,
+	0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x50, 0x75, 0x73,
+	0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67,
+	0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x1a, 0x69, 0x0a, 0x03,
+	0x4d, 0x73, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x12,
+	0x1e, 0x0a, 0x0a, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12,
+	0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x22, 0x94, 0x01, 0x0a, 0x17, 0x50, 0x75, 0x73, 0x68,
+	0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67,
+	0x52, 0x65, 0x71, 0x12, 0x2e, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66, 0x66,
+	0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03,
+	0x6d, 0x73, 0x67, 0x1a, 0x49, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72,
+	0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x66, 0x72,
+	0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x22, 0x57,
+	0x0a, 0x0f, 0x50, 0x75, 0x73, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52, 0x65,
+	0x71, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x62,
+	0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c,
+	0x6f, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0x7d, 0x0a, 0x15, 0x44, 0x65, 0x74, 0x61, 0x63,
+	0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71,
+	0x12, 0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x75, 0x73,
+	0x65, 0x72, 0x69, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x03, 0x52, 0x07, 0x75, 0x73, 0x65,
+	0x72, 0x69, 0x64, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x69, 0x73, 0x73, 0x6f, 0x6c, 0x76, 0x65,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x64, 0x69, 0x73, 0x73, 0x6f, 0x6c, 0x76,
+	0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0xec, 0x04, 0x0a, 0x0f, 0x50, 0x75, 0x73, 0x68, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x26, 0x0a, 0x03, 0x6d, 0x73,
+	0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d,
+	0x73, 0x67, 0x12, 0x2b, 0x0a, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x4d, 0x73, 0x67,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x1a,
+	0x83, 0x04, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x69, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79,
+	0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x4e, 0x61,
+	0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x55,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x41, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x41, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x02, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61,
+	0x76, 0x61, 0x74, 0x61, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x76, 0x61,
+	0x74, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x77,
+	0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x77, 0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x72,
+	0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x64, 0x69, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x64, 0x69, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x54, 0x6f, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x54, 0x6f, 0x12, 0x1a, 0x0a,
+	0x08, 0x6d, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x03, 0x52,
+	0x08, 0x6d, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x65, 0x6e,
+	0x74, 0x69, 0x6f, 0x6e, 0x41, 0x6c, 0x6c, 0x18, 0x12, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6d,
+	0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x6c, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x41, 0x74, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x41, 0x74, 0x22, 0xb6, 0x04, 0x0a, 0x10, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72,
+	0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x12, 0x27, 0x0a, 0x03, 0x6d, 0x73,
+	0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72,
+	0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03,
+	0x6d, 0x73, 0x67, 0x12, 0x2b, 0x0a, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x4d, 0x73,
+	0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f,
+	0x1a, 0xcb, 0x03, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x69, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x72, 0x69, 0x65,
+	0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x66, 0x72,
+	0x69, 0x65, 0x6e, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x72, 0x6f, 0x6d,
+	0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x66, 0x72, 0x6f, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6e, 0x6f, 0x77,
+	0x49, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6e, 0x6f, 0x77, 0x49, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x6c, 0x6f,
+	0x6e, 0x67, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67,
+	0x12, 0x1c, 0x0a, 0x09, 0x77, 0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x77, 0x61, 0x74, 0x65, 0x72, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x10,
+	0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71,
+	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08,
+	0x65, 0x64, 0x69, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x65, 0x64, 0x69, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c,
+	0x79, 0x54, 0x6f, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x79,
+	0x54, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x18, 0x11,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x22, 0x33,
+	0x0a, 0x0c, 0x50, 0x75, 0x73, 0x68, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x23,
+	0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b,
+	0x2e, 0x50, 0x75, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x22, 0xe9, 0x01, 0x0a, 0x0d, 0x50, 0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x52, 0x65, 0x71, 0x12, 0x29, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x52, 0x65, 0x71, 0x2e, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73,
+	0x1a, 0xac, 0x01, 0x0a, 0x04, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x30, 0x0a, 0x08, 0x67,
+	0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x2e,
+	0x4d, 0x73, 0x67, 0x52, 0x08, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x12, 0x33, 0x0a,
+	0x09, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x15, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67,
+	0x52, 0x65, 0x71, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x09, 0x66, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d,
+	0x73, 0x67, 0x12, 0x2b, 0x0a, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x4d, 0x73, 0x67,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x49, 0x6e, 0x66, 0x6f, 0x22,
+	0x85, 0x01, 0x0a, 0x0f, 0x50, 0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x31, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a, 0x3f, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73,
+	0x65, 0x71, 0x12, 0x23, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52,
+	0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x2a, 0x58, 0x0a, 0x0a, 0x50, 0x75, 0x73, 0x68, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e,
+	0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x54, 0x45, 0x44, 0x10,
+	0x01, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x52, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x02, 0x12, 0x11,
+	0x0a, 0x0d, 0x55, 0x53, 0x45, 0x52, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x48, 0x45, 0x52, 0x45, 0x10,
+	0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x48, 0x41, 0x4e, 0x44, 0x45, 0x44, 0x5f, 0x4f, 0x46, 0x46, 0x10,
+	0x04, 0x32, 0xbe, 0x04, 0x0a, 0x0c, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x4c, 0x61, 0x79,
+	0x65, 0x72, 0x12, 0x40, 0x0a, 0x10, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49,
+	0x6e, 0x66, 0x6f, 0x4d, 0x73, 0x67, 0x12, 0x14, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f,
+	0x75, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x12, 0x42, 0x0a, 0x11, 0x50, 0x75, 0x73, 0x68, 0x47, 0x72, 0x6f, 0x75,
+	0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x15, 0x2e, 0x50, 0x75, 0x73, 0x68,
+	0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x46, 0x0a, 0x13, 0x50, 0x75, 0x73, 0x68,
+	0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x12,
+	0x17, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x6e, 0x6c, 0x69,
+	0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x12, 0x48, 0x0a, 0x14, 0x50, 0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66,
+	0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x46,
+	0x72, 0x69, 0x65, 0x6e, 0x64, 0x4f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x4d, 0x73, 0x67, 0x52,
+	0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x2f, 0x0a, 0x0c, 0x50, 0x75,
+	0x73, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x12, 0x10, 0x2e, 0x50, 0x75, 0x73,
+	0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x0d, 0x2e, 0x50,
+	0x75, 0x73, 0x68, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x31, 0x0a, 0x0d, 0x50,
+	0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x12, 0x11, 0x2e, 0x50,
+	0x75, 0x73, 0x68, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x71, 0x1a,
+	0x0d, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x32,
+	0x0a, 0x0a, 0x50, 0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x0e, 0x2e, 0x50,
+	0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x1a, 0x10, 0x2e, 0x50,
+	0x75, 0x73, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x28, 0x01,
+	0x30, 0x01, 0x12, 0x38, 0x0a, 0x0c, 0x50, 0x75, 0x73, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4d,
+	0x73, 0x67, 0x12, 0x10, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4d, 0x73,
+	0x67, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x44, 0x0a, 0x12,
+	0x44, 0x65, 0x74, 0x61, 0x63, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x12, 0x16, 0x2e, 0x44, 0x65, 0x74, 0x61, 0x63, 0x68, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2f, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1195,53 +1867,77 @@ func file_connect_proto_rawDescGZIP() []byte {
 	return file_connect_proto_rawDescData
 }
 
-var file_connect_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_connect_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_connect_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
 var file_connect_proto_goTypes = []interface{}{
-	(*KafkaMsgInfo)(nil),                // 0: kafkaMsgInfo
-	(*PushGroupInfoMsgReq)(nil),         // 1: PushGroupInfoMsgReq
-	(*PushGroupCountMsgReq)(nil),        // 2: PushGroupCountMsgReq
-	(*PushFriendOnlineMsgReq)(nil),      // 3: PushFriendOnlineMsgReq
-	(*PushFriendOfflineMsgReq)(nil),     // 4: PushFriendOfflineMsgReq
-	(*PushGroupMsgReq)(nil),             // 5: PushGroupMsgReq
-	(*PushFriendMsgReq)(nil),            // 6: PushFriendMsgReq
-	(*KafkaMsgInfo_Header)(nil),         // 7: kafkaMsgInfo.Header
-	(*PushGroupInfoMsgReq_Msg)(nil),     // 8: PushGroupInfoMsgReq.Msg
-	(*PushGroupCountMsgReq_Msg)(nil),    // 9: PushGroupCountMsgReq.Msg
-	(*PushFriendOnlineMsgReq_Msg)(nil),  // 10: PushFriendOnlineMsgReq.Msg
-	(*PushFriendOfflineMsgReq_Msg)(nil), // 11: PushFriendOfflineMsgReq.Msg
-	(*PushGroupMsgReq_Msg)(nil),         // 12: PushGroupMsgReq.Msg
-	(*PushFriendMsgReq_Msg)(nil),        // 13: PushFriendMsgReq.Msg
-	(*User)(nil),                        // 14: User
-	(*emptypb.Empty)(nil),               // 15: google.protobuf.Empty
+	(PushResult)(0),                     // 0: PushResult
+	(*KafkaMsgInfo)(nil),                // 1: kafkaMsgInfo
+	(*PushGroupInfoMsgReq)(nil),         // 2: PushGroupInfoMsgReq
+	(*PushGroupCountMsgReq)(nil),        // 3: PushGroupCountMsgReq
+	(*PushFriendOnlineMsgReq)(nil),      // 4: PushFriendOnlineMsgReq
+	(*PushFriendOfflineMsgReq)(nil),     // 5: PushFriendOfflineMsgReq
+	(*PushEventMsgReq)(nil),             // 6: PushEventMsgReq
+	(*DetachGroupChannelReq)(nil),       // 7: DetachGroupChannelReq
+	(*PushGroupMsgReq)(nil),             // 8: PushGroupMsgReq
+	(*PushFriendMsgReq)(nil),            // 9: PushFriendMsgReq
+	(*PushMsgReply)(nil),                // 10: PushMsgReply
+	(*PushStreamReq)(nil),               // 11: PushStreamReq
+	(*PushStreamReply)(nil),             // 12: PushStreamReply
+	(*KafkaMsgInfo_Header)(nil),         // 13: kafkaMsgInfo.Header
+	(*PushGroupInfoMsgReq_Msg)(nil),     // 14: PushGroupInfoMsgReq.Msg
+	(*PushGroupCountMsgReq_Msg)(nil),    // 15: PushGroupCountMsgReq.Msg
+	(*PushFriendOnlineMsgReq_Msg)(nil),  // 16: PushFriendOnlineMsgReq.Msg
+	(*PushFriendOfflineMsgReq_Msg)(nil), // 17: PushFriendOfflineMsgReq.Msg
+	(*PushGroupMsgReq_Msg)(nil),         // 18: PushGroupMsgReq.Msg
+	(*PushFriendMsgReq_Msg)(nil),        // 19: PushFriendMsgReq.Msg
+	(*PushStreamReq_Item)(nil),          // 20: PushStreamReq.Item
+	(*PushStreamReply_Result)(nil),      // 21: PushStreamReply.Result
+	(*User)(nil),                        // 22: User
+	(*GroupMember)(nil),                 // 23: GroupMember
+	(*emptypb.Empty)(nil),               // 24: google.protobuf.Empty
 }
 var file_connect_proto_depIdxs = []int32{
-	7,  // 0: kafkaMsgInfo.headers:type_name -> kafkaMsgInfo.Header
-	8,  // 1: PushGroupInfoMsgReq.msg:type_name -> PushGroupInfoMsgReq.Msg
-	9,  // 2: PushGroupCountMsgReq.msg:type_name -> PushGroupCountMsgReq.Msg
-	10, // 3: PushFriendOnlineMsgReq.msg:type_name -> PushFriendOnlineMsgReq.Msg
-	11, // 4: PushFriendOfflineMsgReq.msg:type_name -> PushFriendOfflineMsgReq.Msg
-	12, // 5: PushGroupMsgReq.msg:type_name -> PushGroupMsgReq.Msg
-	0,  // 6: PushGroupMsgReq.kafkaInfo:type_name -> kafkaMsgInfo
-	13, // 7: PushFriendMsgReq.msg:type_name -> PushFriendMsgReq.Msg
-	0,  // 8: PushFriendMsgReq.kafkaInfo:type_name -> kafkaMsgInfo
-	14, // 9: PushGroupInfoMsgReq.Msg.userArr:type_name -> User
-	1,  // 10: ConnectLayer.PushGroupInfoMsg:input_type -> PushGroupInfoMsgReq
-	2,  // 11: ConnectLayer.PushGroupCountMsg:input_type -> PushGroupCountMsgReq
-	3,  // 12: ConnectLayer.PushFriendOnlineMsg:input_type -> PushFriendOnlineMsgReq
-	4,  // 13: ConnectLayer.PushFriendOfflineMsg:input_type -> PushFriendOfflineMsgReq
-	5,  // 14: ConnectLayer.PushGroupMsg:input_type -> PushGroupMsgReq
-	6,  // 15: ConnectLayer.PushFriendMsg:input_type -> PushFriendMsgReq
-	15, // 16: ConnectLayer.PushGroupInfoMsg:output_type -> google.protobuf.Empty
-	15, // 17: ConnectLayer.PushGroupCountMsg:output_type -> google.protobuf.Empty
-	15, // 18: ConnectLayer.PushFriendOnlineMsg:output_type -> google.protobuf.Empty
-	15, // 19: ConnectLayer.PushFriendOfflineMsg:output_type -> google.protobuf.Empty
-	15, // 20: ConnectLayer.PushGroupMsg:output_type -> google.protobuf.Empty
-	15, // 21: ConnectLayer.PushFriendMsg:output_type -> google.protobuf.Empty
-	16, // [16:22] is the sub-list for method output_type
-	10, // [10:16] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	13, // 0: kafkaMsgInfo.headers:type_name -> kafkaMsgInfo.Header
+	14, // 1: PushGroupInfoMsgReq.msg:type_name -> PushGroupInfoMsgReq.Msg
+	15, // 2: PushGroupCountMsgReq.msg:type_name -> PushGroupCountMsgReq.Msg
+	16, // 3: PushFriendOnlineMsgReq.msg:type_name -> PushFriendOnlineMsgReq.Msg
+	17, // 4: PushFriendOfflineMsgReq.msg:type_name -> PushFriendOfflineMsgReq.Msg
+	18, // 5: PushGroupMsgReq.msg:type_name -> PushGroupMsgReq.Msg
+	1,  // 6: PushGroupMsgReq.kafkaInfo:type_name -> kafkaMsgInfo
+	19, // 7: PushFriendMsgReq.msg:type_name -> PushFriendMsgReq.Msg
+	1,  // 8: PushFriendMsgReq.kafkaInfo:type_name -> kafkaMsgInfo
+	0,  // 9: PushMsgReply.result:type_name -> PushResult
+	20, // 10: PushStreamReq.items:type_name -> PushStreamReq.Item
+	21, // 11: PushStreamReply.results:type_name -> PushStreamReply.Result
+	22, // 12: PushGroupInfoMsgReq.Msg.userArr:type_name -> User
+	23, // 13: PushGroupInfoMsgReq.Msg.members:type_name -> GroupMember
+	18, // 14: PushStreamReq.Item.groupMsg:type_name -> PushGroupMsgReq.Msg
+	19, // 15: PushStreamReq.Item.friendMsg:type_name -> PushFriendMsgReq.Msg
+	1,  // 16: PushStreamReq.Item.kafkaInfo:type_name -> kafkaMsgInfo
+	0,  // 17: PushStreamReply.Result.result:type_name -> PushResult
+	2,  // 18: ConnectLayer.PushGroupInfoMsg:input_type -> PushGroupInfoMsgReq
+	3,  // 19: ConnectLayer.PushGroupCountMsg:input_type -> PushGroupCountMsgReq
+	4,  // 20: ConnectLayer.PushFriendOnlineMsg:input_type -> PushFriendOnlineMsgReq
+	5,  // 21: ConnectLayer.PushFriendOfflineMsg:input_type -> PushFriendOfflineMsgReq
+	8,  // 22: ConnectLayer.PushGroupMsg:input_type -> PushGroupMsgReq
+	9,  // 23: ConnectLayer.PushFriendMsg:input_type -> PushFriendMsgReq
+	11, // 24: ConnectLayer.PushStream:input_type -> PushStreamReq
+	6,  // 25: ConnectLayer.PushEventMsg:input_type -> PushEventMsgReq
+	7,  // 26: ConnectLayer.DetachGroupChannel:input_type -> DetachGroupChannelReq
+	24, // 27: ConnectLayer.PushGroupInfoMsg:output_type -> google.protobuf.Empty
+	24, // 28: ConnectLayer.PushGroupCountMsg:output_type -> google.protobuf.Empty
+	24, // 29: ConnectLayer.PushFriendOnlineMsg:output_type -> google.protobuf.Empty
+	24, // 30: ConnectLayer.PushFriendOfflineMsg:output_type -> google.protobuf.Empty
+	10, // 31: ConnectLayer.PushGroupMsg:output_type -> PushMsgReply
+	10, // 32: ConnectLayer.PushFriendMsg:output_type -> PushMsgReply
+	12, // 33: ConnectLayer.PushStream:output_type -> PushStreamReply
+	24, // 34: ConnectLayer.PushEventMsg:output_type -> google.protobuf.Empty
+	24, // 35: ConnectLayer.DetachGroupChannel:output_type -> google.protobuf.Empty
+	27, // [27:36] is the sub-list for method output_type
+	18, // [18:27] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_connect_proto_init() }
@@ -1312,7 +2008,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushGroupMsgReq); i {
+			switch v := v.(*PushEventMsgReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1324,7 +2020,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushFriendMsgReq); i {
+			switch v := v.(*DetachGroupChannelReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1336,7 +2032,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KafkaMsgInfo_Header); i {
+			switch v := v.(*PushGroupMsgReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1348,7 +2044,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushGroupInfoMsgReq_Msg); i {
+			switch v := v.(*PushFriendMsgReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1360,7 +2056,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushGroupCountMsgReq_Msg); i {
+			switch v := v.(*PushMsgReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1372,7 +2068,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushFriendOnlineMsgReq_Msg); i {
+			switch v := v.(*PushStreamReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1384,7 +2080,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushFriendOfflineMsgReq_Msg); i {
+			switch v := v.(*PushStreamReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1396,7 +2092,7 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushGroupMsgReq_Msg); i {
+			switch v := v.(*KafkaMsgInfo_Header); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1408,6 +2104,66 @@ func file_connect_proto_init() {
 			}
 		}
 		file_connect_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushGroupInfoMsgReq_Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushGroupCountMsgReq_Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushFriendOnlineMsgReq_Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushFriendOfflineMsgReq_Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushGroupMsgReq_Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PushFriendMsgReq_Msg); i {
 			case 0:
 				return &v.state
@@ -1419,19 +2175,44 @@ func file_connect_proto_init() {
 				return nil
 			}
 		}
+		file_connect_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushStreamReq_Item); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_connect_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushStreamReply_Result); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_connect_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   14,
+			NumEnums:      1,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_connect_proto_goTypes,
 		DependencyIndexes: file_connect_proto_depIdxs,
+		EnumInfos:         file_connect_proto_enumTypes,
 		MessageInfos:      file_connect_proto_msgTypes,
 	}.Build()
 	File_connect_proto = out.File
@@ -1456,8 +2237,15 @@ type ConnectLayerClient interface {
 	PushGroupCountMsg(ctx context.Context, in *PushGroupCountMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	PushFriendOnlineMsg(ctx context.Context, in *PushFriendOnlineMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	PushFriendOfflineMsg(ctx context.Context, in *PushFriendOfflineMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	PushGroupMsg(ctx context.Context, in *PushGroupMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	PushFriendMsg(ctx context.Context, in *PushFriendMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// 聊天消息的推送在消息被成功提交或者已经交给目标连接后返回投递结果
+	PushGroupMsg(ctx context.Context, in *PushGroupMsgReq, opts ...grpc.CallOption) (*PushMsgReply, error)
+	PushFriendMsg(ctx context.Context, in *PushFriendMsgReq, opts ...grpc.CallOption) (*PushMsgReply, error)
+	// task层和connect层之间的长连接双向流，task批量推送聊天消息，connect逐条回传投递结果
+	PushStream(ctx context.Context, opts ...grpc.CallOption) (ConnectLayer_PushStreamClient, error)
+	// 表情回应等轻量事件，不需要投递结果，body原样推送给客户端
+	PushEventMsg(ctx context.Context, in *PushEventMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// 成员离开群聊或者群聊解散时，通知对应的成员并将其连接从GroupNode中删除
+	DetachGroupChannel(ctx context.Context, in *DetachGroupChannelReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type connectLayerClient struct {
@@ -1504,8 +2292,8 @@ func (c *connectLayerClient) PushFriendOfflineMsg(ctx context.Context, in *PushF
 	return out, nil
 }
 
-func (c *connectLayerClient) PushGroupMsg(ctx context.Context, in *PushGroupMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *connectLayerClient) PushGroupMsg(ctx context.Context, in *PushGroupMsgReq, opts ...grpc.CallOption) (*PushMsgReply, error) {
+	out := new(PushMsgReply)
 	err := c.cc.Invoke(ctx, "/ConnectLayer/PushGroupMsg", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -1513,8 +2301,8 @@ func (c *connectLayerClient) PushGroupMsg(ctx context.Context, in *PushGroupMsgR
 	return out, nil
 }
 
-func (c *connectLayerClient) PushFriendMsg(ctx context.Context, in *PushFriendMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *connectLayerClient) PushFriendMsg(ctx context.Context, in *PushFriendMsgReq, opts ...grpc.CallOption) (*PushMsgReply, error) {
+	out := new(PushMsgReply)
 	err := c.cc.Invoke(ctx, "/ConnectLayer/PushFriendMsg", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -1522,14 +2310,70 @@ func (c *connectLayerClient) PushFriendMsg(ctx context.Context, in *PushFriendMs
 	return out, nil
 }
 
+func (c *connectLayerClient) PushStream(ctx context.Context, opts ...grpc.CallOption) (ConnectLayer_PushStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ConnectLayer_serviceDesc.Streams[0], "/ConnectLayer/PushStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectLayerPushStreamClient{stream}
+	return x, nil
+}
+
+type ConnectLayer_PushStreamClient interface {
+	Send(*PushStreamReq) error
+	Recv() (*PushStreamReply, error)
+	grpc.ClientStream
+}
+
+type connectLayerPushStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectLayerPushStreamClient) Send(m *PushStreamReq) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *connectLayerPushStreamClient) Recv() (*PushStreamReply, error) {
+	m := new(PushStreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectLayerClient) PushEventMsg(ctx context.Context, in *PushEventMsgReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ConnectLayer/PushEventMsg", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectLayerClient) DetachGroupChannel(ctx context.Context, in *DetachGroupChannelReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ConnectLayer/DetachGroupChannel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ConnectLayerServer is the server API for ConnectLayer service.
 type ConnectLayerServer interface {
 	PushGroupInfoMsg(context.Context, *PushGroupInfoMsgReq) (*emptypb.Empty, error)
 	PushGroupCountMsg(context.Context, *PushGroupCountMsgReq) (*emptypb.Empty, error)
 	PushFriendOnlineMsg(context.Context, *PushFriendOnlineMsgReq) (*emptypb.Empty, error)
 	PushFriendOfflineMsg(context.Context, *PushFriendOfflineMsgReq) (*emptypb.Empty, error)
-	PushGroupMsg(context.Context, *PushGroupMsgReq) (*emptypb.Empty, error)
-	PushFriendMsg(context.Context, *PushFriendMsgReq) (*emptypb.Empty, error)
+	// 聊天消息的推送在消息被成功提交或者已经交给目标连接后返回投递结果
+	PushGroupMsg(context.Context, *PushGroupMsgReq) (*PushMsgReply, error)
+	PushFriendMsg(context.Context, *PushFriendMsgReq) (*PushMsgReply, error)
+	// task层和connect层之间的长连接双向流，task批量推送聊天消息，connect逐条回传投递结果
+	PushStream(ConnectLayer_PushStreamServer) error
+	// 表情回应等轻量事件，不需要投递结果，body原样推送给客户端
+	PushEventMsg(context.Context, *PushEventMsgReq) (*emptypb.Empty, error)
+	// 成员离开群聊或者群聊解散时，通知对应的成员并将其连接从GroupNode中删除
+	DetachGroupChannel(context.Context, *DetachGroupChannelReq) (*emptypb.Empty, error)
 }
 
 // UnimplementedConnectLayerServer can be embedded to have forward compatible implementations.
@@ -1548,12 +2392,21 @@ func (*UnimplementedConnectLayerServer) PushFriendOnlineMsg(context.Context, *Pu
 func (*UnimplementedConnectLayerServer) PushFriendOfflineMsg(context.Context, *PushFriendOfflineMsgReq) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushFriendOfflineMsg not implemented")
 }
-func (*UnimplementedConnectLayerServer) PushGroupMsg(context.Context, *PushGroupMsgReq) (*emptypb.Empty, error) {
+func (*UnimplementedConnectLayerServer) PushGroupMsg(context.Context, *PushGroupMsgReq) (*PushMsgReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushGroupMsg not implemented")
 }
-func (*UnimplementedConnectLayerServer) PushFriendMsg(context.Context, *PushFriendMsgReq) (*emptypb.Empty, error) {
+func (*UnimplementedConnectLayerServer) PushFriendMsg(context.Context, *PushFriendMsgReq) (*PushMsgReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushFriendMsg not implemented")
 }
+func (*UnimplementedConnectLayerServer) PushStream(ConnectLayer_PushStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method PushStream not implemented")
+}
+func (*UnimplementedConnectLayerServer) PushEventMsg(context.Context, *PushEventMsgReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushEventMsg not implemented")
+}
+func (*UnimplementedConnectLayerServer) DetachGroupChannel(context.Context, *DetachGroupChannelReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DetachGroupChannel not implemented")
+}
 
 func RegisterConnectLayerServer(s *grpc.Server, srv ConnectLayerServer) {
 	s.RegisterService(&_ConnectLayer_serviceDesc, srv)
@@ -1667,6 +2520,68 @@ func _ConnectLayer_PushFriendMsg_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConnectLayer_PushStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConnectLayerServer).PushStream(&connectLayerPushStreamServer{stream})
+}
+
+type ConnectLayer_PushStreamServer interface {
+	Send(*PushStreamReply) error
+	Recv() (*PushStreamReq, error)
+	grpc.ServerStream
+}
+
+type connectLayerPushStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectLayerPushStreamServer) Send(m *PushStreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *connectLayerPushStreamServer) Recv() (*PushStreamReq, error) {
+	m := new(PushStreamReq)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ConnectLayer_PushEventMsg_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushEventMsgReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectLayerServer).PushEventMsg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ConnectLayer/PushEventMsg",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectLayerServer).PushEventMsg(ctx, req.(*PushEventMsgReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectLayer_DetachGroupChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DetachGroupChannelReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectLayerServer).DetachGroupChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ConnectLayer/DetachGroupChannel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectLayerServer).DetachGroupChannel(ctx, req.(*DetachGroupChannelReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ConnectLayer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ConnectLayer",
 	HandlerType: (*ConnectLayerServer)(nil),
@@ -1695,7 +2610,22 @@ var _ConnectLayer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PushFriendMsg",
 			Handler:    _ConnectLayer_PushFriendMsg_Handler,
 		},
+		{
+			MethodName: "PushEventMsg",
+			Handler:    _ConnectLayer_PushEventMsg_Handler,
+		},
+		{
+			MethodName: "DetachGroupChannel",
+			Handler:    _ConnectLayer_DetachGroupChannel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PushStream",
+			Handler:       _ConnectLayer_PushStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "connect.proto",
 }
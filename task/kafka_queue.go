@@ -2,11 +2,11 @@ package task
 
 import (
 	"axisChat/common"
+	"axisChat/proto"
 	"axisChat/utils/zlog"
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/segmentio/kafka-go"
 	"strconv"
 	"strings"
 	"sync"
@@ -163,12 +163,48 @@ func (task *Task) startTopic() {
 	}
 }
 
+const (
+	pushWindowSize = 8 // 每个topic最多同时等待投递结果的消息数目
+	maxRetransmit  = 5 // 每条消息最多重传的次数，超过后放弃推送，由客户端通过历史记录补齐
+)
+
+// 以下依赖在测试中替换为不需要connect层和redis的实现
+var (
+	retransmitInterval = time.Second // 消息投递失败后，等待该时间再进行重传
+
+	pushToConnect = func(task *Task, msg *common.MQMessage) <-chan proto.PushResult {
+		return task.Push(msg)
+	}
+	// loadTopicOffset saveTopicOffset 读取和记录topic最后一条推送成功的消息，重新监听topic时以此为准
+	loadTopicOffset = func(topic string) ([]byte, error) {
+		return common.RedisGetString(fmt.Sprintf(common.KafkaTopicOffset, topic))
+	}
+	saveTopicOffset = func(topic string, payload []byte) error {
+		return common.RedisSetString(fmt.Sprintf(common.KafkaTopicOffset, topic), payload, 0)
+	}
+)
+
 type kafkaReader struct {
 	mutex  sync.RWMutex
-	reader *kafka.Reader
+	reader common.MQConsumer
 }
 
-// fetchMsgFromTopic 当对象在在线表onlineObj中时，如果connect消费了前一个消息则开始推送下一条消息
+// inflightMsg 已经推送到connect层但还没有提交偏移量的消息
+type inflightMsg struct {
+	msg       common.MQMessage
+	delivered bool
+	failures  int // 投递失败的次数
+}
+
+type pushOutcome struct {
+	entry  *inflightMsg
+	result proto.PushResult
+	round  int // reader重新初始化或者窗口重新推送后，之前轮次的投递结果直接丢弃
+}
+
+// fetchMsgFromTopic 当对象在在线表onlineObj中时，不断读取topic中的消息并推送到connect层
+// 最多同时有pushWindowSize条消息等待投递结果，偏移量按顺序提交，
+// 有消息投递失败时窗口中之后的消息可能已经推送，等待一段时间后按偏移量顺序重新推送整个窗口
 func (task *Task) fetchMsgFromTopic(ty string, id int64, topic string) {
 	defer func() {
 		objTrigger.mutex.Lock()
@@ -176,30 +212,22 @@ func (task *Task) fetchMsgFromTopic(ty string, id int64, topic string) {
 		delete(onlineObj.OnlineTopic, topic)
 	}()
 
-	//群聊消息，只要被一个reader正确提交偏移量就算成功消费，故对应一个群聊消息的消费组只有一个
-	var consumerSuffix string
-	switch ty {
-	case "group":
-		consumerSuffix = fmt.Sprintf("groupid-%d", id)
-	case "user":
-		consumerSuffix = fmt.Sprintf("userid-%d", id)
-	}
-	redisLock, err := common.NewRedisLocker(fmt.Sprintf(common.RedisLock, topic), topic)
-	//设置锁的过期时间为60s+500ms
-	redisLock.SetExpire(60)
-	if err != nil {
-		zlog.Error(fmt.Sprintf("init redis lock is failure %v", err))
-		return
-	}
+	//群聊消息，只要被一个reader正确提交偏移量就算成功消费，故对应一个群聊消息的消费组只有一个，
+	//消费组的起始偏移量以redis中记录的为准，见initKafkaReader
+	consumerSuffix := common.PushConsumerSuffix
 
 	var (
 		kr        kafkaReader
 		hasCommit common.KafkaMsgInfo
 		offset    int64
+		err       error
 	)
 	defer func() {
 		kr.mutex.Lock()
 		defer kr.mutex.Unlock()
+		if kr.reader == nil {
+			return
+		}
 		err = kr.reader.Close()
 		if err != nil {
 			zlog.Error(err.Error())
@@ -211,16 +239,20 @@ func (task *Task) fetchMsgFromTopic(ty string, id int64, topic string) {
 		kr.mutex.Lock()
 		defer kr.mutex.Unlock()
 
+		if kr.reader != nil {
+			// 重新初始化时关闭之前的reader
+			_ = kr.reader.Close()
+		}
 		kr.reader, err = common.GetConsumeReader(consumerSuffix, topic)
 		if err != nil {
 			zlog.Error(err.Error())
 			return
 		}
-		//根据redis上一次读取的最新的偏移量，来从对应topic中获取最新未被读取的消息
+		//根据redis上一次提交的最新的偏移量，来从对应topic中获取最新未被读取的消息
 		var res []byte
-		res, err = common.RedisGetString(fmt.Sprintf(common.KafkaTopicOffset, topic))
+		res, err = loadTopicOffset(topic)
 		if err != nil {
-			zlog.Error(fmt.Sprintf("common.RedisGetString(fmt.Sprintf(common.KafkaTopicOffset, %s))", topic))
+			zlog.Error(fmt.Sprintf("load topic = %s offset from redis err: %v", topic, err))
 			return
 		}
 
@@ -228,7 +260,7 @@ func (task *Task) fetchMsgFromTopic(ty string, id int64, topic string) {
 		offset = hasCommit.Offset
 		if offset != 0 {
 			// 在Kafka设置正常的偏移量，以redis为准
-			err = kr.reader.CommitMessages(context.Background(), kafka.Message{
+			err = kr.reader.Commit(context.Background(), common.MQMessage{
 				Topic:     hasCommit.Topic,
 				Partition: hasCommit.Partition,
 				Offset:    offset, //因为CommitMessage提交的偏移量是在Msg的基础上加1，所以-1
@@ -240,151 +272,170 @@ func (task *Task) fetchMsgFromTopic(ty string, id int64, topic string) {
 		}
 	}
 
-	// todo 在程序运行前必须保证connect释放分布式锁，确保之前的消息被持久化到db中
-	for {
-		lock, _ := redisLock.Acquire()
-		if !lock {
-			// 没有获取分布式锁则继续获取
-			time.Sleep(time.Millisecond * 50)
-			continue
-		} else {
-			break
+	// commitOffset 窗口头部的消息投递成功后按顺序提交偏移量，以redis提交的偏移量为准
+	commitOffset := func(msg common.MQMessage) {
+		offsetInfoPayload, _ := json.Marshal(common.KafkaMsgInfo{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+		})
+		if err := saveTopicOffset(topic, offsetInfoPayload); err != nil {
+			zlog.Error(fmt.Sprintf("commit topic = %s offset = %d to redis err: %v", topic, msg.Offset, err))
 		}
+		kr.mutex.RLock()
+		_ = common.TopicConsumerConfirm(kr.reader, msg)
+		kr.mutex.RUnlock()
 	}
 
-	initKafkaReader()
-	_, _ = redisLock.Release() // 初始化reader后，释放分布式锁
-
 	var (
-		ctx    context.Context
-		cancel context.CancelFunc
+		ctx        context.Context
+		cancel     context.CancelFunc
+		fetchNext  chan struct{} // 每个令牌允许读取一条消息，消息提交偏移量后归还令牌
+		msgChannel chan kafkaResp
+		window     []*inflightMsg // 按偏移量顺序排列的等待投递结果的消息
+		round      int
+		holding    bool // 等待重新推送窗口期间新读取的消息暂不推送，保证推送顺序
 	)
+	done := make(chan struct{})
+	outcomes := make(chan pushOutcome, pushWindowSize)
+	redeliver := make(chan int, 1)
+
+	startFetch := func() {
+		round++
+		window = nil
+		holding = false
+		ctx, cancel = context.WithCancel(context.Background())
+		fetchNext = make(chan struct{}, pushWindowSize)
+		for i := 0; i < pushWindowSize; i++ {
+			fetchNext <- struct{}{}
+		}
+		msgChannel = make(chan kafkaResp)
+		kr.mutex.RLock()
+		go task.fetchNextOffsetMsg(ctx, kr.reader, fetchNext, msgChannel, topic)
+		kr.mutex.RUnlock()
+	}
+	// 从redis记录的偏移量开始重新监听对应的topic,并销毁之前监听的进程
+	restartFetch := func() {
+		cancel()
+		initKafkaReader()
+		startFetch()
+	}
+	push := func(entry *inflightMsg) {
+		result := pushToConnect(task, &entry.msg)
+		r := round
+		go func() {
+			select {
+			case outcomes <- pushOutcome{entry: entry, result: <-result, round: r}:
+			case <-done:
+			}
+		}()
+	}
 
-	// 第一次投递消息
-	ctx, cancel = context.WithCancel(context.Background())
-	fetchNext := make(chan struct{}) //触发topic中下一个偏移量消息的获取
-	resChannel := make(chan kafkaResp)
-	go task.fetchNextOffsetMsg(ctx, kr.reader, fetchNext, resChannel, topic)
-	fetchNext <- struct{}{} // 推动量,开始监听对应topic
+	initKafkaReader()
+	startFetch()
 	defer func() {
+		close(done)
 		cancel()
-		close(fetchNext)
-		close(resChannel)
 	}()
 
-	quit := make(chan struct{})
-
 	objTrigger.mutex.RLock()
 	ch := objTrigger.preOffTrigger[topic]
 	objTrigger.mutex.RUnlock()
 
-	var (
-		curOffset int64
-	)
-
-	go func() {
-		for {
-			select {
-			case <-ch:
-				objTrigger.mutex.Lock()
-				delete(objTrigger.preOffTrigger, topic)
-				objTrigger.mutex.Unlock()
-				_, _ = redisLock.Release()
-				quit <- struct{}{}
-				return
-
-			case kResp, ok := <-resChannel:
-				if !ok {
-					break
-				}
+	for {
+		select {
+		case <-ch:
+			objTrigger.mutex.Lock()
+			delete(objTrigger.preOffTrigger, topic)
+			objTrigger.mutex.Unlock()
+			zlog.Debug(fmt.Sprintf("fetchMsgFromTopic 成功推出 topic=%v 的监听", topic))
+			return
 
-				if kResp.err != nil {
-					zlog.Error(fmt.Sprintf("%v", err))
-					initKafkaReader()
+		case kResp := <-msgChannel:
+			if kResp.err != nil {
+				zlog.Error(fmt.Sprintf("%v", kResp.err))
+				// 出现异常后重新初始化监听对应的topic，窗口中的消息会被重新读取
+				time.Sleep(retransmitInterval)
+				restartFetch()
+				break
+			}
+			entry := &inflightMsg{msg: kResp.msg}
+			window = append(window, entry)
+			if !holding {
+				push(entry)
+			}
 
-					// 出现异常后重新初始化监听对应的topic,并销毁之前监听的进程
-					cancel()
-					ctx, cancel = context.WithCancel(context.Background())
-					go task.fetchNextOffsetMsg(ctx, kr.reader, fetchNext, resChannel, topic)
-					fetchNext <- struct{}{}
+		case out := <-outcomes:
+			if out.round != round {
+				break
+			}
+			if !isDelivered(out.result) {
+				out.entry.failures++
+				if out.entry.failures <= maxRetransmit {
+					zlog.Error(fmt.Sprintf("topic = %s offset = %d 投递结果为%s，触发重传机制", topic, out.entry.msg.Offset, out.result))
+					// 丢弃窗口中其他消息的投递结果，等待后按顺序重新推送，客户端根据snowId去重
+					round++
+					holding = true
+					r := round
+					time.AfterFunc(retransmitInterval, func() {
+						select {
+						case redeliver <- r:
+						case <-done:
+						}
+					})
 					break
 				}
+				zlog.Error(fmt.Sprintf("topic = %s offset = %d 重传%d次后仍然投递失败，放弃推送", topic, out.entry.msg.Offset, maxRetransmit))
+			}
+			out.entry.delivered = true
+			for len(window) > 0 && window[0].delivered {
+				commitOffset(window[0].msg)
+				window = window[1:]
+				fetchNext <- struct{}{}
+			}
 
-				// 循环获取分布式锁
-				// 这里可以判定如果投递消息后connect层没有成功消费，则分布式锁必须等到60秒才会被释放，所以通过这个特征判定消息是否成功投递
-				checkPreSend := time.NewTicker(30 * time.Second)
-
-			over:
-				for {
-					select {
-					case <-checkPreSend.C:
-						// 如果获取分布式锁超过30s则证明前一次投递消息失败
-						if curOffset > 0 {
-							zlog.Error(fmt.Sprintf("topic = %s 消息投递超时，触发超时重传机制", topic))
-							initKafkaReader()
-							cancel()
-							ctx, cancel = context.WithCancel(context.Background())
-							go task.fetchNextOffsetMsg(ctx, kr.reader, fetchNext, resChannel, topic)
-							fetchNext <- struct{}{}
-						}
-						fetchNext <- struct{}{} // 应该由fetchNextOffsetMsg抛出异常然后触发reader重新初始化
-						break over
-					default:
-						lock, _ := redisLock.Acquire()
-						if !lock {
-							// 没有获取分布式锁则继续获取
-							time.Sleep(time.Millisecond * 50)
-							continue
-						} else {
-							// todo 这里会一直阻塞，直到成功投递消息或者抛出异常
-							task.Push(&kResp.msg)
-							curOffset = kResp.msg.Offset
-							fetchNext <- struct{}{}
-							break over
-						}
-					}
-
-				}
+		case r := <-redeliver:
+			if r != round {
+				break
+			}
+			holding = false
+			for _, entry := range window {
+				entry.delivered = false
+				push(entry)
 			}
 		}
-	}()
-
-	<-quit // 优雅结束
-	zlog.Debug(fmt.Sprintf("fetchMsgFromTopic 成功推出 topic=%v 的监听", topic))
+	}
 }
 
 type kafkaResp struct {
-	msg kafka.Message
+	msg common.MQMessage
 	err error
 }
 
-func (task *Task) fetchNextOffsetMsg(ctx context.Context, reader *kafka.Reader, fetchNext chan struct{}, resChannel chan kafkaResp, topic string) {
+// fetchNextOffsetMsg 每获得一个令牌就从topic中读取下一条消息
+func (task *Task) fetchNextOffsetMsg(ctx context.Context, reader common.MQConsumer, fetchNext chan struct{}, msgChannel chan kafkaResp, topic string) {
 	zlog.Debug(topic)
 	defer zlog.Debug(fmt.Sprintf("fetchNextOffsetMsg「退出」topic=%s 的监听", topic))
 	for {
 		select {
-		case <-fetchNext: // 前一次消息被消费成功才能进行新对消息推送
+		case <-ctx.Done():
+			return
+		case <-fetchNext:
+			begin := time.Now()
+			msg, err := reader.Fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
 			select {
+			case msgChannel <- kafkaResp{msg: msg, err: err}:
 			case <-ctx.Done():
 				return
-			default:
-				begin := time.Now()
-				msg, err := reader.FetchMessage(context.Background())
-
-				select {
-				case _, ok := <-resChannel:
-					// 确保父goroutine没有结束
-					if !ok {
-						return
-					}
-				default:
-					resChannel <- kafkaResp{
-						msg: msg,
-						err: err,
-					}
-				}
-				zlog.Info(fmt.Sprintf("「 fetch Msg 」 topic = %s cur offset = %v Spend time = %v", msg.Topic, msg.Offset, time.Since(begin)))
 			}
+			if err != nil {
+				// 由父goroutine重新初始化reader
+				return
+			}
+			zlog.Info(fmt.Sprintf("「 fetch Msg 」 topic = %s cur offset = %v Spend time = %v", msg.Topic, msg.Offset, time.Since(begin)))
 		}
 	}
 }
@@ -0,0 +1,162 @@
+package task
+
+import (
+	"axisChat/common"
+	"axisChat/proto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type pushCall struct {
+	offset int64
+	result chan proto.PushResult
+}
+
+// startPushWindow 使用内存消息队列监听topic，推送到connect层和在redis记录偏移量都由测试接管
+func startPushWindow(t *testing.T, topic string, count int) (*common.MemoryMQ, chan pushCall, chan int64) {
+	mq := common.NewMemoryMQ()
+	common.SetMQ(mq)
+	for i := 0; i < count; i++ {
+		if err := mq.Produce(topic, []byte(fmt.Sprintf("msg-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := make(chan pushCall, 64)
+	commits := make(chan int64, 64)
+	oldInterval, oldPush, oldLoad, oldSave := retransmitInterval, pushToConnect, loadTopicOffset, saveTopicOffset
+	retransmitInterval = 10 * time.Millisecond
+	pushToConnect = func(task *Task, msg *common.MQMessage) <-chan proto.PushResult {
+		result := make(chan proto.PushResult, 1)
+		calls <- pushCall{offset: msg.Offset, result: result}
+		return result
+	}
+	loadTopicOffset = func(topic string) ([]byte, error) {
+		return nil, nil
+	}
+	saveTopicOffset = func(topic string, payload []byte) error {
+		var info common.KafkaMsgInfo
+		_ = json.Unmarshal(payload, &info)
+		commits <- info.Offset
+		return nil
+	}
+
+	trigger := make(chan int64, 1)
+	objTrigger.mutex.Lock()
+	objTrigger.preOffTrigger[topic] = trigger
+	objTrigger.mutex.Unlock()
+	exited := make(chan struct{})
+	go func() {
+		(&Task{}).fetchMsgFromTopic("user", 1, topic)
+		close(exited)
+	}()
+
+	t.Cleanup(func() {
+		trigger <- 1
+		<-exited
+		retransmitInterval, pushToConnect, loadTopicOffset, saveTopicOffset = oldInterval, oldPush, oldLoad, oldSave
+	})
+	return mq, calls, commits
+}
+
+func nextPush(t *testing.T, calls chan pushCall, offset int64) pushCall {
+	t.Helper()
+	select {
+	case call := <-calls:
+		if call.offset != offset {
+			t.Fatalf("push offset = %d, want %d", call.offset, offset)
+		}
+		return call
+	case <-time.After(2 * time.Second):
+		t.Fatalf("offset %d is not pushed", offset)
+	}
+	return pushCall{}
+}
+
+func expectCommits(t *testing.T, commits chan int64, offsets ...int64) {
+	t.Helper()
+	for _, offset := range offsets {
+		select {
+		case got := <-commits:
+			if got != offset {
+				t.Fatalf("commit offset = %d, want %d", got, offset)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("offset %d is not committed", offset)
+		}
+	}
+	select {
+	case got := <-commits:
+		t.Fatalf("unexpected commit offset = %d", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// groupNextOffset 推送消费组在消息队列中下一条待消费消息的偏移量，所有消息都已经提交时返回-1
+func groupNextOffset(t *testing.T, mq *common.MemoryMQ, topic string) int64 {
+	t.Helper()
+	reader, _ := mq.NewConsumer(fmt.Sprintf("%s-%s", topic, common.PushConsumerSuffix), topic)
+	defer reader.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	msg, err := reader.Fetch(ctx)
+	if err != nil {
+		return -1
+	}
+	return msg.Offset
+}
+
+func TestPushWindowOutOfOrderResults(t *testing.T) {
+	topic := "friend_chat_1001"
+	mq, calls, commits := startPushWindow(t, topic, 3)
+	first := nextPush(t, calls, 0)
+	second := nextPush(t, calls, 1)
+	third := nextPush(t, calls, 2)
+
+	// 后面的消息先投递成功，不能越过还没有投递成功的第一条消息提交偏移量
+	third.result <- proto.PushResult_COMMITTED
+	second.result <- proto.PushResult_HANDED_OFF
+	expectCommits(t, commits)
+	if offset := groupNextOffset(t, mq, topic); offset != 0 {
+		t.Fatalf("group next offset = %d, want 0", offset)
+	}
+
+	first.result <- proto.PushResult_USER_NOT_HERE
+	expectCommits(t, commits, 0, 1, 2)
+	if offset := groupNextOffset(t, mq, topic); offset != -1 {
+		t.Fatalf("group next offset = %d, want all committed", offset)
+	}
+}
+
+func TestPushWindowRetransmit(t *testing.T) {
+	topic := "friend_chat_1002"
+	mq, calls, commits := startPushWindow(t, topic, 2)
+
+	// 第一条消息一直投递失败，每次重传都按偏移量顺序重新推送整个窗口
+	for i := 0; i <= maxRetransmit; i++ {
+		first := nextPush(t, calls, 0)
+		second := nextPush(t, calls, 1)
+		second.result <- proto.PushResult_COMMITTED
+		first.result <- proto.PushResult_DROPPED
+		if i < maxRetransmit {
+			expectCommits(t, commits)
+			if offset := groupNextOffset(t, mq, topic); offset != 0 {
+				t.Fatalf("round %d group next offset = %d, want 0", i, offset)
+			}
+		}
+	}
+
+	// 重传maxRetransmit次后放弃推送，之后不再重传
+	expectCommits(t, commits, 0, 1)
+	select {
+	case call := <-calls:
+		t.Fatalf("offset %d is pushed after giving up", call.offset)
+	case <-time.After(5 * retransmitInterval):
+	}
+	if offset := groupNextOffset(t, mq, topic); offset != -1 {
+		t.Fatalf("group next offset = %d, want all committed", offset)
+	}
+}
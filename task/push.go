@@ -2,11 +2,13 @@ package task
 
 import (
 	"axisChat/common"
+	"axisChat/proto"
 	"axisChat/utils/zlog"
 	"encoding/json"
 	"fmt"
-	"github.com/segmentio/kafka-go"
+	"hash/fnv"
 	"math/rand"
+	"time"
 )
 
 /*
@@ -16,28 +18,94 @@ import (
  */
 
 var (
-	pushChannel          []chan *kafka.Message
+	pushChannel          []chan *pushJob
 	pushStatusMsgChannel []chan []byte
 )
 
+// pushJob 推送聊天消息的任务，投递结果写入result
+type pushJob struct {
+	msg    *common.MQMessage
+	result chan proto.PushResult
+}
+
 func init() {
-	pushChannel = make([]chan *kafka.Message, 2)
+	pushChannel = make([]chan *pushJob, 2)
 	pushStatusMsgChannel = make([]chan []byte, 2)
 }
 
 func (task *Task) GoPush() {
 	for i := 0; i < len(pushChannel); i++ {
 		// 初始化每个缓冲channel的大小为50
-		pushChannel[i] = make(chan *kafka.Message, 50)
+		pushChannel[i] = make(chan *pushJob, 50)
 		pushStatusMsgChannel[i] = make(chan []byte, 50)
 		go task.pushMsgToConnect(pushChannel[i])
 		go task.pushStatusMsgToConnect(pushStatusMsgChannel[i])
 	}
 }
 
-func (task *Task) Push(msg *kafka.Message) {
+// Push 推送聊天消息，不等待投递结果，投递结果会写入返回的channel
+func (task *Task) Push(msg *common.MQMessage) <-chan proto.PushResult {
+	job := &pushJob{
+		msg:    msg,
+		result: make(chan proto.PushResult, 1),
+	}
+	// 同一个topic的消息总是由同一个推送进程处理，保证消息按照偏移量的顺序推送到connect层
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg.Topic))
 	// 将msg写入channel缓冲区，触发消息推送机制
-	pushChannel[rand.Int()%2] <- msg
+	pushChannel[h.Sum32()%uint32(len(pushChannel))] <- job
+	return job.result
+}
+
+// isDelivered 消息被提交或者已经交给目标连接时视为投递成功，目标用户不在线时由历史记录补齐消息，
+// 同样不需要重传
+func isDelivered(result proto.PushResult) bool {
+	return result == proto.PushResult_COMMITTED || result == proto.PushResult_HANDED_OFF ||
+		result == proto.PushResult_USER_NOT_HERE
+}
+
+// mergePushResult 合并群聊消息在多个connect实例上的投递结果，只要有一个实例成功提交就算成功消费
+func mergePushResult(a, b proto.PushResult) proto.PushResult {
+	rank := map[proto.PushResult]int{
+		proto.PushResult_USER_NOT_HERE: 0,
+		proto.PushResult_UNKNOWN:       1,
+		proto.PushResult_DROPPED:       2,
+		proto.PushResult_HANDED_OFF:    3,
+		proto.PushResult_COMMITTED:     4,
+	}
+	if rank[a] >= rank[b] {
+		return a
+	}
+	return b
+}
+
+// chatMsgOp 撤回等事件与聊天消息经过同样的推送路径，带有groupId的事件按群聊消息推送，其余按私聊消息推送
+func chatMsgOp(op int, value []byte) int {
+	switch op {
+	case common.OpMsgRecallSend, common.OpMsgEditSend:
+		payload := common.MsgSend{Msg: new(common.GroupInfoMsg)}
+		_ = json.Unmarshal(value, &payload)
+		if payload.Msg.(*common.GroupInfoMsg).GroupId != 0 {
+			return common.OpGroupMsgSend
+		}
+		return common.OpFriendMsgSend
+	}
+	return op
+}
+
+// expiringMsg 聊天消息和事件中的过期时间
+type expiringMsg struct {
+	ExpireAt string `json:"expireAt"`
+}
+
+// chatMsgExpired 会话设置了消息存活时间时，已经过期的消息和事件不再推送，kafka等不支持删除单条消息的信箱中过期消息在这里被跳过
+func chatMsgExpired(value []byte, now time.Time) bool {
+	payload := common.MsgSend{Msg: new(expiringMsg)}
+	if err := json.Unmarshal(value, &payload); err != nil {
+		return false
+	}
+	expireAt, err := time.Parse(time.RFC3339, payload.Msg.(*expiringMsg).ExpireAt)
+	return err == nil && !expireAt.After(now)
 }
 
 func (task *Task) PushStatusMsg(msg []byte) {
@@ -98,18 +166,110 @@ func (task *Task) pushStatusMsgToConnect(ch chan []byte) {
 					_ = json.Unmarshal(msg, &payload)
 					task.pushFriendOfflineMsg(serverId, payload.Msg.(*common.FriendOfflineMsg))
 				}
+			case common.OpFriendRequestSend:
+				payload.Msg = new(common.FriendRequestMsg)
+				_ = json.Unmarshal(msg, &payload)
+				belong := payload.Msg.(*common.FriendRequestMsg).Belong
+				res, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, belong))
+				if err != nil {
+					zlog.Error(fmt.Sprintf("push friend request msg can`t get serverId by belong err: %v", err))
+					break
+				}
+				// 接收方不在线时不需要推送，上线后可以通过好友申请列表获取
+				if serverId := string(res); serverId != "" {
+					task.pushEventMsg(serverId, 0, belong, msg)
+				}
+			case common.OpGroupJoinRequestSend:
+				payload.Msg = new(common.GroupJoinRequestMsg)
+				_ = json.Unmarshal(msg, &payload)
+				belong := payload.Msg.(*common.GroupJoinRequestMsg).Belong
+				res, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, belong))
+				if err != nil {
+					zlog.Error(fmt.Sprintf("push group join request msg can`t get serverId by belong err: %v", err))
+					break
+				}
+				// 接收方不在线时不需要推送，上线后可以通过入群申请列表获取
+				if serverId := string(res); serverId != "" {
+					task.pushEventMsg(serverId, 0, belong, msg)
+				}
+			case common.OpUnreadSend:
+				payload.Msg = new(common.UnreadMsg)
+				_ = json.Unmarshal(msg, &payload)
+				belong := payload.Msg.(*common.UnreadMsg).Belong
+				res, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, belong))
+				if err != nil {
+					zlog.Error(fmt.Sprintf("push unread msg can`t get serverId by belong err: %v", err))
+					break
+				}
+				// 用户不在线时不需要推送，上线后可以获取最新的未读数
+				if serverId := string(res); serverId != "" {
+					task.pushEventMsg(serverId, 0, belong, msg)
+				}
+			case common.OpGroupDetachSend:
+				payload.Msg = new(common.GroupDetachMsg)
+				_ = json.Unmarshal(msg, &payload)
+				event := payload.Msg.(*common.GroupDetachMsg)
+				serverIdMap := make(map[string]struct{})
+				for _, serverId := range event.ServerIds {
+					serverIdMap[serverId] = struct{}{}
+				}
+				// 推送给客户端的事件中不需要包含serverId
+				event.ServerIds = nil
+				body, _ := json.Marshal(&payload)
+				for serverId := range serverIdMap {
+					if serverId == "" {
+						continue
+					}
+					task.detachGroupChannel(serverId, event, body)
+				}
+			case common.OpMsgReactionSend:
+				payload.Msg = new(common.ReactionMsg)
+				_ = json.Unmarshal(msg, &payload)
+				event := payload.Msg.(*common.ReactionMsg)
+				serverIdMap := make(map[string]struct{})
+				if event.GroupId != 0 {
+					allOnlineUserId, err := common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, event.GroupId))
+					if err != nil {
+						zlog.Error(fmt.Sprintf("push reaction msg get err:%v", err))
+						break
+					}
+					for _, serverId := range allOnlineUserId {
+						serverIdMap[serverId] = struct{}{}
+					}
+				} else {
+					res, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, event.Belong))
+					if err != nil {
+						zlog.Error(fmt.Sprintf("push reaction msg can`t get serverId by belong err: %v", err))
+						break
+					}
+					serverIdMap[string(res)] = struct{}{}
+				}
+				for serverId := range serverIdMap {
+					if serverId == "" {
+						continue
+					}
+					task.pushEventMsg(serverId, event.GroupId, event.Belong, msg)
+				}
 			}
 		}
 	}
 }
 
-func (task *Task) pushMsgToConnect(ch chan *kafka.Message) {
+func (task *Task) pushMsgToConnect(ch chan *pushJob) {
 	for {
 		select {
-		case msg := <-ch:
+		case job := <-ch:
+			msg := job.msg
+			if chatMsgExpired(msg.Value, time.Now()) {
+				// 直接当作已经投递，使消费的偏移量继续前进
+				job.result <- proto.PushResult_COMMITTED
+				continue
+			}
+			// 每个相关connect实例的投递结果
+			var results []<-chan streamResult
 			var payload common.MsgSend
 			_ = json.Unmarshal(msg.Value, &payload)
-			switch payload.Op {
+			switch chatMsgOp(payload.Op, msg.Value) {
 			case common.OpGroupMsgSend:
 				//todo 因为所有Group Msg都有group_id 这一项所以利用common.GroupInfoMsg来提取所有类型消息的groupId
 				payload.Msg = new(common.GroupInfoMsg)
@@ -117,15 +277,19 @@ func (task *Task) pushMsgToConnect(ch chan *kafka.Message) {
 				allOnlineUserId, err := common.RedisHGetAll(fmt.Sprintf(common.GroupOnlineUser, payload.Msg.(*common.GroupInfoMsg).GroupId))
 				if err != nil {
 					zlog.Error(fmt.Sprintf("push Group Info msg get err:%v", err))
+					results = append(results, failedResult(err))
 					break
 				}
 				// 可以通过限制同一个serverId推送一次消息来解决
 				serverIdMap := make(map[string]struct{})
 				for _, serverId := range allOnlineUserId {
+					if serverId == "" {
+						continue
+					}
 					serverIdMap[serverId] = struct{}{}
 				}
 				for serverId := range serverIdMap {
-					task.pushGroupMsg(serverId, msg)
+					results = append(results, task.pushGroupMsg(serverId, msg))
 				}
 			case common.OpFriendMsgSend:
 				payload.Msg = new(common.FriendOnlineMsg)
@@ -133,11 +297,33 @@ func (task *Task) pushMsgToConnect(ch chan *kafka.Message) {
 				res, err := common.RedisGetString(fmt.Sprintf(common.UseridMapServerId, payload.Msg.(*common.FriendOnlineMsg).Belong))
 				if err != nil {
 					zlog.Error(fmt.Sprintf("push signal msg can`t get serverId by friendId err: %v", err))
+					results = append(results, failedResult(err))
 					break
 				}
 				serverId := string(res)
-				task.pushFriendMsg(serverId, msg)
+				if serverId == "" {
+					break
+				}
+				results = append(results, task.pushFriendMsg(serverId, msg))
 			}
+			// 等待投递结果时不阻塞后续消息的推送
+			go func(job *pushJob, results []<-chan streamResult) {
+				result := proto.PushResult_USER_NOT_HERE
+				for _, res := range results {
+					r := <-res
+					if r.err != nil {
+						zlog.Error(fmt.Sprintf("topic = %s offset = %d push get err: %v", job.msg.Topic, job.msg.Offset, r.err))
+					}
+					result = mergePushResult(result, r.result)
+				}
+				job.result <- result
+			}(job, results)
 		}
 	}
 }
+
+func failedResult(err error) <-chan streamResult {
+	res := make(chan streamResult, 1)
+	res <- streamResult{result: proto.PushResult_UNKNOWN, err: err}
+	return res
+}
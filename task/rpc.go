@@ -10,7 +10,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/segmentio/kafka-go"
 	"strings"
 	"time"
 )
@@ -60,6 +59,14 @@ func (task *Task) pushGroupInfoMsg(serverId string, msg *common.GroupInfoMsg) {
 		}
 		userArr = append(userArr, t)
 	}
+	var members []*proto.GroupMember
+	for _, m := range msg.Members {
+		members = append(members, &proto.GroupMember{
+			Userid:    m.Userid,
+			Role:      int32(m.Role),
+			MuteUntil: m.MuteUntil,
+		})
+	}
 
 	connectClient := proto.NewConnectLayerClient(connectRpcInstance.ins.Conn)
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
@@ -67,10 +74,14 @@ func (task *Task) pushGroupInfoMsg(serverId string, msg *common.GroupInfoMsg) {
 	_, err = connectClient.PushGroupInfoMsg(_ctx, &proto.PushGroupInfoMsgReq{
 		Msg: &proto.PushGroupInfoMsgReq_Msg{
 			GroupId:       msg.GroupId,
+			GroupName:     msg.GroupName,
+			Notice:        msg.Notice,
+			Avatar:        msg.Avatar,
 			Count:         int32(msg.Count),
 			UserArr:       userArr,
 			Op:            int32(msg.Op),
 			OnlineUserIds: msg.OnlineUserIds,
+			Members:       members,
 		},
 	})
 	if err != nil {
@@ -78,6 +89,47 @@ func (task *Task) pushGroupInfoMsg(serverId string, msg *common.GroupInfoMsg) {
 	}
 }
 
+// pushEventMsg 推送表情回应等轻量事件，不等待投递结果
+func (task *Task) pushEventMsg(serverId string, groupId, belong int64, body []byte) {
+	ins, err := serDiscovery.GetServiceByServerId(serverId)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	connectClient := proto.NewConnectLayerClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = connectClient.PushEventMsg(_ctx, &proto.PushEventMsgReq{
+		GroupId: groupId,
+		Belong:  belong,
+		Body:    body,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+}
+
+// detachGroupChannel 通知connect层将离开群聊的成员的连接从GroupNode中删除
+func (task *Task) detachGroupChannel(serverId string, msg *common.GroupDetachMsg, body []byte) {
+	ins, err := serDiscovery.GetServiceByServerId(serverId)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	connectClient := proto.NewConnectLayerClient(ins.Conn)
+	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = connectClient.DetachGroupChannel(_ctx, &proto.DetachGroupChannelReq{
+		GroupId:   msg.GroupId,
+		Userids:   msg.Userids,
+		Dissolved: msg.Dissolved,
+		Body:      body,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+}
+
 func (task *Task) pushGroupCountMsg(serverId string, msg *common.GroupCountMsg) {
 	var err error
 	connectRpcInstance.ins, err = serDiscovery.GetServiceByServerId(serverId)
@@ -147,62 +199,91 @@ func (task *Task) pushFriendOfflineMsg(serverId string, msg *common.FriendOfflin
 	}
 }
 
-func (task *Task) pushGroupMsg(serverId string, msg *kafka.Message) {
-	var err error
-	connectRpcInstance.ins, err = serDiscovery.GetServiceByServerId(serverId)
-	if err != nil {
-		zlog.Error(err.Error())
-		return
-	}
+// pushGroupMsg 通过PushStream推送群聊消息，双向流无法建立时退化为单次rpc调用
+func (task *Task) pushGroupMsg(serverId string, msg *common.MQMessage) <-chan streamResult {
 	var payload common.MsgSend
 	payload.Msg = new(proto.PushGroupMsgReq_Msg)
 	_ = json.Unmarshal(msg.Value, &payload)
 
-	// todo 为保证消息持久化到db后到时序性，采用分布式系统中常用的snow flake ID的方法
-	payload.Msg.(*proto.PushGroupMsgReq_Msg).SnowId = utils.GetSnowflakeId()
+	// snowId在logic层生产消息时分配，重传时保持不变，兼容升级前已经写入topic但没有snowId的消息
+	if payload.Msg.(*proto.PushGroupMsgReq_Msg).SnowId == "" {
+		snowId, err := utils.GetSnowflakeId()
+		if err != nil {
+			zlog.Error(fmt.Sprintf("generate snowflake id err:%v", err))
+			return failedResult(err)
+		}
+		payload.Msg.(*proto.PushGroupMsgReq_Msg).SnowId = snowId
+	}
 
-	connectClient := proto.NewConnectLayerClient(connectRpcInstance.ins.Conn)
-	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
-	_, err = connectClient.PushGroupMsg(_ctx, &proto.PushGroupMsgReq{
-		Msg: payload.Msg.(*proto.PushGroupMsgReq_Msg),
-		KafkaInfo: &proto.KafkaMsgInfo{
-			Topic:     msg.Topic,
-			Partition: int32(msg.Partition),
-			Offset:    msg.Offset,
-		},
-	})
-	if err != nil {
-		zlog.Error(err.Error())
+	item := &proto.PushStreamReq_Item{
+		GroupMsg:  payload.Msg.(*proto.PushGroupMsgReq_Msg),
+		KafkaInfo: toKafkaMsgInfo(msg),
 	}
+	stream, err := getConnectStream(serverId)
+	if err == nil {
+		return stream.Push(item)
+	}
+	zlog.Error(err.Error())
+	return task.pushChatMsgByRpc(serverId, func(client proto.ConnectLayerClient, ctx context.Context) (*proto.PushMsgReply, error) {
+		return client.PushGroupMsg(ctx, &proto.PushGroupMsgReq{Msg: item.GroupMsg, KafkaInfo: item.KafkaInfo})
+	})
 }
 
-func (task *Task) pushFriendMsg(serverId string, msg *kafka.Message) {
-	var err error
-	connectRpcInstance.ins, err = serDiscovery.GetServiceByServerId(serverId)
-	if err != nil {
-		zlog.Error(err.Error())
-		return
-	}
+// pushFriendMsg 通过PushStream推送好友消息，双向流无法建立时退化为单次rpc调用
+func (task *Task) pushFriendMsg(serverId string, msg *common.MQMessage) <-chan streamResult {
 	var payload common.MsgSend
 	payload.Msg = new(proto.PushFriendMsgReq_Msg)
 	_ = json.Unmarshal(msg.Value, &payload)
 
-	// todo 为保证消息持久化到db后到时序性，采用分布式系统中常用的snow flake ID的方法
-	payload.Msg.(*proto.PushFriendMsgReq_Msg).SnowId = utils.GetSnowflakeId()
+	// snowId在logic层生产消息时分配，重传时保持不变，兼容升级前已经写入topic但没有snowId的消息
+	if payload.Msg.(*proto.PushFriendMsgReq_Msg).SnowId == "" {
+		snowId, err := utils.GetSnowflakeId()
+		if err != nil {
+			zlog.Error(fmt.Sprintf("generate snowflake id err:%v", err))
+			return failedResult(err)
+		}
+		payload.Msg.(*proto.PushFriendMsgReq_Msg).SnowId = snowId
+	}
 
-	connectClient := proto.NewConnectLayerClient(connectRpcInstance.ins.Conn)
+	item := &proto.PushStreamReq_Item{
+		FriendMsg: payload.Msg.(*proto.PushFriendMsgReq_Msg),
+		KafkaInfo: toKafkaMsgInfo(msg),
+	}
+	stream, err := getConnectStream(serverId)
+	if err == nil {
+		return stream.Push(item)
+	}
+	zlog.Error(err.Error())
+	return task.pushChatMsgByRpc(serverId, func(client proto.ConnectLayerClient, ctx context.Context) (*proto.PushMsgReply, error) {
+		return client.PushFriendMsg(ctx, &proto.PushFriendMsgReq{Msg: item.FriendMsg, KafkaInfo: item.KafkaInfo})
+	})
+}
+
+// pushChatMsgByRpc 同步调用connect层的单次推送rpc，为保证消息的有序性调用方会阻塞直到rpc返回
+func (task *Task) pushChatMsgByRpc(serverId string, call func(client proto.ConnectLayerClient, ctx context.Context) (*proto.PushMsgReply, error)) <-chan streamResult {
+	res := make(chan streamResult, 1)
+	ins, err := serDiscovery.GetServiceByServerId(serverId)
+	if err != nil {
+		zlog.Error(err.Error())
+		res <- streamResult{result: proto.PushResult_UNKNOWN, err: err}
+		return res
+	}
 	_ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	_, err = connectClient.PushFriendMsg(_ctx, &proto.PushFriendMsgReq{
-		Msg: payload.Msg.(*proto.PushFriendMsgReq_Msg),
-		KafkaInfo: &proto.KafkaMsgInfo{
-			Topic:     msg.Topic,
-			Partition: int32(msg.Partition),
-			Offset:    msg.Offset,
-		},
-	})
+	reply, err := call(proto.NewConnectLayerClient(ins.Conn), _ctx)
 	if err != nil {
 		zlog.Error(err.Error())
+		res <- streamResult{result: proto.PushResult_UNKNOWN, err: err}
+		return res
+	}
+	res <- streamResult{result: reply.Result}
+	return res
+}
+
+func toKafkaMsgInfo(msg *common.MQMessage) *proto.KafkaMsgInfo {
+	return &proto.KafkaMsgInfo{
+		Topic:     msg.Topic,
+		Partition: int32(msg.Partition),
+		Offset:    msg.Offset,
 	}
 }
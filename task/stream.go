@@ -0,0 +1,186 @@
+package task
+
+import (
+	"axisChat/proto"
+	"axisChat/utils/zlog"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+/*
+*Author:AxisZql
+*Date:2022-7-22
+*Desc: task层和每个connect实例之间维护一条PushStream双向流，批量推送聊天消息并接收逐条的投递结果
+ */
+
+const (
+	streamBatchSize  = 32                   // 每批最多推送的消息数目
+	streamBatchWait  = 5 * time.Millisecond // 凑够一批消息的最长等待时间
+	streamResultWait = 35 * time.Second     // 等待投递结果的最长时间，需要大于connect层的ackTimeout
+)
+
+type streamResult struct {
+	result proto.PushResult
+	err    error
+}
+
+type connectStream struct {
+	serverId string
+	stream   proto.ConnectLayer_PushStreamClient
+	cancel   context.CancelFunc
+	items    chan *proto.PushStreamReq_Item
+
+	mutex   sync.Mutex
+	seq     int64
+	waiters map[int64]chan streamResult // seq和等待投递结果的调用方之间的映射
+	err     error                       // 流被关闭的原因，不为nil时不再接受新的消息
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+type ConnectStreams struct {
+	mutex   sync.Mutex
+	streams map[string]*connectStream // serverId和对应双向流之间的映射
+}
+
+var connectStreams = &ConnectStreams{
+	streams: make(map[string]*connectStream),
+}
+
+// getConnectStream 获取serverId对应的双向流，不存在或者已经断开时重新建立
+func getConnectStream(serverId string) (*connectStream, error) {
+	connectStreams.mutex.Lock()
+	defer connectStreams.mutex.Unlock()
+	if s, ok := connectStreams.streams[serverId]; ok {
+		return s, nil
+	}
+	ins, err := serDiscovery.GetServiceByServerId(serverId)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := proto.NewConnectLayerClient(ins.Conn).PushStream(ctx)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, fmt.Sprintf("open push stream to serverId=%s failure", serverId))
+	}
+	s := &connectStream{
+		serverId: serverId,
+		stream:   stream,
+		cancel:   cancel,
+		items:    make(chan *proto.PushStreamReq_Item, streamBatchSize),
+		waiters:  make(map[int64]chan streamResult),
+		closed:   make(chan struct{}),
+	}
+	connectStreams.streams[serverId] = s
+	go s.sendLoop()
+	go s.recvLoop()
+	zlog.Info(fmt.Sprintf("success open push stream to serverId=%s", serverId))
+	return s, nil
+}
+
+// Push 按调用顺序推送一条消息，不等待投递结果，connect层回传的投递结果会写入返回的channel
+func (s *connectStream) Push(item *proto.PushStreamReq_Item) <-chan streamResult {
+	res := make(chan streamResult, 1)
+	s.mutex.Lock()
+	if s.err != nil {
+		s.mutex.Unlock()
+		res <- streamResult{result: proto.PushResult_UNKNOWN, err: s.err}
+		return res
+	}
+	s.seq++
+	seq := s.seq
+	item.Seq = seq
+	s.waiters[seq] = res
+	s.mutex.Unlock()
+
+	select {
+	case s.items <- item:
+	case <-s.closed:
+		// 流关闭时所有等待中的调用方都会收到错误
+	}
+	// 只有rpc本身出现异常时才会超时，正常情况下connect层总会回传投递结果
+	time.AfterFunc(streamResultWait, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, ok := s.waiters[seq]; ok {
+			delete(s.waiters, seq)
+			res <- streamResult{
+				result: proto.PushResult_UNKNOWN,
+				err:    errors.New(fmt.Sprintf("wait push result from serverId=%s timeout", s.serverId)),
+			}
+		}
+	})
+	return res
+}
+
+func (s *connectStream) sendLoop() {
+	for {
+		var item *proto.PushStreamReq_Item
+		select {
+		case <-s.closed:
+			return
+		case item = <-s.items:
+		}
+		req := &proto.PushStreamReq{Items: []*proto.PushStreamReq_Item{item}}
+		timer := time.NewTimer(streamBatchWait)
+	collect:
+		for len(req.Items) < streamBatchSize {
+			select {
+			case item = <-s.items:
+				req.Items = append(req.Items, item)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		if err := s.stream.Send(req); err != nil {
+			s.close(errors.Wrap(err, fmt.Sprintf("push stream to serverId=%s send failure", s.serverId)))
+			return
+		}
+	}
+}
+
+func (s *connectStream) recvLoop() {
+	for {
+		reply, err := s.stream.Recv()
+		if err != nil {
+			s.close(errors.Wrap(err, fmt.Sprintf("push stream to serverId=%s recv failure", s.serverId)))
+			return
+		}
+		s.mutex.Lock()
+		for _, r := range reply.Results {
+			if res, ok := s.waiters[r.Seq]; ok {
+				delete(s.waiters, r.Seq)
+				res <- streamResult{result: r.Result}
+			}
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// close 关闭双向流，所有等待投递结果的调用方都会收到err，下一次推送时会重新建立
+func (s *connectStream) close(err error) {
+	s.once.Do(func() {
+		zlog.Error(err.Error())
+		connectStreams.mutex.Lock()
+		if connectStreams.streams[s.serverId] == s {
+			delete(connectStreams.streams, s.serverId)
+		}
+		connectStreams.mutex.Unlock()
+
+		s.mutex.Lock()
+		s.err = err
+		for seq, res := range s.waiters {
+			delete(s.waiters, seq)
+			res <- streamResult{result: proto.PushResult_UNKNOWN, err: err}
+		}
+		s.mutex.Unlock()
+		close(s.closed)
+		s.cancel()
+	})
+}
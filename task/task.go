@@ -1,6 +1,9 @@
 package task
 
-import "runtime"
+import (
+	"axisChat/common"
+	"runtime"
+)
 
 /**
 *Author:AxisZql
@@ -17,6 +20,10 @@ func New() *Task {
 func (task *Task) Run() {
 	// 配置connect层最多使用的核心数为4
 	runtime.GOMAXPROCS(runtime.NumCPU())
+	// 从etcd租用集群内唯一的snowflake workerId
+	if err := common.InitSnowflakeNode("task"); err != nil {
+		panic(err)
+	}
 	// 初始化connectRcp服务客户端
 	task.InitConnectRpcClient()
 	// 初始化消息kafka消息消费reader
@@ -1,11 +1,153 @@
 package utils
 
-import "github.com/bwmarrin/snowflake"
-
-func GetSnowflakeId() string {
-	// default node id eq 1,this can modify to different serverId node
-	node, _ := snowflake.NewNode(1)
-	// Generate a snowflake ID
-	id := node.Generate().String()
-	return id
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/**
+*Author: AxisZql
+*Date: 2022-7-25
+*DESC: snowflake id generator, 41位毫秒时间戳 + 10位workerId + 12位序列号，
+*      workerId由etcd在集群范围内分配，保证不同进程生成的id不会冲突
+ */
+
+const (
+	SnowflakeEpoch     int64 = 1288834974657 // 与twitter snowflake的起始时间保持一致，兼容之前生成的id
+	SnowflakeWorkerMax int64 = 1<<workerBits - 1
+
+	workerBits  = 10
+	stepBits    = 12
+	stepMask    = 1<<stepBits - 1
+	timeShift   = workerBits + stepBits
+	workerShift = stepBits
+
+	// 时钟回拨在该范围内时等待时钟追上，超过该范围直接返回错误
+	maxClockBackward = 10 * time.Millisecond
+)
+
+var (
+	ErrClockBackward  = errors.New("clock moved backwards")
+	ErrNoSnowflake    = errors.New("snowflake worker id is not allocated")
+	snowflakeMutex    sync.RWMutex
+	snowflakeInstance *Snowflake
+	snowflakeDisabled bool // workerId的租约失效后，重新分配workerId之前不允许生成id
+)
+
+type Snowflake struct {
+	mutex    sync.Mutex
+	workerId int64
+	lastTime int64 // 上一次生成id的毫秒时间戳（相对于SnowflakeEpoch）
+	step     int64
+	now      func() int64
+}
+
+func NewSnowflake(workerId int64) (*Snowflake, error) {
+	if workerId < 0 || workerId > SnowflakeWorkerMax {
+		return nil, errors.New(fmt.Sprintf("snowflake worker id must be between 0 and %d", SnowflakeWorkerMax))
+	}
+	return &Snowflake{
+		workerId: workerId,
+		now: func() int64 {
+			return time.Now().UnixNano()/int64(time.Millisecond) - SnowflakeEpoch
+		},
+	}, nil
+}
+
+func (s *Snowflake) WorkerId() int64 {
+	return s.workerId
+}
+
+// NextId 生成下一个id，同一个generator生成的id严格递增
+func (s *Snowflake) NextId() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	if now < s.lastTime {
+		// 时钟回拨：小范围回拨时等待时钟追上上一次的时间戳，否则拒绝生成id，防止生成重复的id
+		backward := time.Duration(s.lastTime-now) * time.Millisecond
+		if backward > maxClockBackward {
+			return 0, errors.Wrap(ErrClockBackward, fmt.Sprintf("refusing to generate id for %v", backward))
+		}
+		time.Sleep(backward)
+		for now = s.now(); now < s.lastTime; now = s.now() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if now == s.lastTime {
+		s.step = (s.step + 1) & stepMask
+		if s.step == 0 {
+			// 当前毫秒的序列号已经用完，等待下一毫秒
+			for now <= s.lastTime {
+				now = s.now()
+			}
+		}
+	} else {
+		s.step = 0
+	}
+	s.lastTime = now
+	return now<<timeShift | s.workerId<<workerShift | s.step, nil
+}
+
+func (s *Snowflake) NextIdString() (string, error) {
+	id, err := s.NextId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// InitSnowflake 设置当前进程使用的generator，每个进程只保留一个generator
+func InitSnowflake(workerId int64) error {
+	s, err := NewSnowflake(workerId)
+	if err != nil {
+		return err
+	}
+	snowflakeMutex.Lock()
+	if old := snowflakeInstance; old != nil {
+		// 重新分配到同一个workerId时，保留上一次的时间戳，防止新的generator在同一毫秒内生成相同的id
+		old.mutex.Lock()
+		s.lastTime, s.step = old.lastTime, old.step
+		old.mutex.Unlock()
+	}
+	snowflakeInstance = s
+	snowflakeDisabled = false
+	snowflakeMutex.Unlock()
+	return nil
+}
+
+// DisableSnowflake workerId不再属于当前进程时调用，直到下一次InitSnowflake之前生成id都会返回错误
+func DisableSnowflake() {
+	snowflakeMutex.Lock()
+	snowflakeDisabled = true
+	snowflakeMutex.Unlock()
+}
+
+func getSnowflake() (*Snowflake, error) {
+	snowflakeMutex.RLock()
+	defer snowflakeMutex.RUnlock()
+	if snowflakeInstance == nil || snowflakeDisabled {
+		return nil, ErrNoSnowflake
+	}
+	return snowflakeInstance, nil
+}
+
+func NextSnowflakeId() (int64, error) {
+	s, err := getSnowflake()
+	if err != nil {
+		return 0, err
+	}
+	return s.NextId()
+}
+
+func GetSnowflakeId() (string, error) {
+	s, err := getSnowflake()
+	if err != nil {
+		return "", err
+	}
+	return s.NextIdString()
 }
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestSnowflakeNextId(t *testing.T) {
+	s, err := NewSnowflake(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pre int64
+	for i := 0; i < 10000; i++ {
+		id, err := s.NextId()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= pre {
+			t.Fatalf("id %d not greater than previous %d", id, pre)
+		}
+		if (id>>workerShift)&SnowflakeWorkerMax != 3 {
+			t.Fatalf("id %d get wrong worker id", id)
+		}
+		pre = id
+	}
+	if _, err = NewSnowflake(SnowflakeWorkerMax + 1); err == nil {
+		t.Fatal("worker id out of range should be rejected")
+	}
+}
+
+func TestSnowflakeClockBackward(t *testing.T) {
+	s, _ := NewSnowflake(1)
+	now := int64(1000)
+	s.now = func() int64 {
+		return now
+	}
+	first, _ := s.NextId()
+
+	// 小范围的时钟回拨会等待时钟追上
+	clock := []int64{995, 998, 1001}
+	s.now = func() int64 {
+		now, clock = clock[0], clock[1:]
+		return now
+	}
+	second, err := s.NextId()
+	if err != nil || second <= first {
+		t.Fatalf("small clock backward got %d %v", second, err)
+	}
+
+	// 大范围的时钟回拨直接返回错误
+	s.now = func() int64 {
+		return 1
+	}
+	if _, err = s.NextId(); err == nil {
+		t.Fatal("large clock backward should return error")
+	}
+}